@@ -41,7 +41,7 @@ func Product(A MatrixRO, Bs ...MatrixRO) (C *DenseMatrix) {
 	for _, B := range Bs {
 		Cm, err := C.Times(B)
 		if err != nil {
-			return
+			return nil
 		}
 		C = Cm.(*DenseMatrix)
 	}
@@ -108,54 +108,25 @@ func Unvectorize(V MatrixRO, rows, cols uint) (A *DenseMatrix) {
 }
 
 /*
-Uses a number of goroutines to do the dot products necessary
-for the matrix multiplication in parallel.
+Computes the product of two matrices with the blocked dense multiply, using
+workers goroutines. A non-positive workers selects runtime.GOMAXPROCS(0).
 */
-func ParallelProduct(A, B MatrixRO) (C *DenseMatrix) {
+func ParallelProduct(A, B MatrixRO, workers int) (C *DenseMatrix) {
 	if A.Cols() != B.Rows() {
 		return nil
 	}
 
-	C = Zeros(A.Rows(), B.Cols())
-
-	in := make(chan uint)
-	quit := make(chan bool)
-
-	dotRowCol := func() {
-		for {
-			select {
-			case i := <-in:
-				sums := make([]float64, B.Cols())
-				var k, j uint
-				for k = 0; k < A.Cols(); k++ {
-					for j = 0; j < B.Cols(); j++ {
-						sums[j] += A.Get(i, k) * B.Get(k, j)
-					}
-				}
-				for j = 0; j < B.Cols(); j++ {
-					C.Set(i, j, sums[j])
-				}
-			case <-quit:
-				return
-			}
-		}
-	}
-
-	var threads uint = 2
-
-	var i uint
-	for i = 0; i < threads; i++ {
-		go dotRowCol()
-	}
-
-	for i = 0; i < A.Rows(); i++ {
-		in <- i
+	Ad, ok := A.(*DenseMatrix)
+	if !ok {
+		Ad = MakeDenseCopy(A)
 	}
-
-	for i = 0; i < threads; i++ {
-		quit <- true
+	Bd, ok := B.(*DenseMatrix)
+	if !ok {
+		Bd = MakeDenseCopy(B)
 	}
 
+	C = Zeros(A.Rows(), B.Cols())
+	GemmOptions{Workers: workers}.Gemm(false, false, 1, Ad, Bd, 0, C)
 	return
 }
 
@@ -0,0 +1,358 @@
+package math
+
+import "math"
+
+/*
+BLAS style primitives, for code ported from Fortran and C references. The
+level 1 and 2 routines take their vectors as *Vector; a vector that is only
+read may be sparse. Matrices are DenseMatrix values of any stride, so views
+stand in for the submatrices and leading dimensions of the reference BLAS.
+The character options become flags and, as with Gemm, the output comes
+last. Dimension errors are returned before anything is written.
+*/
+
+// y = alpha*x + y
+func Axpy(alpha float64, x, y *Vector) error { return y.Axpy(alpha, x) }
+
+// x = alpha*x
+func Scal(alpha float64, x *Vector) { x.Scale(alpha) }
+
+// The Euclidean norm of x
+func Nrm2(x *Vector) float64 { return x.Norm() }
+
+// The sum of the absolute values of x
+func Asum(x *Vector) float64 { return x.Norm1() }
+
+// The index of the element of x with the largest absolute value, the first
+// one if there are several; -1 if x stores no elements
+func Iamax(x *Vector) int {
+	k, best := -1, -1.0
+	for p, v := range x.values {
+		if a := math.Abs(v); a > best {
+			k, best = p, a
+		}
+	}
+	if k >= 0 && x.isSparse {
+		return x.indexes[k]
+	}
+	return k
+}
+
+// Checks that x can be read as a vector of length n
+func (v *Vector) fitsLen(n uint) bool {
+	if !v.isSparse {
+		return uint(len(v.values)) == n
+	}
+	k := len(v.indexes)
+	return k == 0 || uint(v.indexes[k-1]) < n
+}
+
+// The index of stored element k of v
+func (v *Vector) indexOf(k int) uint {
+	if v.isSparse {
+		return uint(v.indexes[k])
+	}
+	return uint(k)
+}
+
+/*
+Computes y = alpha*op(A)*x + beta*y, where op(A) is A or A' depending on
+trans. y must be dense. When beta is zero y is overwritten.
+*/
+func Gemv(trans bool, alpha float64, A *DenseMatrix, x *Vector, beta float64, y *Vector) error {
+	m, n := A.rows, A.cols
+	if trans {
+		m, n = n, m
+	}
+	if y.isSparse || uint(len(y.values)) != m || !x.fitsLen(n) {
+		return ErrorDimensionMismatch
+	}
+	scaleValues(beta, y.values)
+	if alpha == 0 {
+		return nil
+	}
+	if !trans {
+		var i uint
+		for i = 0; i < m; i++ {
+			var s float64
+			for k, v := range x.values {
+				s += A.elements[A.index(i, x.indexOf(k))] * v
+			}
+			y.values[i] += alpha * s
+		}
+		return nil
+	}
+	// y += alpha*x_r*A(r,:) for every row r
+	for k, v := range x.values {
+		r := x.indexOf(k)
+		if v == 0 {
+			continue
+		}
+		av := alpha * v
+		var i uint
+		for i = 0; i < m; i++ {
+			y.values[i] += av * A.elements[A.index(r, i)]
+		}
+	}
+	return nil
+}
+
+// x = beta*x, with a zero beta clearing x
+func scaleValues(beta float64, x []float64) {
+	switch beta {
+	case 1:
+	case 0:
+		for i := range x {
+			x[i] = 0
+		}
+	default:
+		for i := range x {
+			x[i] *= beta
+		}
+	}
+}
+
+/*
+Computes the rank one update A = alpha*x*y' + A.
+*/
+func Ger(alpha float64, x, y *Vector, A *DenseMatrix) error {
+	if !x.fitsLen(A.rows) || !y.fitsLen(A.cols) {
+		return ErrorDimensionMismatch
+	}
+	if alpha == 0 {
+		return nil
+	}
+	for p, xv := range x.values {
+		i := x.indexOf(p)
+		axv := alpha * xv
+		for q, yv := range y.values {
+			A.elements[A.index(i, y.indexOf(q))] += axv * yv
+		}
+	}
+	return nil
+}
+
+// Element i, j of the symmetric matrix stored in the upper or lower triangle
+// of A.
+func symAt(A *DenseMatrix, upper bool, i, j uint) float64 {
+	if (i <= j) != upper {
+		i, j = j, i
+	}
+	return A.elements[A.index(i, j)]
+}
+
+/*
+Computes y = alpha*A*x + beta*y for the symmetric matrix A, of which only
+the upper or the lower triangle is read. y must be dense. When beta is zero
+y is overwritten.
+*/
+func Symv(upper bool, alpha float64, A *DenseMatrix, x *Vector, beta float64, y *Vector) error {
+	n := A.rows
+	if A.cols != n || y.isSparse || uint(len(y.values)) != n || !x.fitsLen(n) {
+		return ErrorDimensionMismatch
+	}
+	scaleValues(beta, y.values)
+	if alpha == 0 {
+		return nil
+	}
+	var i uint
+	for i = 0; i < n; i++ {
+		var s float64
+		for k, v := range x.values {
+			s += symAt(A, upper, i, x.indexOf(k)) * v
+		}
+		y.values[i] += alpha * s
+	}
+	return nil
+}
+
+// Element i, j of op(A), the triangular matrix stored in the upper or lower
+// triangle of A, transposed if trans is set. The other triangle is zero and
+// the diagonal one if unit is set.
+func triAt(A *DenseMatrix, upper, trans, unit bool, i, j uint) float64 {
+	if trans {
+		i, j = j, i
+	}
+	switch {
+	case i == j && unit:
+		return 1
+	case i != j && (i < j) != upper:
+		return 0
+	}
+	return A.elements[A.index(i, j)]
+}
+
+/*
+Computes x = op(A)*x for the triangular matrix A, stored in its upper or
+lower triangle; op(A) is A' if trans is set. With unit set the diagonal is
+taken to be one and not read. x must be dense.
+*/
+func Trmv(upper, trans, unit bool, A *DenseMatrix, x *Vector) error {
+	n := A.rows
+	if A.cols != n || x.isSparse || uint(len(x.values)) != n {
+		return ErrorDimensionMismatch
+	}
+	xs := x.values
+	// an upper op(A) only reads the elements of x not yet overwritten when
+	// going down, a lower one when going up
+	var i, j uint
+	if upper != trans {
+		for i = 0; i < n; i++ {
+			var s float64
+			for j = i; j < n; j++ {
+				s += triAt(A, upper, trans, unit, i, j) * xs[j]
+			}
+			xs[i] = s
+		}
+		return nil
+	}
+	for i = n; i > 0; i-- {
+		var s float64
+		for j = 0; j < i; j++ {
+			s += triAt(A, upper, trans, unit, i-1, j) * xs[j]
+		}
+		xs[i-1] = s
+	}
+	return nil
+}
+
+/*
+Solves op(A)*x = b for the triangular matrix A, see Trmv, overwriting b in
+x with the solution. Returns ExceptionSingular if the diagonal has a zero,
+in which case x is left partly solved.
+*/
+func Trsv(upper, trans, unit bool, A *DenseMatrix, x *Vector) error {
+	n := A.rows
+	if A.cols != n || x.isSparse || uint(len(x.values)) != n {
+		return ErrorDimensionMismatch
+	}
+	xs := x.values
+	solve := func(i uint, s float64) error {
+		d := triAt(A, upper, trans, unit, i, i)
+		if d == 0 {
+			return ExceptionSingular
+		}
+		xs[i] = s / d
+		return nil
+	}
+	var i, j uint
+	if upper == trans {
+		// op(A) is lower triangular: forward substitution
+		for i = 0; i < n; i++ {
+			s := xs[i]
+			for j = 0; j < i; j++ {
+				s -= triAt(A, upper, trans, unit, i, j) * xs[j]
+			}
+			if err := solve(i, s); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for i = n; i > 0; i-- {
+		s := xs[i-1]
+		for j = i; j < n; j++ {
+			s -= triAt(A, upper, trans, unit, i-1, j) * xs[j]
+		}
+		if err := solve(i-1, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Computes the symmetric rank k update C = alpha*A*A' + beta*C, or
+C = alpha*A'*A + beta*C if trans is set. Only the upper or lower triangle of
+C is written; the other is left as it is. When beta is zero the triangle is
+overwritten.
+*/
+func Syrk(upper, trans bool, alpha float64, A *DenseMatrix, beta float64, C *DenseMatrix) error {
+	n := A.rows
+	if trans {
+		n = A.cols
+	}
+	if C.rows != n || C.cols != n {
+		return ErrorDimensionMismatch
+	}
+	// the full product through the blocked kernel, then the triangle
+	T := Zeros(n, n)
+	if alpha != 0 {
+		if err := Gemm(trans, !trans, alpha, A, A, 0, T); err != nil {
+			return err
+		}
+	}
+	var i, j uint
+	for i = 0; i < n; i++ {
+		j0, j1 := uint(0), i+1
+		if upper {
+			j0, j1 = i, n
+		}
+		for j = j0; j < j1; j++ {
+			c := &C.elements[C.index(i, j)]
+			if beta == 0 {
+				*c = T.elements[i*T.step+j]
+			} else {
+				*c = T.elements[i*T.step+j] + beta**c
+			}
+		}
+	}
+	return nil
+}
+
+/*
+Solves op(A)*X = alpha*B if left is set, or X*op(A) = alpha*B otherwise, for
+the triangular matrix A, see Trmv, overwriting B with X. Returns
+ExceptionSingular if the diagonal has a zero, in which case B is left
+partly solved.
+*/
+func Trsm(left, upper, trans, unit bool, alpha float64, A, B *DenseMatrix) error {
+	if !left {
+		// X op(A) = alpha B is op(A)' X' = alpha B', and B' is a view
+		return Trsm(true, upper, !trans, unit, alpha, A, B.T())
+	}
+	m, n := B.rows, B.cols
+	if A.rows != m || A.cols != m {
+		return ErrorDimensionMismatch
+	}
+	var i, j, k uint
+	if alpha != 1 {
+		for i = 0; i < m; i++ {
+			for j = 0; j < n; j++ {
+				B.elements[B.index(i, j)] *= alpha
+			}
+		}
+	}
+
+	// row i of X is row i of B less the rows of X already known, divided by
+	// the diagonal
+	lower := upper == trans
+	for r := uint(0); r < m; r++ {
+		i = r
+		if !lower {
+			i = m - 1 - r
+		}
+		for k = 0; k < m; k++ {
+			if k == i || (k < i) != lower {
+				continue
+			}
+			a := triAt(A, upper, trans, unit, i, k)
+			if a == 0 {
+				continue
+			}
+			for j = 0; j < n; j++ {
+				B.elements[B.index(i, j)] -= a * B.elements[B.index(k, j)]
+			}
+		}
+		d := triAt(A, upper, trans, unit, i, i)
+		if d == 0 {
+			return ExceptionSingular
+		}
+		if d != 1 {
+			for j = 0; j < n; j++ {
+				B.elements[B.index(i, j)] /= d
+			}
+		}
+	}
+	return nil
+}
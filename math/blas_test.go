@@ -0,0 +1,169 @@
+package math
+
+import (
+	"testing"
+
+	"github.com/hezila/hezila/utils"
+)
+
+// x as a column matrix
+func colMatrix(x *Vector) *DenseMatrix {
+	X := Zeros(uint(len(x.values)), 1)
+	for i, v := range x.values {
+		X.Set(uint(i), 0, v)
+	}
+	return X
+}
+
+func vectorOf(A *DenseMatrix) *Vector {
+	v := NewVector(int(A.Rows()))
+	for i := range v.values {
+		v.values[i] = A.Get(uint(i), 0)
+	}
+	return v
+}
+
+func TestBlasLevel1(t *testing.T) {
+	x := NewVector(4)
+	x.SetValues([]float64{3, -4, 4, 0})
+	utils.Expect(t, "1", Iamax(x))
+	utils.Expect(t, "11", Asum(x))
+	s, _ := NewSparseVectorFrom([]int{3, 2}, []float64{-2, 1.5})
+	utils.Expect(t, "3", Iamax(s))
+	utils.Expect(t, "-1", Iamax(NewSparseVector()))
+	Scal(2, s)
+	utils.Expect(t, "7", Asum(s))
+	y := NewVector(4)
+	if err := Axpy(1, s, y); err != nil {
+		t.Fatal(err)
+	}
+	utils.Expect(t, "5", Nrm2(y))
+}
+
+func TestBlasLevel2(t *testing.T) {
+	// a strided view stands in for a matrix with a leading dimension
+	Afull := Normals(10, 12)
+	A := Afull.StridedView(1, 2, 5, 5, 2, 2)
+	x := vectorOf(Normals(5, 1))
+	y0 := vectorOf(Normals(5, 1))
+
+	for _, trans := range []bool{false, true} {
+		op := A
+		if trans {
+			op = A.Transpose()
+		}
+		y := NewVector(5)
+		y.Copy(y0)
+		if err := Gemv(trans, 2, A, x, -1, y); err != nil {
+			t.Fatal(err)
+		}
+		want := Sum(Scaled(Product(op, colMatrix(x)), 2), Scaled(colMatrix(y0), -1))
+		if !ApproxEquals(colMatrix(y), want, 1e-12) {
+			t.Errorf("Gemv trans=%v: %v", trans, y.values)
+		}
+	}
+
+	// the symmetric matrix with A's upper triangle
+	S := A.Copy()
+	var i, j uint
+	for i = 0; i < 5; i++ {
+		for j = 0; j < i; j++ {
+			S.Set(i, j, A.Get(j, i))
+		}
+	}
+	y := NewVector(5)
+	Symv(true, 1, A, x, 0, y)
+	if !ApproxEquals(colMatrix(y), Product(S, colMatrix(x)), 1e-12) {
+		t.Errorf("Symv: %v", y.values)
+	}
+
+	G := A.Copy()
+	Ger(3, x, y0, G)
+	if !ApproxEquals(G, Sum(A, Scaled(Product(colMatrix(x), colMatrix(y0).Transpose()), 3)), 1e-12) {
+		t.Errorf("Ger: %v", G)
+	}
+
+	// Trsv undoes Trmv for every triangle, transpose and diagonal
+	for _, flags := range [][3]bool{{true, false, false}, {false, false, true}, {true, true, false}, {false, true, true}} {
+		T := Normals(5, 5)
+		for i = 0; i < 5; i++ {
+			T.Set(i, i, 4+T.Get(i, i))
+		}
+		z := NewVector(5)
+		z.Copy(x)
+		if err := Trmv(flags[0], flags[1], flags[2], T, z); err != nil {
+			t.Fatal(err)
+		}
+		if err := Trsv(flags[0], flags[1], flags[2], T, z); err != nil {
+			t.Fatal(err)
+		}
+		if !ApproxEquals(colMatrix(z), colMatrix(x), 1e-12) {
+			t.Errorf("Trsv %v: %v", flags, z.values)
+		}
+	}
+	if err := Trsv(true, false, false, Zeros(2, 2), NewVector(2)); err != ExceptionSingular {
+		t.Errorf("expected %v, got %v", ExceptionSingular, err)
+	}
+}
+
+func TestBlasLevel3(t *testing.T) {
+	A := Normals(6, 4)
+	C0 := Normals(6, 6)
+	for _, trans := range []bool{false, true} {
+		// A'A of the transposed view is AA' again
+		X := A
+		if trans {
+			X = A.T()
+		}
+		C := C0.Copy()
+		if err := Syrk(false, trans, 2, X, 0.5, C); err != nil {
+			t.Fatal(err)
+		}
+		full := Product(A, A.Transpose())
+		var i, j uint
+		for i = 0; i < 6; i++ {
+			for j = 0; j < 6; j++ {
+				want := C0.Get(i, j)
+				if j <= i {
+					want = 2*full.Get(i, j) + 0.5*want
+				}
+				if d := C.Get(i, j) - want; d > 1e-12 || d < -1e-12 {
+					t.Errorf("Syrk trans=%v: C(%d,%d)=%v, expected %v", trans, i, j, C.Get(i, j), want)
+				}
+			}
+		}
+	}
+
+	T := Normals(4, 4)
+	for i := uint(0); i < 4; i++ {
+		T.Set(i, i, 4+T.Get(i, i))
+	}
+	for _, left := range []bool{true, false} {
+		for _, upper := range []bool{true, false} {
+			for _, trans := range []bool{true, false} {
+				op := Zeros(4, 4)
+				var i, j uint
+				for i = 0; i < 4; i++ {
+					for j = 0; j < 4; j++ {
+						op.Set(i, j, triAt(T, upper, trans, false, i, j))
+					}
+				}
+				B := Normals(4, 3)
+				if !left {
+					B = Normals(3, 4)
+				}
+				X := B.Copy()
+				if err := Trsm(left, upper, trans, false, 2, T, X); err != nil {
+					t.Fatal(err)
+				}
+				AX := Product(op, X)
+				if !left {
+					AX = Product(X, op)
+				}
+				if !ApproxEquals(AX, Scaled(B, 2), 1e-10) {
+					t.Errorf("Trsm left=%v upper=%v trans=%v", left, upper, trans)
+				}
+			}
+		}
+	}
+}
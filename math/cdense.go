@@ -0,0 +1,318 @@
+package math
+
+import (
+	"math"
+	"math/cmplx"
+	"strings"
+)
+
+/*
+A dense matrix of complex128 elements, stored row after row. Its elements
+are not float64, so it does not implement MatrixRO; Real, Imag and
+MakeCDenseCopy convert to and from the real types.
+*/
+type CDenseMatrix struct {
+	matrix
+
+	// flatted elements, row after row
+	elements []complex128
+}
+
+func CZeros(rows, cols uint) *CDenseMatrix {
+	return MakeCDenseMatrix(make([]complex128, rows*cols), rows, cols)
+}
+
+func CEye(span uint) *CDenseMatrix {
+	A := CZeros(span, span)
+	for i := uint(0); i < span; i++ {
+		A.elements[i*span+i] = 1
+	}
+	return A
+}
+
+/*
+Makes a CDenseMatrix of the row-major elements, which are not copied.
+*/
+func MakeCDenseMatrix(elements []complex128, rows, cols uint) *CDenseMatrix {
+	A := new(CDenseMatrix)
+	A.rows = rows
+	A.cols = cols
+	A.elements = elements
+	return A
+}
+
+func MakeCDenseMatrixStacked(data [][]complex128) *CDenseMatrix {
+	if len(data) == 0 {
+		return CZeros(0, 0)
+	}
+	rows := uint(len(data))
+	cols := uint(len(data[0]))
+	A := CZeros(rows, cols)
+	for i := uint(0); i < rows; i++ {
+		copy(A.elements[i*cols:(i+1)*cols], data[i])
+	}
+	return A
+}
+
+/*
+Returns the complex matrix with real part A and zero imaginary part.
+*/
+func MakeCDenseCopy(A MatrixRO) *CDenseMatrix {
+	return MakeCDenseParts(A, nil)
+}
+
+/*
+Returns the complex matrix re + i*im. A nil im is zero. Returns nil if the
+dimensions differ.
+*/
+func MakeCDenseParts(re, im MatrixRO) *CDenseMatrix {
+	if im != nil && (re.Rows() != im.Rows() || re.Cols() != im.Cols()) {
+		return nil
+	}
+	A := CZeros(re.Rows(), re.Cols())
+	var i, j uint
+	for i = 0; i < A.rows; i++ {
+		for j = 0; j < A.cols; j++ {
+			var y float64
+			if im != nil {
+				y = im.Get(i, j)
+			}
+			A.elements[i*A.cols+j] = complex(re.Get(i, j), y)
+		}
+	}
+	return A
+}
+
+func (A *CDenseMatrix) Get(i, j uint) complex128 {
+	if i >= A.rows || j >= A.cols {
+		panic(ErrorIllegalIndex)
+	}
+	return A.elements[i*A.cols+j]
+}
+
+func (A *CDenseMatrix) Set(i, j uint, v complex128) {
+	if i >= A.rows || j >= A.cols {
+		panic(ErrorIllegalIndex)
+	}
+	A.elements[i*A.cols+j] = v
+}
+
+/*
+Returns the row-major elements. The slice references the matrix data.
+*/
+func (A *CDenseMatrix) Array() []complex128 { return A.elements }
+
+/*
+Returns one slice per row, referencing the matrix data.
+*/
+func (A *CDenseMatrix) Arrays() [][]complex128 {
+	a := make([][]complex128, A.rows)
+	for i := uint(0); i < A.rows; i++ {
+		a[i] = A.elements[i*A.cols : (i+1)*A.cols]
+	}
+	return a
+}
+
+func (A *CDenseMatrix) Copy() *CDenseMatrix {
+	B := CZeros(A.rows, A.cols)
+	copy(B.elements, A.elements)
+	return B
+}
+
+// Applies f to every element of A, in a new matrix of the same shape.
+func (A *CDenseMatrix) realParts(f func(complex128) float64) *DenseMatrix {
+	B := Zeros(A.rows, A.cols)
+	for p, v := range A.elements {
+		B.elements[p] = f(v)
+	}
+	return B
+}
+
+// The real part of A
+func (A *CDenseMatrix) Real() *DenseMatrix {
+	return A.realParts(func(c complex128) float64 { return real(c) })
+}
+
+// The imaginary part of A
+func (A *CDenseMatrix) Imag() *DenseMatrix {
+	return A.realParts(func(c complex128) float64 { return imag(c) })
+}
+
+// The element-wise absolute values of A
+func (A *CDenseMatrix) Abs() *DenseMatrix { return A.realParts(cmplx.Abs) }
+
+func (A *CDenseMatrix) Transpose() *CDenseMatrix {
+	B := CZeros(A.cols, A.rows)
+	var i, j uint
+	for i = 0; i < A.rows; i++ {
+		for j = 0; j < A.cols; j++ {
+			B.elements[j*B.cols+i] = A.elements[i*A.cols+j]
+		}
+	}
+	return B
+}
+
+/*
+Returns the conjugate transpose A^H.
+*/
+func (A *CDenseMatrix) ConjTranspose() *CDenseMatrix {
+	B := A.Transpose()
+	B.conjInPlace()
+	return B
+}
+
+/*
+Returns the element-wise conjugate of A.
+*/
+func (A *CDenseMatrix) Conj() *CDenseMatrix {
+	B := A.Copy()
+	B.conjInPlace()
+	return B
+}
+
+func (A *CDenseMatrix) conjInPlace() {
+	for p, v := range A.elements {
+		A.elements[p] = cmplx.Conj(v)
+	}
+}
+
+func (A *CDenseMatrix) Trace() (res complex128) {
+	for i := uint(0); i < minUInt(A.rows, A.cols); i++ {
+		res += A.elements[i*A.cols+i]
+	}
+	return
+}
+
+// A = A + B
+func (A *CDenseMatrix) Add(B *CDenseMatrix) error {
+	if A.rows != B.rows || A.cols != B.cols {
+		return ErrorDimensionMismatch
+	}
+	for p, v := range B.elements {
+		A.elements[p] += v
+	}
+	return nil
+}
+
+// A = A - B
+func (A *CDenseMatrix) Subtract(B *CDenseMatrix) error {
+	if A.rows != B.rows || A.cols != B.cols {
+		return ErrorDimensionMismatch
+	}
+	for p, v := range B.elements {
+		A.elements[p] -= v
+	}
+	return nil
+}
+
+// A = f*A
+func (A *CDenseMatrix) Scale(f complex128) {
+	for p := range A.elements {
+		A.elements[p] *= f
+	}
+}
+
+/*
+Returns the product AB.
+*/
+func (A *CDenseMatrix) Times(B *CDenseMatrix) (*CDenseMatrix, error) {
+	if A.cols != B.rows {
+		return nil, ErrorDimensionMismatch
+	}
+	C := CZeros(A.rows, B.cols)
+	var i, k uint
+	for i = 0; i < A.rows; i++ {
+		crow := C.elements[i*C.cols : (i+1)*C.cols]
+		for k = 0; k < A.cols; k++ {
+			a := A.elements[i*A.cols+k]
+			if a == 0 {
+				continue
+			}
+			for j, b := range B.elements[k*B.cols : (k+1)*B.cols] {
+				crow[j] += a * b
+			}
+		}
+	}
+	return C, nil
+}
+
+/*
+Returns y = Ax.
+*/
+func (A *CDenseMatrix) MulVec(x []complex128) ([]complex128, error) {
+	if uint(len(x)) != A.cols {
+		return nil, ErrorDimensionMismatch
+	}
+	y := make([]complex128, A.rows)
+	for i := uint(0); i < A.rows; i++ {
+		for j, a := range A.elements[i*A.cols : (i+1)*A.cols] {
+			y[i] += a * x[j]
+		}
+	}
+	return y, nil
+}
+
+/*
+Tests to see if the difference between two complex matrices, element-wise,
+exceeds ε in absolute value.
+*/
+func CApproxEquals(A, B *CDenseMatrix, ε float64) bool {
+	if A.rows != B.rows || A.cols != B.cols {
+		return false
+	}
+	for p, v := range A.elements {
+		if cmplx.Abs(v-B.elements[p]) > ε {
+			return false
+		}
+	}
+	return true
+}
+
+// Formats c like String formats a real element, with the imaginary part
+// appended as in MATLAB: 1+2i, -3i, 4.
+func formatComplex(c complex128) string {
+	re, im := real(c), imag(c)
+	if im == 0 {
+		return formatMatlabFloat(re, -1)
+	}
+	ims := formatMatlabFloat(math.Abs(im), -1) + "i"
+	if math.Signbit(im) {
+		ims = "-" + ims
+	} else if re != 0 {
+		ims = "+" + ims
+	}
+	if re == 0 {
+		return ims
+	}
+	return formatMatlabFloat(re, -1) + ims
+}
+
+func (A *CDenseMatrix) String() string {
+	if A == nil {
+		return "{nil}"
+	}
+	var maxLen int
+	vs := make([]string, len(A.elements))
+	for p, v := range A.elements {
+		vs[p] = formatComplex(v)
+		maxLen = maxInt(maxLen, len(vs[p]))
+	}
+	s := "{"
+	var i, j uint
+	for i = 0; i < A.rows; i++ {
+		for j = 0; j < A.cols; j++ {
+			v := vs[i*A.cols+j]
+			s += strings.Repeat(" ", maxLen-len(v)) + v
+			if i != A.rows-1 || j != A.cols-1 {
+				s += ","
+			}
+			if j != A.cols-1 {
+				s += " "
+			}
+		}
+		if i != A.rows-1 {
+			s += "\n "
+		}
+	}
+	return s + "}"
+}
@@ -0,0 +1,223 @@
+package math
+
+import "math/cmplx"
+
+/*
+The LU factorization with partial pivoting PLU = A of a square complex
+matrix, see LU.
+*/
+type CLU struct {
+	// L below the unit diagonal, U on and above it
+	lu *CDenseMatrix
+
+	// row i of LU comes from row piv[i] of A
+	piv     []uint
+	pivsign float64
+}
+
+/*
+Computes the LU factorization of the square matrix A. A is left untouched.
+*/
+func (A *CDenseMatrix) LU() (*CLU, error) {
+	if A.rows != A.cols {
+		return nil, ErrorDimensionMismatch
+	}
+	n := A.rows
+	F := &CLU{lu: A.Copy(), piv: make([]uint, n), pivsign: 1}
+	LU := F.lu.elements
+	var i, j, k uint
+	for i = 0; i < n; i++ {
+		F.piv[i] = i
+	}
+	for k = 0; k < n; k++ {
+		p := k
+		for i = k + 1; i < n; i++ {
+			if cmplx.Abs(LU[i*n+k]) > cmplx.Abs(LU[p*n+k]) {
+				p = i
+			}
+		}
+		if p != k {
+			for j = 0; j < n; j++ {
+				LU[p*n+j], LU[k*n+j] = LU[k*n+j], LU[p*n+j]
+			}
+			F.piv[p], F.piv[k] = F.piv[k], F.piv[p]
+			F.pivsign = -F.pivsign
+		}
+
+		akk := LU[k*n+k]
+		if akk == 0 {
+			continue
+		}
+		for i = k + 1; i < n; i++ {
+			LU[i*n+k] /= akk
+			lik := LU[i*n+k]
+			if lik == 0 {
+				continue
+			}
+			for j = k + 1; j < n; j++ {
+				LU[i*n+j] -= lik * LU[k*n+j]
+			}
+		}
+	}
+	return F, nil
+}
+
+/*
+The dimension of the factorized matrix.
+*/
+func (F *CLU) Size() uint { return F.lu.rows }
+
+/*
+Returns the unit lower triangular factor L.
+*/
+func (F *CLU) L() *CDenseMatrix {
+	n := F.lu.rows
+	L := CEye(n)
+	var i, j uint
+	for i = 0; i < n; i++ {
+		for j = 0; j < i; j++ {
+			L.elements[i*n+j] = F.lu.elements[i*n+j]
+		}
+	}
+	return L
+}
+
+/*
+Returns the upper triangular factor U.
+*/
+func (F *CLU) U() *CDenseMatrix {
+	n := F.lu.rows
+	U := CZeros(n, n)
+	var i, j uint
+	for i = 0; i < n; i++ {
+		for j = i; j < n; j++ {
+			U.elements[i*n+j] = F.lu.elements[i*n+j]
+		}
+	}
+	return U
+}
+
+/*
+Returns the pivot matrix P, st PLU=A.
+*/
+func (F *CLU) P() *PivotMatrix {
+	piv := make([]uint, len(F.piv))
+	copy(piv, F.piv)
+	return MakePivotMatrix(piv, F.pivsign)
+}
+
+/*
+Returns true if U has a zero on its diagonal.
+*/
+func (F *CLU) Singular() bool {
+	n := F.lu.rows
+	for i := uint(0); i < n; i++ {
+		if F.lu.elements[i*n+i] == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Returns X such that AX=B, solving for every column of B at once.
+*/
+func (F *CLU) Solve(B *CDenseMatrix) (*CDenseMatrix, error) {
+	n := F.lu.rows
+	if B.rows != n {
+		return nil, ErrorDimensionMismatch
+	}
+	if F.Singular() {
+		return nil, ExceptionSingular
+	}
+
+	m := B.cols
+	X := CZeros(n, m)
+	var i, j, k uint
+	for i = 0; i < n; i++ {
+		copy(X.elements[i*m:(i+1)*m], B.elements[F.piv[i]*m:(F.piv[i]+1)*m])
+	}
+	LU, x := F.lu.elements, X.elements
+	// LY = PB
+	for k = 0; k < n; k++ {
+		for i = k + 1; i < n; i++ {
+			if l := LU[i*n+k]; l != 0 {
+				for j = 0; j < m; j++ {
+					x[i*m+j] -= l * x[k*m+j]
+				}
+			}
+		}
+	}
+	// UX = Y
+	for k = n; k > 0; k-- {
+		d := LU[(k-1)*n+k-1]
+		for j = 0; j < m; j++ {
+			x[(k-1)*m+j] /= d
+		}
+		for i = 0; i < k-1; i++ {
+			if u := LU[i*n+k-1]; u != 0 {
+				for j = 0; j < m; j++ {
+					x[i*m+j] -= u * x[(k-1)*m+j]
+				}
+			}
+		}
+	}
+	return X, nil
+}
+
+/*
+Returns the determinant of the factorized matrix.
+*/
+func (F *CLU) Det() complex128 {
+	n := F.lu.rows
+	d := complex(F.pivsign, 0)
+	for i := uint(0); i < n; i++ {
+		d *= F.lu.elements[i*n+i]
+	}
+	return d
+}
+
+/*
+Returns the inverse of the factorized matrix.
+*/
+func (F *CLU) Inverse() (*CDenseMatrix, error) {
+	return F.Solve(CEye(F.lu.rows))
+}
+
+/*
+Returns the determinant of the square matrix A, NaN if A is not square.
+*/
+func (A *CDenseMatrix) Det() complex128 {
+	F, err := A.LU()
+	if err != nil {
+		return cmplx.NaN()
+	}
+	return F.Det()
+}
+
+/*
+Returns X such that AX=B.
+*/
+func (A *CDenseMatrix) Solve(B *CDenseMatrix) (*CDenseMatrix, error) {
+	F, err := A.LU()
+	if err != nil {
+		return nil, err
+	}
+	return F.Solve(B)
+}
+
+/*
+Returns the eigenvectors as the columns of a complex matrix, column k
+belonging to Values()[k]; see Vectors.
+*/
+func (E *Eigen) ComplexVectors() *CDenseMatrix {
+	vecs := E.Vectors()
+	n := E.v.rows
+	V := CZeros(n, uint(len(vecs)))
+	for k, x := range vecs {
+		for i, c := range x {
+			V.elements[uint(i)*V.cols+uint(k)] = c
+		}
+	}
+	return V
+}
@@ -0,0 +1,149 @@
+package math
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/hezila/hezila/utils"
+)
+
+func TestParseMatlabComplex(t *testing.T) {
+	A, err := ParseMatlabComplex("[1+2i 3; -i 4.5j; 1e1-j 0]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Ar := MakeCDenseMatrix([]complex128{1 + 2i, 3, -1i, 4.5i, 10 - 1i, 0}, 3, 2)
+	if !CApproxEquals(A, Ar, 0) {
+		t.Errorf("A=%v", A)
+	}
+	utils.Expect(t, "{ 1+2i,     3,\n   -1i,  4.5i,\n 10-1i,     0}", A.String())
+	if _, err := ParseMatlabComplex("[1 2; 3]"); err == nil {
+		t.Error("misaligned rows parsed")
+	}
+	if _, err := ParseMatlabComplex("[1 2+]"); err == nil {
+		t.Error("bad element parsed")
+	}
+
+	// the syntax of ParseMatlab, with the same rules for signs
+	cases := []struct {
+		txt  string
+		want *CDenseMatrix
+	}{
+		{"[1 -2i]", MakeCDenseMatrix([]complex128{1, -2i}, 1, 2)},
+		{"[1, +i]", MakeCDenseMatrix([]complex128{1, 1i}, 1, 2)},
+		{"Z = [1:2 eye(1) % comment\n 2e-1j, ... continued\n -.5-1E1i 0];", MakeCDenseMatrix([]complex128{1, 2, 1, 0.2i, -0.5 - 10i, 0}, 2, 3)},
+		{"[[1i; 2] ones(2, 1)]", MakeCDenseMatrix([]complex128{1i, 1, 2, 1}, 2, 2)},
+		{"[1 - 2i]", nil},
+		{"[1 -2 i]", MakeCDenseMatrix([]complex128{1, -2, 1i}, 1, 3)},
+		{"[2i+1]", nil},
+		{"[1+2]", nil},
+		{"1:2i", nil},
+		{"zeros(1i)", nil},
+		{"[x]", nil},
+	}
+	for _, c := range cases {
+		A, err := ParseMatlabComplex(c.txt)
+		if c.want == nil {
+			if _, ok := err.(*ParseError); !ok {
+				t.Errorf("%q: %v %v", c.txt, A, err)
+			}
+		} else if err != nil || A.Rows() != c.want.Rows() || A.Cols() != c.want.Cols() || !CApproxEquals(A, c.want, 0) {
+			t.Errorf("%q: %v %v", c.txt, A, err)
+		}
+	}
+
+	// String reads back, with infinite and NaN parts
+	B := MakeCDenseMatrix([]complex128{complex(math.Inf(-1), math.NaN()), complex(0, math.Inf(1)), 0.1 - 3e-20i, -1}, 2, 2)
+	C, err := ParseMatlabComplex(B.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsInf(real(C.Get(0, 0)), -1) || !math.IsNaN(imag(C.Get(0, 0))) || C.Get(0, 1) != complex(0, math.Inf(1)) ||
+		C.Get(1, 0) != B.Get(1, 0) || C.Get(1, 1) != -1 {
+		t.Errorf("%s read as %v", B, C)
+	}
+	if _, err := ParseMatlab("[1 2i]"); err == nil {
+		t.Error("ParseMatlab read an imaginary number")
+	}
+}
+
+func TestCDenseArithmetic(t *testing.T) {
+	A := MakeCDenseMatrix([]complex128{1 + 1i, 2, 0, 1i}, 2, 2)
+	H := A.ConjTranspose()
+	if H.Get(0, 0) != 1-1i || H.Get(1, 0) != 2 || H.Get(1, 1) != -1i {
+		t.Errorf("A^H=%v", H)
+	}
+	// A^H A is Hermitian with a real diagonal
+	G, _ := H.Times(A)
+	if !CApproxEquals(G, G.ConjTranspose(), 1e-15) || imag(G.Trace()) != 0 {
+		t.Errorf("A^H A=%v", G)
+	}
+	B := A.Copy()
+	B.Add(A)
+	B.Scale(0.5i)
+	if !CApproxEquals(B, MakeCDenseMatrix([]complex128{-1 + 1i, 2i, 0, -1}, 2, 2), 0) {
+		t.Errorf("B=%v", B)
+	}
+	if err := B.Subtract(CZeros(2, 3)); err != ErrorDimensionMismatch {
+		t.Errorf("Subtract: %v", err)
+	}
+	if !Equals(MakeCDenseParts(A.Real(), A.Imag()).Real(), A.Real()) {
+		t.Error("parts")
+	}
+}
+
+func TestCDenseLU(t *testing.T) {
+	A := MakeCDenseMatrix([]complex128{
+		0, 2 + 1i, 1,
+		1i, 1, 3 - 2i,
+		4, 1 - 1i, 2i}, 3, 3)
+	F, err := A.LU()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// PLU = A
+	LU, _ := F.L().Times(F.U())
+	P := F.P()
+	for i := uint(0); i < 3; i++ {
+		for j := uint(0); j < 3; j++ {
+			if cmplx.Abs(LU.Get(i, j)-A.Get(P.pivots[i], j)) > 1e-14 {
+				t.Fatalf("PLU != A: %v", LU)
+			}
+		}
+	}
+	// the determinant by cofactor expansion
+	e := A.elements
+	det := e[0]*(e[4]*e[8]-e[5]*e[7]) - e[1]*(e[3]*e[8]-e[5]*e[6]) + e[2]*(e[3]*e[7]-e[4]*e[6])
+	if cmplx.Abs(A.Det()-det) > 1e-13 {
+		t.Errorf("det=%v, want %v", A.Det(), det)
+	}
+
+	Xr := MakeCDenseMatrix([]complex128{1, 1i, 2 - 1i, 0, -1, 3i}, 3, 2)
+	B, _ := A.Times(Xr)
+	X, err := A.Solve(B)
+	if err != nil || !CApproxEquals(X, Xr, 1e-13) {
+		t.Errorf("X=%v %v", X, err)
+	}
+	if _, err := CZeros(2, 2).Solve(CZeros(2, 1)); err != ExceptionSingular {
+		t.Errorf("singular: %v", err)
+	}
+	if !cmplx.IsNaN(CZeros(2, 3).Det()) {
+		t.Error("det of a non-square matrix")
+	}
+}
+
+func TestEigenComplexVectors(t *testing.T) {
+	// a rotation has the eigenvalues ±i
+	R := MakeDenseMatrix([]float64{0, -1, 1, 0}, 2, 2)
+	E, err := R.Eigen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	V := E.ComplexVectors()
+	AV, _ := MakeCDenseCopy(R).Times(V)
+	VD, _ := V.Times(MakeCDenseMatrix([]complex128{E.Values()[0], 0, 0, E.Values()[1]}, 2, 2))
+	if !CApproxEquals(AV, VD, 1e-14) {
+		t.Errorf("AV=%v, VD=%v", AV, VD)
+	}
+}
@@ -0,0 +1,313 @@
+package math
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// What a CSVReader does with a missing field.
+type CSVMissing int
+
+const (
+	// return a *ParseError
+	CSVMissingError CSVMissing = iota
+	// read NaN
+	CSVMissingNaN
+	// read CSVOptions.Fill
+	CSVMissingFill
+	// leave out the record
+	CSVMissingSkipRow
+)
+
+/*
+Options of CSVReader and WriteCSV. The zero value reads comma separated
+numbers without a header, every column, and fails on missing fields.
+*/
+type CSVOptions struct {
+	// field delimiter, ',' if zero; '\t' for TSV
+	Comma rune
+	// lines starting with Comment are skipped, if it is not zero
+	Comment rune
+	// quotes may appear in unquoted fields, as in many TSV files
+	LazyQuotes bool
+
+	// the first record names the columns
+	Header bool
+	// the columns to read, by name, which needs Header, or by index from 0;
+	// all columns if both are nil
+	Columns       []string
+	ColumnIndexes []int
+
+	// fields that count as missing besides empty ones, e.g. "NA"
+	NA      []string
+	Missing CSVMissing
+	Fill    float64
+
+	// converts a field that is not missing, given the index of its column
+	// among those read; nil reads numbers with strconv.ParseFloat, and true
+	// and false as 1 and 0
+	Parse func(col int, field string) (float64, error)
+}
+
+func (o *CSVOptions) comma() rune {
+	if o.Comma == 0 {
+		return ','
+	}
+	return o.Comma
+}
+
+// The default conversion of CSVOptions.Parse.
+func parseCSVField(col int, field string) (float64, error) {
+	switch strings.ToLower(field) {
+	case "true":
+		return 1, nil
+	case "false":
+		return 0, nil
+	}
+	return strconv.ParseFloat(field, 64)
+}
+
+/*
+Reads the records of a CSV or TSV stream into matrices, one row per record,
+all at once or in batches of rows. Spaces around fields are ignored.
+*/
+type CSVReader struct {
+	r    *csv.Reader
+	opts CSVOptions
+	// the indexes of the fields read, nil until the first record is seen if
+	// all are read
+	cols  []int
+	names []string
+	na    map[string]bool
+}
+
+func csvError(line int, format string, args ...interface{}) error {
+	return &ParseError{Format: "csv", Line: line, Msg: fmt.Sprintf(format, args...)}
+}
+
+/*
+Returns a reader of r, reading the header if there is one. A nil opts is
+the zero CSVOptions.
+*/
+func NewCSVReader(r io.Reader, opts *CSVOptions) (*CSVReader, error) {
+	R := new(CSVReader)
+	if opts != nil {
+		R.opts = *opts
+	}
+	R.r = csv.NewReader(r)
+	R.r.Comma = R.opts.comma()
+	R.r.Comment = R.opts.Comment
+	R.r.LazyQuotes = R.opts.LazyQuotes
+	R.r.TrimLeadingSpace = true
+	R.r.FieldsPerRecord = -1
+	R.r.ReuseRecord = true
+	R.na = map[string]bool{"": true}
+	for _, s := range R.opts.NA {
+		R.na[s] = true
+	}
+	if R.opts.Columns != nil && R.opts.ColumnIndexes != nil {
+		return nil, ErrorIllegalArgument
+	}
+	if R.opts.Columns != nil && !R.opts.Header {
+		return nil, ErrorIllegalArgument
+	}
+	R.cols = R.opts.ColumnIndexes
+
+	if !R.opts.Header {
+		return R, nil
+	}
+	header, err := R.r.Read()
+	if err == io.EOF {
+		return nil, csvError(0, "no header")
+	}
+	if err != nil {
+		return nil, R.wrap(err)
+	}
+	byName := make(map[string]int, len(header))
+	for k, name := range header {
+		name = strings.TrimSpace(name)
+		if _, dup := byName[name]; !dup {
+			byName[name] = k
+		}
+	}
+	if R.opts.Columns != nil {
+		R.cols = make([]int, len(R.opts.Columns))
+		for c, name := range R.opts.Columns {
+			k, ok := byName[name]
+			if !ok {
+				return nil, csvError(1, "no column %q", name)
+			}
+			R.cols[c] = k
+		}
+	}
+	if R.cols == nil {
+		R.cols = make([]int, len(header))
+		for k := range header {
+			R.cols[k] = k
+		}
+	}
+	R.names = make([]string, len(R.cols))
+	for c, k := range R.cols {
+		if k < 0 || k >= len(header) {
+			return nil, csvError(1, "no column %d in %d", k, len(header))
+		}
+		R.names[c] = strings.TrimSpace(header[k])
+	}
+	return R, nil
+}
+
+/*
+The names of the columns read, from the header; nil without a header.
+*/
+func (R *CSVReader) Columns() []string { return R.names }
+
+// Errors of encoding/csv as ParseErrors.
+func (R *CSVReader) wrap(err error) error {
+	if pe, ok := err.(*csv.ParseError); ok {
+		return csvError(pe.Line, "%v", pe.Err)
+	}
+	return err
+}
+
+// Appends the fields of record to row. Returns false to skip the record.
+func (R *CSVReader) convert(record []string, row []float64) ([]float64, bool, error) {
+	line, _ := R.r.FieldPos(0)
+	if R.cols == nil {
+		R.cols = make([]int, len(record))
+		for k := range record {
+			R.cols[k] = k
+		}
+	}
+	parse := R.opts.Parse
+	if parse == nil {
+		parse = parseCSVField
+	}
+	// when every column is read the widths must agree
+	selected := R.opts.ColumnIndexes != nil || R.opts.Columns != nil
+	if !selected && len(record) != len(R.cols) {
+		return nil, false, csvError(line, "%d fields, want %d", len(record), len(R.cols))
+	}
+	n := len(row)
+	for c, k := range R.cols {
+		if k < 0 || k >= len(record) {
+			return nil, false, csvError(line, "%d fields, no column %d", len(record), k)
+		}
+		field := strings.TrimSpace(record[k])
+		if R.na[field] {
+			switch R.opts.Missing {
+			case CSVMissingNaN:
+				row = append(row, math.NaN())
+				continue
+			case CSVMissingFill:
+				row = append(row, R.opts.Fill)
+				continue
+			case CSVMissingSkipRow:
+				return row[:n], false, nil
+			}
+			return nil, false, csvError(line, "missing value in column %d", k)
+		}
+		v, err := parse(c, field)
+		if err != nil {
+			return nil, false, csvError(line, "column %d: %q: %v", k, field, err)
+		}
+		row = append(row, v)
+	}
+	return row, true, nil
+}
+
+/*
+Reads up to n records into the rows of a matrix, fewer at the end of the
+stream; n <= 0 reads all that are left. Returns io.EOF when no record is
+left. Malformed records return a *ParseError, which counts columns from 0
+like ColumnIndexes.
+*/
+func (R *CSVReader) ReadBatch(n int) (*DenseMatrix, error) {
+	var elements []float64
+	rows := 0
+	for n <= 0 || rows < n {
+		record, err := R.r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, R.wrap(err)
+		}
+		var ok bool
+		elements, ok, err = R.convert(record, elements)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			rows++
+		}
+	}
+	if rows == 0 {
+		return nil, io.EOF
+	}
+	return MakeDenseMatrix(elements, uint(rows), uint(len(R.cols))), nil
+}
+
+/*
+Reads all records. An empty stream gives a matrix with no rows.
+*/
+func (R *CSVReader) ReadAll() (*DenseMatrix, error) {
+	A, err := R.ReadBatch(0)
+	if err == io.EOF {
+		return Zeros(0, uint(len(R.cols))), nil
+	}
+	return A, err
+}
+
+/*
+Reads all of a CSV stream into a matrix, see CSVReader. Returns the column
+names if opts has Header set.
+*/
+func ReadCSV(r io.Reader, opts *CSVOptions) (*DenseMatrix, []string, error) {
+	R, err := NewCSVReader(r, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	A, err := R.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	return A, R.Columns(), nil
+}
+
+/*
+Writes the rows of A as CSV records, after a header record if header is not
+nil. Numbers are written with the fewest digits that read back exactly, and
+NaN and the infinities as NaN, +Inf and -Inf, which ReadCSV reads back with
+any options. Of opts only Comma is used; nil writes commas.
+Returns ErrorDimensionMismatch if header does not name every column.
+*/
+func WriteCSV(w io.Writer, A MatrixRO, header []string, opts *CSVOptions) error {
+	if header != nil && uint(len(header)) != A.Cols() {
+		return ErrorDimensionMismatch
+	}
+	cw := csv.NewWriter(w)
+	if opts != nil {
+		cw.Comma = opts.comma()
+	}
+	if header != nil {
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+	}
+	record := make([]string, A.Cols())
+	var i, j uint
+	for i = 0; i < A.Rows(); i++ {
+		for j = 0; j < A.Cols(); j++ {
+			record[j] = strconv.FormatFloat(A.Get(i, j), 'g', -1, 64)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
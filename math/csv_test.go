@@ -0,0 +1,116 @@
+package math
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/hezila/hezila/utils"
+)
+
+func TestReadCSV(t *testing.T) {
+	data := "id, price, sold, note\n" +
+		"1, 2.5, true, \"a, b\"\n" +
+		"# a comment\n" +
+		"2, NA, false, c\n" +
+		"3, -1e3, true, d\n"
+
+	opts := &CSVOptions{Header: true, Comment: '#', Columns: []string{"price", "sold"},
+		NA: []string{"NA"}, Missing: CSVMissingFill, Fill: -1}
+	A, cols, err := ReadCSV(strings.NewReader(data), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equals(A, MakeDenseMatrix([]float64{2.5, 1, -1, 0, -1000, 1}, 3, 2)) {
+		t.Errorf("A=%v", A)
+	}
+	utils.Expect(t, "[price sold]", cols)
+
+	opts.Missing = CSVMissingSkipRow
+	A, _, err = ReadCSV(strings.NewReader(data), opts)
+	if err != nil || !Equals(A, MakeDenseMatrix([]float64{2.5, 1, -1000, 1}, 2, 2)) {
+		t.Errorf("A=%v %v", A, err)
+	}
+
+	opts.Missing = CSVMissingError
+	_, _, err = ReadCSV(strings.NewReader(data), opts)
+	if pe, ok := err.(*ParseError); !ok || pe.Line != 4 || pe.Msg != "missing value in column 1" {
+		t.Errorf("missing value: %v", err)
+	}
+
+	// the notes are not numbers
+	if _, _, err := ReadCSV(strings.NewReader(data), &CSVOptions{Header: true, Comment: '#', Missing: CSVMissingNaN}); err == nil {
+		t.Error("read text as numbers")
+	}
+	if _, _, err := ReadCSV(strings.NewReader(data), &CSVOptions{Header: true, Columns: []string{"cost"}}); err == nil {
+		t.Error("read an unknown column")
+	}
+
+	// TSV by index, with a custom conversion
+	tsv := "1\t10%\t0\n2\t25%\t1\n"
+	A, _, err = ReadCSV(strings.NewReader(tsv), &CSVOptions{Comma: '\t', ColumnIndexes: []int{1, 0},
+		Parse: func(col int, field string) (float64, error) {
+			if col == 0 {
+				field = strings.TrimSuffix(field, "%")
+			}
+			return parseCSVField(col, field)
+		}})
+	if err != nil || !Equals(A, MakeDenseMatrix([]float64{10, 1, 25, 2}, 2, 2)) {
+		t.Errorf("A=%v %v", A, err)
+	}
+
+	if _, _, err := ReadCSV(strings.NewReader("1,2\n3\n"), nil); err == nil {
+		t.Error("read a short record")
+	}
+	A, _, err = ReadCSV(strings.NewReader(""), nil)
+	if err != nil || A.Rows() != 0 {
+		t.Errorf("empty: %v %v", A, err)
+	}
+}
+
+func TestCSVBatches(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i < 10; i++ {
+		b.WriteString("1,2,3\n")
+	}
+	R, err := NewCSVReader(strings.NewReader(b.String()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sizes []uint
+	for {
+		A, err := R.ReadBatch(4)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		sizes = append(sizes, A.Rows())
+	}
+	utils.Expect(t, "[4 4 2]", sizes)
+}
+
+func TestWriteCSV(t *testing.T) {
+	A := MakeDenseMatrix([]float64{0.1, math.NaN(), -2, 1e-20}, 2, 2)
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, A, []string{"x", "y, z"}, &CSVOptions{Comma: ';'}); err != nil {
+		t.Fatal(err)
+	}
+	utils.Expect(t, "x;y, z\n0.1;NaN\n-2;1e-20\n", buf.String())
+
+	// the default options read NaN back
+	B, cols, err := ReadCSV(&buf, &CSVOptions{Comma: ';', Header: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.Expect(t, "[x y, z]", cols)
+	if B.Get(0, 0) != 0.1 || !math.IsNaN(B.Get(0, 1)) || B.Get(1, 0) != -2 || B.Get(1, 1) != 1e-20 {
+		t.Errorf("B=%v", B)
+	}
+	if err := WriteCSV(&buf, A, []string{"x"}, nil); err != ErrorDimensionMismatch {
+		t.Errorf("short header: %v", err)
+	}
+}
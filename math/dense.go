@@ -1,10 +1,6 @@
 package math
 
-import (
-	"math/rand"
-
-	log "github.com/golang/glog"
-)
+import "math/rand"
 
 // A matrix backed by a flat array of all elements
 type DenseMatrix struct {
@@ -15,6 +11,10 @@ type DenseMatrix struct {
 
 	// offset between rows; step = cols
 	step uint
+
+	// offset between columns; inc = 1 unless this is a transposed or
+	// column strided view
+	inc uint
 }
 
 func NewDenseMatrix(rows, cols uint) *DenseMatrix {
@@ -22,6 +22,7 @@ func NewDenseMatrix(rows, cols uint) *DenseMatrix {
 	M.rows = rows
 	M.cols = cols
 	M.step = cols
+	M.inc = 1
 
 	M.elements = make([]float64, rows*cols)
 
@@ -36,6 +37,7 @@ func MakeDenseMatrix(elements []float64, rows, cols uint) *DenseMatrix {
 	A.rows = rows
 	A.cols = cols
 	A.step = cols
+	A.inc = 1
 
 	A.elements = elements
 	return A
@@ -54,86 +56,126 @@ func MakeDenseMatrixStacked(data [][]float64) *DenseMatrix {
 	return MakeDenseMatrix(elements, rows, cols)
 }
 
+// The position of element (i, j) in the backing slice.
+func (M *DenseMatrix) index(i, j uint) uint { return i*M.step + j*M.inc }
+
+// Returns true if the elements of every row are adjacent in memory, so rows
+// can be handed out as slices.
+func (M *DenseMatrix) rowContiguous() bool { return M.inc == 1 || M.cols <= 1 }
+
+/*
+Returns an array of slices referencing the matrix data. For views whose rows
+are not contiguous in memory, such as transposed views, the rows are copies.
+*/
 func (M *DenseMatrix) Arrays() [][]float64 {
 	a := make([][]float64, M.rows)
 	for i := uint(0); i < M.rows; i++ {
-		a[i] = M.elements[i*M.step : i*M.step+M.cols]
+		if M.rowContiguous() {
+			a[i] = M.elements[i*M.step : i*M.step+M.cols]
+		} else {
+			a[i] = M.RowCopy(i)
+		}
 	}
 	return a
 }
 
 func (M *DenseMatrix) Array() []float64 {
-	if M.step == M.cols {
+	if M.step == M.cols && M.rowContiguous() {
 		return M.elements[0 : M.rows*M.cols]
 	}
 
 	a := make([]float64, M.rows*M.cols)
 	for i := uint(0); i < M.rows; i++ {
 		for j := uint(0); j < M.cols; j++ {
-			a[i*M.cols+j] = M.elements[i*M.step+j]
+			a[i*M.cols+j] = M.elements[M.index(i, j)]
 		}
 	}
 	return a
 }
 
+/*
+Returns the row as a slice referencing the matrix data, or as a copy if the
+row is not contiguous in memory. Panics with ErrorIllegalIndex if row is out
+of bounds.
+*/
 func (M *DenseMatrix) RowSlice(row uint) []float64 {
-	if row < 0 || row > M.rows-1 {
-		log.Fatal("index out of bound!")
+	if row >= M.rows {
+		panic(ErrorIllegalIndex)
+	}
+	if !M.rowContiguous() {
+		return M.RowCopy(row)
 	}
 	return M.elements[row*M.step : row*M.step+M.cols]
 }
 
+/*
+Returns a copy of the column. Panics with ErrorIllegalIndex if col is out of
+bounds.
+*/
 func (M *DenseMatrix) ColSlice(col uint) []float64 {
-	if col < 0 || col > M.cols-1 {
-		log.Fatal("index out of bound!")
+	if col >= M.cols {
+		panic(ErrorIllegalIndex)
 	}
 	a := make([]float64, M.rows)
 	for i := uint(0); i < M.rows; i++ {
-		a[i] = M.elements[i*M.step+col]
+		a[i] = M.elements[M.index(i, col)]
 	}
 	return a
 }
 
-func (M *DenseMatrix) Get(i, j uint) (v float64) {
-	if i < 0 {
-		i = M.rows + i
-		if i < 0 {
-			log.Fatal("index out of bound!")
-		}
+/*
+Returns the element at i, j. Panics with ErrorIllegalIndex if the index is
+out of bounds; use GetChecked to get the error instead.
+*/
+func (M *DenseMatrix) Get(i, j uint) float64 {
+	if i >= M.rows || j >= M.cols {
+		panic(ErrorIllegalIndex)
 	}
+	return M.elements[M.index(i, j)]
+}
 
-	if j < 0 {
-		j = M.cols + j
-		if j < 0 {
-			log.Fatal("index out of bound!")
-		}
+/*
+Sets the element at i, j. Panics with ErrorIllegalIndex if the index is out
+of bounds; use SetChecked to get the error instead.
+*/
+func (M *DenseMatrix) Set(i, j uint, v float64) {
+	if i >= M.rows || j >= M.cols {
+		panic(ErrorIllegalIndex)
 	}
+	M.elements[M.index(i, j)] = v
+}
 
+// Like Get, but returns ErrorIllegalIndex rather than panicking.
+func (M *DenseMatrix) GetChecked(i, j uint) (float64, error) {
 	if i >= M.rows || j >= M.cols {
-		log.Fatal("index out of bound!")
+		return 0, ErrorIllegalIndex
 	}
-	v = M.elements[i*M.step+j]
-	return
+	return M.elements[M.index(i, j)], nil
 }
 
-func (M *DenseMatrix) Set(i, j uint, v float64) {
-	if i < 0 {
-		i = M.rows + i
-		if i < 0 {
-			log.Fatal("index out of bound!")
-		}
-	}
-	if j < 0 {
-		j = M.cols + j
-		if j < 0 {
-			log.Fatal("index out of bound!")
-		}
-	}
+// Like Set, but returns ErrorIllegalIndex rather than panicking.
+func (M *DenseMatrix) SetChecked(i, j uint, v float64) error {
 	if i >= M.rows || j >= M.cols {
-		log.Fatal("index out of bound!")
+		return ErrorIllegalIndex
 	}
-	M.elements[i*M.step+j] = v
-	return
+	M.elements[M.index(i, j)] = v
+	return nil
+}
+
+/*
+Returns the element at i, j without checking the index against the matrix
+dimensions. Meant for inner loops whose bounds are already known to be valid:
+an out of range column silently reads a neighbouring element.
+*/
+func (M *DenseMatrix) UncheckedGet(i, j uint) float64 {
+	return M.elements[i*M.step+j*M.inc]
+}
+
+/*
+Sets the element at i, j without checking the index, see UncheckedGet.
+*/
+func (M *DenseMatrix) UncheckedSet(i, j uint, v float64) {
+	M.elements[i*M.step+j*M.inc] = v
 }
 
 func (M *DenseMatrix) SetValue(index uint, v float64) {
@@ -147,14 +189,14 @@ func (M *DenseMatrix) SubMatrix(i, j, rows, cols uint) (A *DenseMatrix, err erro
 		err = ErrorIllegalIndex
 	}
 
-	A = new(DenseMatrix)
-	A.elements = make([]float64, rows*cols)
-	A.step = cols
-	A.rows = rows
-	A.cols = cols
+	if err != nil {
+		return
+	}
+
+	A = Zeros(rows, cols)
 	for r := uint(0); r < rows; r++ {
 		for c := uint(0); c < cols; c++ {
-			A.elements[r*A.step+c] = M.elements[(i+r)*M.step+j+c]
+			A.elements[r*A.step+c] = M.elements[M.index(i+r, j+c)]
 		}
 	}
 	return
@@ -163,17 +205,11 @@ func (M *DenseMatrix) SubMatrix(i, j, rows, cols uint) (A *DenseMatrix, err erro
 // Get a submatrix starting at i,j with rows rows and cols columns. Changes to
 // the returned matrix show up in the original.
 func (A *DenseMatrix) GetMatrix(i, j, rows, cols uint) *DenseMatrix {
-	B := new(DenseMatrix)
-	B.elements = A.elements[i*A.step+j : i*A.step+j+(rows-1)*A.step+cols]
-	B.rows = rows
-	B.cols = cols
-	B.step = A.step
-	return B
+	return A.View(i, j, rows, cols)
 }
 
 // Copy A into M, with A's 0, 0 aligning with M's i, j
 func (M *DenseMatrix) SetMatrix(i, j uint, A *DenseMatrix) {
-	var r, c uint
 	for r := uint(0); r < A.rows; r++ {
 		for c := uint(0); c < A.cols; c++ {
 			M.Set(i+r, j+c, A.Get(r, c))
@@ -181,23 +217,30 @@ func (M *DenseMatrix) SetMatrix(i, j uint, A *DenseMatrix) {
 	}
 }
 
+// Returns a copy of column j, see ColView for an aliasing version.
 func (M *DenseMatrix) ColVector(j uint) *DenseMatrix {
-	return M.SubMatrix(0, j, M.rows, 1)
+	return M.ColView(j).Copy()
 }
 
+// Returns a copy of row i, see RowView for an aliasing version.
 func (M *DenseMatrix) RowVector(i uint) *DenseMatrix {
-	return M.SubMatrix(i, 0, 1, M.cols)
+	return M.RowView(i).Copy()
 }
 
+/*
+Returns a contiguous copy of this matrix. Copying a view materializes it.
+*/
 func (M *DenseMatrix) Copy() *DenseMatrix {
-	A := new(DenseMatrix)
-	A.rows = M.rows
-	A.cols = M.cols
-	A.step = M.step
-	A.elements = make([]float64, M.rows*M.cols)
-
+	A := Zeros(M.rows, M.cols)
 	for r := uint(0); r < A.rows; r++ {
-		copy(A.RowSlice(r), M.RowSlice(r))
+		Arow := A.elements[r*A.step : r*A.step+A.cols]
+		if M.rowContiguous() {
+			copy(Arow, M.elements[r*M.step:r*M.step+M.cols])
+			continue
+		}
+		for c := range Arow {
+			Arow[c] = M.elements[M.index(r, uint(c))]
+		}
 	}
 	return A
 }
@@ -249,7 +292,7 @@ func (M *DenseMatrix) AugmentFill(A, B *DenseMatrix) (err error) {
 func (A *DenseMatrix) SparseMatrix() *SparseMatrix {
 	B := ZerosSparse(A.rows, A.cols)
 	for i := uint(0); i < A.rows; i++ {
-		for j = uint(0); j < A.cols; j++ {
+		for j := uint(0); j < A.cols; j++ {
 			v := A.Get(i, j)
 			if v != 0 {
 				B.Set(i, j, v)
@@ -259,6 +302,12 @@ func (A *DenseMatrix) SparseMatrix() *SparseMatrix {
 	return B
 }
 
+// Create a compressed sparse row copy.
+func (A *DenseMatrix) CSR() *CSRMatrix { return MakeCSRCopy(A) }
+
+// Create a compressed sparse column copy.
+func (A *DenseMatrix) CSC() *CSCMatrix { return MakeCSCCopy(A) }
+
 func (A *DenseMatrix) DenseMatrix() *DenseMatrix {
 	return A.Copy()
 }
@@ -269,6 +318,7 @@ func Zeros(rows, cols uint) *DenseMatrix {
 	Z.rows = rows
 	Z.cols = cols
 	Z.step = cols
+	Z.inc = 1
 	return Z
 }
 
@@ -278,6 +328,7 @@ func Ones(rows, cols uint) *DenseMatrix {
 	O.rows = rows
 	O.cols = cols
 	O.step = cols
+	O.inc = 1
 	for i := uint(0); i < rows*cols; i++ {
 		O.elements[i] = 1
 	}
@@ -0,0 +1,240 @@
+package math
+
+/*
+A dense matrix of float32 elements, for data such as embedding tables and
+model weights that do not need double precision and take half the memory
+this way. It implements Matrix like DenseMatrix: Get and Set convert to and
+from float64, and values outside the float32 range become infinities.
+Products accumulate in float64 and round once. For factorizations and the
+other dense algorithms convert with DenseMatrix.
+*/
+type DenseMatrix32 struct {
+	matrix
+
+	// flatted elements, row after row
+	elements []float32
+}
+
+func Zeros32(rows, cols uint) *DenseMatrix32 {
+	return MakeDenseMatrix32(make([]float32, rows*cols), rows, cols)
+}
+
+/*
+Makes a DenseMatrix32 of the row-major elements, which are not copied.
+*/
+func MakeDenseMatrix32(elements []float32, rows, cols uint) *DenseMatrix32 {
+	A := new(DenseMatrix32)
+	A.rows = rows
+	A.cols = cols
+	A.elements = elements
+	return A
+}
+
+/*
+Returns A rounded to float32.
+*/
+func MakeDenseCopy32(A MatrixRO) *DenseMatrix32 {
+	B := Zeros32(A.Rows(), A.Cols())
+	switch Am := A.(type) {
+	case *DenseMatrix32:
+		copy(B.elements, Am.elements)
+	case *DenseMatrix:
+		var i, j uint
+		for i = 0; i < Am.rows; i++ {
+			for j = 0; j < Am.cols; j++ {
+				B.elements[i*B.cols+j] = float32(Am.elements[Am.index(i, j)])
+			}
+		}
+	default:
+		is, js, vs := triplets(A)
+		for p, v := range vs {
+			B.elements[is[p]*B.cols+js[p]] = float32(v)
+		}
+	}
+	return B
+}
+
+/*
+Returns this matrix rounded to float32.
+*/
+func (A *DenseMatrix) DenseMatrix32() *DenseMatrix32 { return MakeDenseCopy32(A) }
+
+/*
+Returns the elements in float64.
+*/
+func (A *DenseMatrix32) DenseMatrix() *DenseMatrix {
+	B := Zeros(A.rows, A.cols)
+	for p, v := range A.elements {
+		B.elements[p] = float64(v)
+	}
+	return B
+}
+
+func (A *DenseMatrix32) SparseMatrix() *SparseMatrix {
+	B := ZerosSparse(A.rows, A.cols)
+	for p, v := range A.elements {
+		if v != 0 {
+			B.elements[uint(p)] = float64(v)
+		}
+	}
+	return B
+}
+
+/*
+Returns the row-major elements. The slice references the matrix data.
+*/
+func (A *DenseMatrix32) Elements32() []float32 { return A.elements }
+
+/*
+Returns one slice per row. The slices reference the matrix data, unlike
+those of Arrays.
+*/
+func (A *DenseMatrix32) Arrays32() [][]float32 {
+	a := make([][]float32, A.rows)
+	for i := uint(0); i < A.rows; i++ {
+		a[i] = A.elements[i*A.cols : (i+1)*A.cols]
+	}
+	return a
+}
+
+/*
+Returns the elements of row i. The slice references the matrix data.
+*/
+func (A *DenseMatrix32) RowSlice32(i uint) []float32 {
+	if i >= A.rows {
+		panic(ErrorIllegalIndex)
+	}
+	return A.elements[i*A.cols : (i+1)*A.cols]
+}
+
+func (A *DenseMatrix32) Get32(i, j uint) float32 {
+	if i >= A.rows || j >= A.cols {
+		panic(ErrorIllegalIndex)
+	}
+	return A.elements[i*A.cols+j]
+}
+
+func (A *DenseMatrix32) Set32(i, j uint, v float32) {
+	if i >= A.rows || j >= A.cols {
+		panic(ErrorIllegalIndex)
+	}
+	A.elements[i*A.cols+j] = v
+}
+
+func (A *DenseMatrix32) Get(i, j uint) float64 { return float64(A.Get32(i, j)) }
+
+func (A *DenseMatrix32) Set(i, j uint, v float64) { A.Set32(i, j, float32(v)) }
+
+/*
+Returns the elements in float64, one slice per row. Unlike those of a
+DenseMatrix the slices are copies; Arrays32 references the data.
+*/
+func (A *DenseMatrix32) Arrays() [][]float64 { return A.DenseMatrix().Arrays() }
+
+/*
+Returns the row-major elements in float64, as a copy; Elements32 references
+the data.
+*/
+func (A *DenseMatrix32) Array() []float64 { return A.DenseMatrix().elements }
+
+func (A *DenseMatrix32) Det() float64 { return A.DenseMatrix().Det() }
+
+func (A *DenseMatrix32) Trace() (res float64) {
+	for i := uint(0); i < minUInt(A.rows, A.cols); i++ {
+		res += float64(A.elements[i*A.cols+i])
+	}
+	return
+}
+
+func (A *DenseMatrix32) Copy() *DenseMatrix32 { return MakeDenseCopy32(A) }
+
+func (A *DenseMatrix32) Transpose() *DenseMatrix32 {
+	B := Zeros32(A.cols, A.rows)
+	var i, j uint
+	for i = 0; i < A.rows; i++ {
+		for j = 0; j < A.cols; j++ {
+			B.elements[j*B.cols+i] = A.elements[i*A.cols+j]
+		}
+	}
+	return B
+}
+
+func (A *DenseMatrix32) Add(B MatrixRO) error { return A.addScaled(1, B) }
+
+func (A *DenseMatrix32) Subtract(B MatrixRO) error { return A.addScaled(-1, B) }
+
+func (A *DenseMatrix32) addScaled(alpha float64, B MatrixRO) error {
+	if A.rows != B.Rows() || A.cols != B.Cols() {
+		return ErrorDimensionMismatch
+	}
+	if Bm, ok := B.(*DenseMatrix32); ok {
+		a := float32(alpha)
+		for p, v := range Bm.elements {
+			A.elements[p] += a * v
+		}
+		return nil
+	}
+	var i, j uint
+	for i = 0; i < A.rows; i++ {
+		for j = 0; j < A.cols; j++ {
+			p := i*A.cols + j
+			A.elements[p] = float32(float64(A.elements[p]) + alpha*B.Get(i, j))
+		}
+	}
+	return nil
+}
+
+func (A *DenseMatrix32) Scale(f float64) {
+	for p, v := range A.elements {
+		A.elements[p] = float32(float64(v) * f)
+	}
+}
+
+/*
+Returns y = Ax.
+*/
+func (A *DenseMatrix32) MulVec32(x []float32) ([]float32, error) {
+	if uint(len(x)) != A.cols {
+		return nil, ErrorDimensionMismatch
+	}
+	y := make([]float32, A.rows)
+	for i := uint(0); i < A.rows; i++ {
+		var s float64
+		for j, a := range A.elements[i*A.cols : (i+1)*A.cols] {
+			s += float64(a) * float64(x[j])
+		}
+		y[i] = float32(s)
+	}
+	return y, nil
+}
+
+/*
+Returns the product AB, accumulated in float64 one row of C at a time.
+*/
+func (A *DenseMatrix32) TimesDense32(B *DenseMatrix32) (*DenseMatrix32, error) {
+	if A.cols != B.rows {
+		return nil, ErrorDimensionMismatch
+	}
+	C := Zeros32(A.rows, B.cols)
+	acc := make([]float64, B.cols)
+	for i := uint(0); i < A.rows; i++ {
+		for c := range acc {
+			acc[c] = 0
+		}
+		for k, a := range A.elements[i*A.cols : (i+1)*A.cols] {
+			if a == 0 {
+				continue
+			}
+			af := float64(a)
+			for c, b := range B.elements[uint(k)*B.cols : uint(k+1)*B.cols] {
+				acc[c] += af * float64(b)
+			}
+		}
+		for c, s := range acc {
+			C.elements[i*C.cols+uint(c)] = float32(s)
+		}
+	}
+	return C, nil
+}
+
+func (A *DenseMatrix32) String() string { return String(A) }
@@ -0,0 +1,104 @@
+package math
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDenseMatrix32(t *testing.T) {
+	A := MakeDenseMatrix([]float64{
+		1, 2, 0,
+		0, 0.1, 4}, 2, 3)
+	A32 := A.DenseMatrix32()
+	var _ Matrix = A32
+	var _ Matrix = A32.SparseMatrix().SparseMatrix32()
+
+	if A32.Get32(1, 1) != float32(0.1) || A32.Get(1, 1) == 0.1 {
+		t.Errorf("rounding: %v", A32.Get(1, 1))
+	}
+	if !ApproxEquals(A32, A, 1e-7) || !ApproxEquals(A32.DenseMatrix(), A, 1e-7) {
+		t.Errorf("A32=%v", A32)
+	}
+	// a view converts by its own elements
+	if V := MakeDenseCopy32(A.T()); !Equals(V, A32.Transpose()) {
+		t.Errorf("transposed view=%v", V)
+	}
+
+	B := A32.Copy()
+	if err := B.Add(A); err != nil {
+		t.Fatal(err)
+	}
+	B.Scale(0.5)
+	if !Equals(B, A32) {
+		t.Errorf("(A+A)/2=%v", B)
+	}
+	if err := B.Subtract(Zeros(3, 2)); err != ErrorDimensionMismatch {
+		t.Errorf("Subtract: %v", err)
+	}
+
+	C, err := A32.TimesDense32(A32.Transpose())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := A.Times(A.T()); !ApproxEquals(C, want, 1e-6) {
+		t.Errorf("AA'=%v, want %v", C, want)
+	}
+	y, err := A32.MulVec32([]float32{1, 1, 1})
+	if err != nil || y[0] != 3 || math.Abs(float64(y[1])-4.1) > 1e-6 {
+		t.Errorf("Ax=%v %v", y, err)
+	}
+	if A32.Trace() != 1+float64(float32(0.1)) {
+		t.Errorf("trace=%v", A32.Trace())
+	}
+
+	// Arrays copies, Arrays32 references the data
+	B = A32.Copy()
+	B.Arrays()[0][0] = 7
+	B.Arrays32()[1][2] = 8
+	if B.Get(0, 0) != 1 || B.Get(1, 2) != 8 {
+		t.Errorf("B=%v", B)
+	}
+}
+
+func TestSparseMatrix32(t *testing.T) {
+	S := ZerosSparse32(3, 3)
+	S.Set(0, 2, 1e-50) // underflows to zero
+	S.Set(1, 0, 2)
+	S.Set32(2, 2, 3)
+	if S.NNZ() != 2 {
+		t.Errorf("NNZ=%d", S.NNZ())
+	}
+	want := MakeDenseMatrix([]float64{0, 0, 0, 2, 0, 0, 0, 0, 3}, 3, 3)
+	if !Equals(S, want) || !Equals(S.CSR(), want) || !Equals(S.SparseMatrix(), want) {
+		t.Errorf("S=%v", S)
+	}
+	if err := S.Subtract(want); err != nil || S.NNZ() != 0 {
+		t.Errorf("S-S=%v %v", S, err)
+	}
+}
+
+func TestVector32(t *testing.T) {
+	v, _ := NewSparseVectorFrom([]int{4, 1}, []float64{2, 1e-50})
+	v32 := v.Vector32()
+	if v32.NNZ() != 1 || v32.Get(4) != 2 || v32.Get(1) != 0 {
+		t.Errorf("v32=%v", v32.Vector())
+	}
+	d := NewVector32(5)
+	d.Set(4, 3)
+	d.Set(0, 1)
+	if dot := d.Dot(v32); dot != 6 || v32.Dot(d) != 6 {
+		t.Errorf("dot=%v", dot)
+	}
+	if err := d.Axpy(2, v32); err != nil || d.Get(4) != 7 {
+		t.Errorf("axpy: %v %v", d.Vector(), err)
+	}
+	if err := v32.Axpy(1, d); err != nil || v32.NNZ() != 2 || v32.Get(4) != 9 {
+		t.Errorf("sparse axpy: %v %v", v32.Vector(), err)
+	}
+	if n := d.Norm(); math.Abs(n-math.Sqrt(50)) > 1e-12 {
+		t.Errorf("norm=%v", n)
+	}
+	if err := d.Axpy(1, NewVector32(4)); err != ErrorDimensionMismatch {
+		t.Errorf("Axpy: %v", err)
+	}
+}
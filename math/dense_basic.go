@@ -27,8 +27,8 @@ func (M *DenseMatrix) SwapRows(r1, r2 uint) {
 		temp := M.elements[index1]
 		M.elements[index1] = M.elements[index2]
 		M.elements[index2] = temp
-		index1++
-		index2++
+		index1 += M.inc
+		index2 += M.inc
 	}
 }
 
@@ -37,7 +37,7 @@ func (M *DenseMatrix) ScaleRow(r uint, f float64) {
 
 	for j := uint(0); j < M.cols; j++ {
 		M.elements[index] *= f
-		index++
+		index += M.inc
 	}
 }
 
@@ -47,47 +47,29 @@ func (M *DenseMatrix) ScaleAddRow(rd, rs uint, f float64) {
 
 	for j := uint(0); j < M.cols; j++ {
 		M.elements[indexd] += f * M.elements[indexs]
-		indexd++
-		indexs++
+		indexd += M.inc
+		indexs += M.inc
 	}
 }
 
 func (A *DenseMatrix) Inverse() (*DenseMatrix, error) {
-	if A.rows != A.cols {
-		return nil, ErrorDimensionMismatch
-	}
-	aug, _ := A.Augment(Eye(A.rows))
-
-	for i := uint(0); i < aug.rows; i++ {
-		j := i
-		for k := i; k < aug.rows; k++ {
-			if math.Abs(aug.Get(k, i)) > math.Abs(aug.Get(j, i)) {
-				j = k
-			}
-		}
-		if j != i {
-			aug.SwapRows(i, j)
-		}
-		if aug.Get(i, i) == 0 {
-			return nil, ExceptionSingular
-		}
-		aug.ScaleRow(i, 1.0/aug.Get(i, i))
-
-		for k := uint(0); k < aug.rows; k++ {
-			if k == i {
-				continue
-			}
-			aug.ScaleAddRow(k, i, -aug.Get(k, i))
-		}
+	F, err := A.LU()
+	if err != nil {
+		return nil, err
 	}
-	inv := aug.GetMatrix(0, A.cols, A.rows, A.cols)
-	return inv, nil
+	return F.Inverse()
 }
 
+/*
+The determinant of A, computed from its LU factorization. Returns NaN if A is
+not square.
+*/
 func (A *DenseMatrix) Det() float64 {
-	B := A.Copy()
-	P := B.LUInPlace()
-	return product(B.DiagonalCopy()) * P.Det()
+	F, err := A.LU()
+	if err != nil {
+		return math.NaN()
+	}
+	return F.Det()
 }
 
 func (A *DenseMatrix) Trace() float64 { return sum(A.DiagonalCopy()) }
@@ -102,12 +84,27 @@ func (A *DenseMatrix) OneNorm() (ε float64) {
 	return
 }
 
+/*
+The spectral norm, the largest singular value of A. NaN if A holds NaN or
+infinite elements.
+*/
 func (A *DenseMatrix) TwoNorm() float64 {
+	s, err := svdValues(A)
+	if err != nil {
+		return math.NaN()
+	}
+	return (&SVD{s: s}).TwoNorm()
+}
+
+/*
+The Frobenius norm, the square root of the sum of squared elements.
+*/
+func (A *DenseMatrix) FrobeniusNorm() float64 {
 	var sum float64 = 0
 	var i, j uint
 	for i = 0; i < A.rows; i++ {
 		for j = 0; j < A.cols; j++ {
-			v := A.elements[i*A.step+j]
+			v := A.elements[A.index(i, j)]
 			sum += v * v
 		}
 	}
@@ -151,45 +148,24 @@ func (A *DenseMatrix) TransposeInPlace() (err error) {
 	return
 }
 
-func solveLower(A *DenseMatrix, b Matrix) *DenseMatrix {
-	x := make([]float64, A.Cols())
-	var i, j uint
-	for i = 0; i < A.Rows(); i++ {
-		x[i] = b.Get(i, 0)
-		for j = 0; j < i; j++ {
-			x[i] -= x[j] * A.Get(i, j)
-		}
-		//the diagonal defined to be ones
-		//x[i] /= A.Get(i, i);
+func (A *DenseMatrix) Solve(b MatrixRO) (*DenseMatrix, error) {
+	// rectangular systems are solved in the least squares sense
+	if A.rows != A.cols {
+		return A.QR().SolveLeastSquares(b)
 	}
-	return MakeDenseMatrix(x, A.Cols(), 1)
-}
 
-func solveUpper(A *DenseMatrix, b Matrix) *DenseMatrix {
-	x := make([]float64, A.Cols())
-	for i := A.Rows() - 1; i >= 0; i-- {
-		x[i] = b.Get(i, 0)
-		for j := i + 1; j < A.Cols(); j++ {
-			x[i] -= x[j] * A.Get(i, j)
+	// symmetric positive definite systems take the cheaper Cholesky path
+	if A.Symmetric() {
+		if C, err := A.Cholesky(); err == nil {
+			return C.SolveMulti(b)
 		}
-		x[i] /= A.Get(i, i)
 	}
-	return MakeDenseMatrix(x, A.Cols(), 1)
-}
 
-func (A *DenseMatrix) Solve(b MatrixRO) (*DenseMatrix, error) {
-	Acopy := A.Copy()
-	P := Acopy.LUInPlace()
-	Pinv := P.Inverse()
-	pb, err := Pinv.Times(b)
-
-	if !(err == nil) {
+	F, err := A.LU()
+	if err != nil {
 		return nil, err
 	}
-
-	y := solveLower(Acopy, pb)
-	x := solveUpper(Acopy, y)
-	return x, nil
+	return F.Solve(b)
 }
 
 func (A *DenseMatrix) SolveDense(b *DenseMatrix) (*DenseMatrix, error) {
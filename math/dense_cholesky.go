@@ -0,0 +1,160 @@
+package math
+
+import "math"
+
+/*
+The Cholesky factorization A = LL' of a symmetric positive definite matrix.
+The factor can be reused to solve against the same matrix many times.
+*/
+type Cholesky struct {
+	// lower triangular factor, the upper part is kept zero
+	l *DenseMatrix
+}
+
+/*
+Computes the Cholesky factorization of A. Only the lower triangle of A is
+read, so A is assumed to be symmetric. Returns ExceptionNotSPD if A is not
+positive definite.
+*/
+func (A *DenseMatrix) Cholesky() (*Cholesky, error) {
+	if A.rows != A.cols {
+		return nil, ErrorDimensionMismatch
+	}
+	n := A.rows
+	L := Zeros(n, n)
+
+	var i, j, k uint
+	for j = 0; j < n; j++ {
+		Lrowj := L.elements[j*L.step : j*L.step+n]
+
+		d := A.elements[A.index(j, j)]
+		for k = 0; k < j; k++ {
+			d -= Lrowj[k] * Lrowj[k]
+		}
+		// the negated test also catches NaN
+		if !(d > 0) {
+			return nil, ExceptionNotSPD
+		}
+		ljj := math.Sqrt(d)
+		Lrowj[j] = ljj
+
+		for i = j + 1; i < n; i++ {
+			Lrowi := L.elements[i*L.step : i*L.step+n]
+			s := A.elements[A.index(i, j)]
+			for k = 0; k < j; k++ {
+				s -= Lrowi[k] * Lrowj[k]
+			}
+			Lrowi[j] = s / ljj
+		}
+	}
+
+	return &Cholesky{l: L}, nil
+}
+
+/*
+Returns a copy of the lower triangular factor L.
+*/
+func (C *Cholesky) L() *DenseMatrix { return C.l.Copy() }
+
+/*
+The dimension of the factorized matrix.
+*/
+func (C *Cholesky) Size() uint { return C.l.rows }
+
+/*
+Returns x such that Ax=b, where b is a single column.
+*/
+func (C *Cholesky) Solve(b MatrixRO) (*DenseMatrix, error) {
+	if b.Cols() != 1 {
+		return nil, ErrorDimensionMismatch
+	}
+	return C.SolveMulti(b)
+}
+
+/*
+Returns X such that AX=B, solving for every column of B at once.
+*/
+func (C *Cholesky) SolveMulti(B MatrixRO) (*DenseMatrix, error) {
+	n := C.l.rows
+	if B.Rows() != n {
+		return nil, ErrorDimensionMismatch
+	}
+	X := MakeDenseCopy(B)
+	C.solveInPlace(X)
+	return X, nil
+}
+
+// Overwrites X with the solution of LL'Y=X.
+func (C *Cholesky) solveInPlace(X *DenseMatrix) {
+	L := C.l
+	n := L.rows
+	m := X.cols
+	var i, j, k uint
+
+	// forward substitution with L
+	for i = 0; i < n; i++ {
+		Xrowi := X.elements[i*X.step : i*X.step+m]
+		for k = 0; k < i; k++ {
+			lik := L.elements[i*L.step+k]
+			if lik == 0 {
+				continue
+			}
+			Xrowk := X.elements[k*X.step : k*X.step+m]
+			for j = 0; j < m; j++ {
+				Xrowi[j] -= lik * Xrowk[j]
+			}
+		}
+		lii := L.elements[i*L.step+i]
+		for j = 0; j < m; j++ {
+			Xrowi[j] /= lii
+		}
+	}
+
+	// back substitution with L'
+	for i = n; i > 0; i-- {
+		r := i - 1
+		Xrowr := X.elements[r*X.step : r*X.step+m]
+		for k = r + 1; k < n; k++ {
+			lkr := L.elements[k*L.step+r]
+			if lkr == 0 {
+				continue
+			}
+			Xrowk := X.elements[k*X.step : k*X.step+m]
+			for j = 0; j < m; j++ {
+				Xrowr[j] -= lkr * Xrowk[j]
+			}
+		}
+		lrr := L.elements[r*L.step+r]
+		for j = 0; j < m; j++ {
+			Xrowr[j] /= lrr
+		}
+	}
+}
+
+/*
+The determinant of the factorized matrix.
+*/
+func (C *Cholesky) Det() float64 {
+	d := product(C.l.DiagonalCopy())
+	return d * d
+}
+
+/*
+The natural logarithm of the determinant, which does not overflow for large
+matrices.
+*/
+func (C *Cholesky) LogDet() (ld float64) {
+	for _, v := range C.l.DiagonalCopy() {
+		ld += math.Log(v)
+	}
+	return 2 * ld
+}
+
+/*
+The inverse of the factorized matrix.
+*/
+func (C *Cholesky) Inverse() *DenseMatrix {
+	X := Eye(C.l.rows)
+	C.solveInPlace(X)
+	return X
+}
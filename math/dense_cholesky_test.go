@@ -0,0 +1,50 @@
+package math
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCholesky(t *testing.T) {
+	A := MakeDenseMatrix([]float64{
+		4, 12, -16,
+		12, 37, -43,
+		-16, -43, 98}, 3, 3)
+
+	C, err := A.Cholesky()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Lr := MakeDenseMatrix([]float64{
+		2, 0, 0,
+		6, 1, 0,
+		-8, 5, 3}, 3, 3)
+	if !ApproxEquals(C.L(), Lr, 1e-12) {
+		t.Errorf("L=%v", C.L())
+	}
+
+	if math.Abs(C.Det()-36) > 1e-9 || math.Abs(C.LogDet()-math.Log(36)) > 1e-12 {
+		t.Errorf("det=%v logdet=%v", C.Det(), C.LogDet())
+	}
+
+	b := MakeDenseMatrix([]float64{1, 2, 3}, 3, 1)
+	x, err := C.Solve(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ApproxEquals(Product(A, x), b, 1e-9) {
+		t.Errorf("x=%v", x)
+	}
+
+	if !ApproxEquals(Product(A, C.Inverse()), Eye(3), 1e-9) {
+		t.Error()
+	}
+}
+
+func TestCholeskyNotSPD(t *testing.T) {
+	A := MakeDenseMatrix([]float64{1, 2, 2, 1}, 2, 2)
+	if _, err := A.Cholesky(); err != ExceptionNotSPD {
+		t.Errorf("expected %v, got %v", ExceptionNotSPD, err)
+	}
+}
@@ -9,8 +9,6 @@ have 1s in the diagonal.
 func (A *DenseMatrix) LUInPlace() (P *PivotMatrix) {
 	m := A.Rows()
 	n := A.Cols()
-	LUcolj := make([]float64, m)
-	LUrowi := make([]float64, n)
 	piv := make([]uint, m)
 	var i, j, k uint
 	for i = 0; i < m; i++ {
@@ -18,40 +16,36 @@ func (A *DenseMatrix) LUInPlace() (P *PivotMatrix) {
 	}
 	pivsign := float64(1.0)
 
-	for j = 0; j < n; j++ {
-		A.BufferCol(j, LUcolj)
-		for i = 0; i < m; i++ {
-			A.BufferRow(i, LUrowi)
-			kmax := i
-			if j < i {
-				kmax = j
-			}
-			s := float64(0)
-			for k = 0; k < kmax; k++ {
-				s += LUrowi[k] * LUcolj[k]
-			}
-			LUcolj[i] -= s
-			LUrowi[j] = LUcolj[i]
-			A.Set(i, j, LUrowi[j])
-		}
-
-		p := j
-		for i := j + 1; i < m; i++ {
-			if math.Abs(LUcolj[i]) > math.Abs(LUcolj[p]) {
+	kmax := minUInt(m, n)
+	for k = 0; k < kmax; k++ {
+		p := k
+		for i = k + 1; i < m; i++ {
+			if math.Abs(A.elements[A.index(i, k)]) > math.Abs(A.elements[A.index(p, k)]) {
 				p = i
 			}
 		}
-		if p != j {
-			A.SwapRows(p, j)
-			k := piv[p]
-			piv[p] = piv[j]
-			piv[j] = k
+		if p != k {
+			A.SwapRows(p, k)
+			piv[p], piv[k] = piv[k], piv[p]
 			pivsign = -pivsign
 		}
 
-		if j < m && A.Get(j, j) != 0 {
-			for i := j + 1; i < m; i++ {
-				A.Set(i, j, A.Get(i, j)/A.Get(j, j))
+		akk := A.elements[A.index(k, k)]
+		if akk == 0 {
+			continue
+		}
+		for i = k + 1; i < m; i++ {
+			A.elements[A.index(i, k)] /= akk
+			lik := A.elements[A.index(i, k)]
+			if lik == 0 {
+				continue
+			}
+			ij := A.index(i, k+1)
+			kj := A.index(k, k+1)
+			for j = k + 1; j < n; j++ {
+				A.elements[ij] -= lik * A.elements[kj]
+				ij += A.inc
+				kj += A.inc
 			}
 		}
 	}
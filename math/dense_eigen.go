@@ -0,0 +1,626 @@
+package math
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// upper bound on the QR iterations spent on a single eigenvalue
+const hqrMaxIter = 1000
+
+/*
+The eigendecomposition AV = VD of a general real square matrix. Complex
+eigenvalues come in conjugate pairs, stored next to each other with the
+positive imaginary part first.
+*/
+type Eigen struct {
+	// real and imaginary parts of the eigenvalues
+	wr, wi []float64
+
+	// real block form of the eigenvectors: for a complex pair k, k+1 the
+	// columns k and k+1 hold the real and imaginary parts of the vector
+	v *DenseMatrix
+}
+
+/*
+Computes the eigenvalues and right eigenvectors of the square matrix A by
+orthogonal reduction to upper Hessenberg form followed by the shifted QR
+algorithm. Symmetric matrices are passed to EigenSym. Returns
+ExceptionNoConvergence if the QR iteration stalls.
+*/
+func (A *DenseMatrix) Eigen() (*Eigen, error) {
+	if A.rows != A.cols {
+		return nil, ErrorDimensionMismatch
+	}
+	n := int(A.rows)
+
+	if A.Symmetric() {
+		S, err := A.EigenSym()
+		if err != nil {
+			return nil, err
+		}
+		return &Eigen{wr: S.values, wi: make([]float64, n), v: S.vectors}, nil
+	}
+
+	H := MakeDenseCopy(A)
+	V := Eye(A.rows)
+	d := make([]float64, n)
+	e := make([]float64, n)
+
+	h := H.Arrays()
+	v := V.Arrays()
+	orthes(n, h, v)
+	if err := hqr2(n, h, v, d, e); err != nil {
+		return nil, err
+	}
+	return &Eigen{wr: d, wi: e, v: V}, nil
+}
+
+/*
+Returns the eigenvalues.
+*/
+func (E *Eigen) Values() []complex128 {
+	w := make([]complex128, len(E.wr))
+	for k := range w {
+		w[k] = complex(E.wr[k], E.wi[k])
+	}
+	return w
+}
+
+/*
+Returns the real parts of the eigenvalues.
+*/
+func (E *Eigen) RealValues() []float64 {
+	w := make([]float64, len(E.wr))
+	copy(w, E.wr)
+	return w
+}
+
+/*
+Returns the imaginary parts of the eigenvalues.
+*/
+func (E *Eigen) ImagValues() []float64 {
+	w := make([]float64, len(E.wi))
+	copy(w, E.wi)
+	return w
+}
+
+/*
+Returns true if every eigenvalue is real.
+*/
+func (E *Eigen) IsReal() bool {
+	for _, v := range E.wi {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Returns a copy of the eigenvectors in real block form. A real eigenvalue k
+has its eigenvector in column k; for a complex pair k, k+1 the vectors are
+V[:,k] ± i*V[:,k+1].
+*/
+func (E *Eigen) RealVectors() *DenseMatrix { return E.v.Copy() }
+
+/*
+Returns the eigenvectors, Vectors()[k] belonging to Values()[k]. Every vector
+is scaled to unit Euclidean norm.
+*/
+func (E *Eigen) Vectors() [][]complex128 {
+	n := E.v.rows
+	V := E.v
+	vecs := make([][]complex128, len(E.wr))
+	var i, k uint
+	for k = 0; k < uint(len(E.wr)); k++ {
+		x := make([]complex128, n)
+		switch {
+		case E.wi[k] > 0:
+			for i = 0; i < n; i++ {
+				x[i] = complex(V.elements[i*V.step+k], V.elements[i*V.step+k+1])
+			}
+		case E.wi[k] < 0:
+			for i = 0; i < n; i++ {
+				x[i] = complex(V.elements[i*V.step+k-1], -V.elements[i*V.step+k])
+			}
+		default:
+			for i = 0; i < n; i++ {
+				x[i] = complex(V.elements[i*V.step+k], 0)
+			}
+		}
+
+		var nrm float64
+		for _, c := range x {
+			nrm = math.Hypot(nrm, cmplx.Abs(c))
+		}
+		if nrm != 0 {
+			for c := range x {
+				x[c] /= complex(nrm, 0)
+			}
+		}
+		vecs[k] = x
+	}
+	return vecs
+}
+
+/*
+Nonsymmetric reduction to Hessenberg form by orthogonal similarity
+transformations, accumulated into V. Derived from the Algol procedures orthes
+and ortran by Martin and Wilkinson, Handbook for Auto. Comp., Vol.ii-Linear
+Algebra, and the corresponding Fortran subroutines in EISPACK.
+*/
+func orthes(n int, H, V [][]float64) {
+	low := 0
+	high := n - 1
+	ort := make([]float64, n)
+
+	for m := low + 1; m <= high-1; m++ {
+		// scale column
+		scale := float64(0)
+		for i := m; i <= high; i++ {
+			scale += math.Abs(H[i][m-1])
+		}
+		if scale == 0 {
+			continue
+		}
+
+		// compute Householder transformation
+		h := float64(0)
+		for i := high; i >= m; i-- {
+			ort[i] = H[i][m-1] / scale
+			h += ort[i] * ort[i]
+		}
+		g := math.Sqrt(h)
+		if ort[m] > 0 {
+			g = -g
+		}
+		h = h - ort[m]*g
+		ort[m] = ort[m] - g
+
+		// apply Householder similarity transformation
+		// H = (I-u*u'/h)*H*(I-u*u')/h)
+		for j := m; j < n; j++ {
+			f := float64(0)
+			for i := high; i >= m; i-- {
+				f += ort[i] * H[i][j]
+			}
+			f = f / h
+			for i := m; i <= high; i++ {
+				H[i][j] -= f * ort[i]
+			}
+		}
+		for i := 0; i <= high; i++ {
+			f := float64(0)
+			for j := high; j >= m; j-- {
+				f += ort[j] * H[i][j]
+			}
+			f = f / h
+			for j := m; j <= high; j++ {
+				H[i][j] -= f * ort[j]
+			}
+		}
+		ort[m] = scale * ort[m]
+		H[m][m-1] = scale * g
+	}
+
+	// accumulate transformations (algorithm ortran)
+	for m := high - 1; m >= low+1; m-- {
+		if H[m][m-1] == 0 {
+			continue
+		}
+		for i := m + 1; i <= high; i++ {
+			ort[i] = H[i][m-1]
+		}
+		for j := m; j <= high; j++ {
+			g := float64(0)
+			for i := m; i <= high; i++ {
+				g += ort[i] * V[i][j]
+			}
+			// double division avoids possible underflow
+			g = (g / ort[m]) / H[m][m-1]
+			for i := m; i <= high; i++ {
+				V[i][j] += g * ort[i]
+			}
+		}
+	}
+}
+
+// Complex scalar division (xr+i*xi)/(yr+i*yi).
+func cdiv(xr, xi, yr, yi float64) (float64, float64) {
+	var r, d float64
+	if math.Abs(yr) > math.Abs(yi) {
+		r = yi / yr
+		d = yr + r*yi
+		return (xr + r*xi) / d, (xi - r*xr) / d
+	}
+	r = yr / yi
+	d = yi + r*yr
+	return (r*xr + xi) / d, (r*xi - xr) / d
+}
+
+/*
+Nonsymmetric reduction from Hessenberg to real Schur form, followed by back
+substitution for the eigenvectors. Derived from the Algol procedure hqr2 by
+Martin and Wilkinson, Handbook for Auto. Comp., Vol.ii-Linear Algebra, and
+the corresponding Fortran subroutine in EISPACK.
+*/
+func hqr2(nn int, H, V [][]float64, d, e []float64) error {
+	n := nn - 1
+	low := 0
+	high := nn - 1
+	exshift := float64(0)
+	var p, q, r, s, z, t, w, x, y float64
+
+	// compute matrix norm
+	norm := float64(0)
+	for i := 0; i < nn; i++ {
+		for j := maxInt(i-1, 0); j < nn; j++ {
+			norm += math.Abs(H[i][j])
+		}
+	}
+
+	// outer loop over eigenvalue index
+	iter := 0
+	for n >= low {
+		// look for single small sub-diagonal element
+		l := n
+		for l > low {
+			s = math.Abs(H[l-1][l-1]) + math.Abs(H[l][l])
+			if s == 0 {
+				s = norm
+			}
+			if math.Abs(H[l][l-1]) < eps*s {
+				break
+			}
+			l--
+		}
+
+		// check for convergence
+		if l == n {
+			// one root found
+			H[n][n] = H[n][n] + exshift
+			d[n] = H[n][n]
+			e[n] = 0
+			n--
+			iter = 0
+		} else if l == n-1 {
+			// two roots found
+			w = H[n][n-1] * H[n-1][n]
+			p = (H[n-1][n-1] - H[n][n]) / 2.0
+			q = p*p + w
+			z = math.Sqrt(math.Abs(q))
+			H[n][n] = H[n][n] + exshift
+			H[n-1][n-1] = H[n-1][n-1] + exshift
+			x = H[n][n]
+
+			if q >= 0 {
+				// real pair
+				if p >= 0 {
+					z = p + z
+				} else {
+					z = p - z
+				}
+				d[n-1] = x + z
+				d[n] = d[n-1]
+				if z != 0 {
+					d[n] = x - w/z
+				}
+				e[n-1] = 0
+				e[n] = 0
+				x = H[n][n-1]
+				s = math.Abs(x) + math.Abs(z)
+				p = x / s
+				q = z / s
+				r = math.Sqrt(p*p + q*q)
+				p = p / r
+				q = q / r
+
+				// row modification
+				for j := n - 1; j < nn; j++ {
+					z = H[n-1][j]
+					H[n-1][j] = q*z + p*H[n][j]
+					H[n][j] = q*H[n][j] - p*z
+				}
+
+				// column modification
+				for i := 0; i <= n; i++ {
+					z = H[i][n-1]
+					H[i][n-1] = q*z + p*H[i][n]
+					H[i][n] = q*H[i][n] - p*z
+				}
+
+				// accumulate transformations
+				for i := low; i <= high; i++ {
+					z = V[i][n-1]
+					V[i][n-1] = q*z + p*V[i][n]
+					V[i][n] = q*V[i][n] - p*z
+				}
+			} else {
+				// complex pair
+				d[n-1] = x + p
+				d[n] = x + p
+				e[n-1] = z
+				e[n] = -z
+			}
+			n = n - 2
+			iter = 0
+		} else {
+			// no convergence yet
+			if iter >= hqrMaxIter {
+				return ExceptionNoConvergence
+			}
+
+			// form shift
+			x = H[n][n]
+			y = 0
+			w = 0
+			if l < n {
+				y = H[n-1][n-1]
+				w = H[n][n-1] * H[n-1][n]
+			}
+
+			// Wilkinson's original ad hoc shift
+			if iter == 10 {
+				exshift += x
+				for i := low; i <= n; i++ {
+					H[i][i] -= x
+				}
+				s = math.Abs(H[n][n-1]) + math.Abs(H[n-1][n-2])
+				x = 0.75 * s
+				y = x
+				w = -0.4375 * s * s
+			}
+
+			// MATLAB's new ad hoc shift
+			if iter == 30 {
+				s = (y - x) / 2.0
+				s = s*s + w
+				if s > 0 {
+					s = math.Sqrt(s)
+					if y < x {
+						s = -s
+					}
+					s = x - w/((y-x)/2.0+s)
+					for i := low; i <= n; i++ {
+						H[i][i] -= s
+					}
+					exshift += s
+					x = 0.964
+					y = x
+					w = x
+				}
+			}
+
+			iter++
+
+			// look for two consecutive small sub-diagonal elements
+			m := n - 2
+			for m >= l {
+				z = H[m][m]
+				r = x - z
+				s = y - z
+				p = (r*s-w)/H[m+1][m] + H[m][m+1]
+				q = H[m+1][m+1] - z - r - s
+				r = H[m+2][m+1]
+				s = math.Abs(p) + math.Abs(q) + math.Abs(r)
+				p = p / s
+				q = q / s
+				r = r / s
+				if m == l {
+					break
+				}
+				if math.Abs(H[m][m-1])*(math.Abs(q)+math.Abs(r)) <
+					eps*(math.Abs(p)*(math.Abs(H[m-1][m-1])+math.Abs(z)+math.Abs(H[m+1][m+1]))) {
+					break
+				}
+				m--
+			}
+
+			for i := m + 2; i <= n; i++ {
+				H[i][i-2] = 0
+				if i > m+2 {
+					H[i][i-3] = 0
+				}
+			}
+
+			// double QR step involving rows l:n and columns m:n
+			for k := m; k <= n-1; k++ {
+				notlast := k != n-1
+				if k != m {
+					p = H[k][k-1]
+					q = H[k+1][k-1]
+					r = 0
+					if notlast {
+						r = H[k+2][k-1]
+					}
+					x = math.Abs(p) + math.Abs(q) + math.Abs(r)
+					if x == 0 {
+						continue
+					}
+					p = p / x
+					q = q / x
+					r = r / x
+				}
+
+				s = math.Sqrt(p*p + q*q + r*r)
+				if p < 0 {
+					s = -s
+				}
+				if s == 0 {
+					continue
+				}
+				if k != m {
+					H[k][k-1] = -s * x
+				} else if l != m {
+					H[k][k-1] = -H[k][k-1]
+				}
+				p = p + s
+				x = p / s
+				y = q / s
+				z = r / s
+				q = q / p
+				r = r / p
+
+				// row modification
+				for j := k; j < nn; j++ {
+					p = H[k][j] + q*H[k+1][j]
+					if notlast {
+						p = p + r*H[k+2][j]
+						H[k+2][j] = H[k+2][j] - p*z
+					}
+					H[k][j] = H[k][j] - p*x
+					H[k+1][j] = H[k+1][j] - p*y
+				}
+
+				// column modification
+				for i := 0; i <= minInt(n, k+3); i++ {
+					p = x*H[i][k] + y*H[i][k+1]
+					if notlast {
+						p = p + z*H[i][k+2]
+						H[i][k+2] = H[i][k+2] - p*r
+					}
+					H[i][k] = H[i][k] - p
+					H[i][k+1] = H[i][k+1] - p*q
+				}
+
+				// accumulate transformations
+				for i := low; i <= high; i++ {
+					p = x*V[i][k] + y*V[i][k+1]
+					if notlast {
+						p = p + z*V[i][k+2]
+						V[i][k+2] = V[i][k+2] - p*r
+					}
+					V[i][k] = V[i][k] - p
+					V[i][k+1] = V[i][k+1] - p*q
+				}
+			}
+		}
+	}
+
+	// backsubstitute to find vectors of upper triangular form
+	if norm == 0 {
+		return nil
+	}
+
+	for n = nn - 1; n >= 0; n-- {
+		p = d[n]
+		q = e[n]
+
+		if q == 0 {
+			// real vector
+			l := n
+			H[n][n] = 1.0
+			for i := n - 1; i >= 0; i-- {
+				w = H[i][i] - p
+				r = 0
+				for j := l; j <= n; j++ {
+					r = r + H[i][j]*H[j][n]
+				}
+				if e[i] < 0 {
+					z = w
+					s = r
+				} else {
+					l = i
+					if e[i] == 0 {
+						if w != 0 {
+							H[i][n] = -r / w
+						} else {
+							H[i][n] = -r / (eps * norm)
+						}
+					} else {
+						// solve real equations
+						x = H[i][i+1]
+						y = H[i+1][i]
+						q = (d[i]-p)*(d[i]-p) + e[i]*e[i]
+						t = (x*s - z*r) / q
+						H[i][n] = t
+						if math.Abs(x) > math.Abs(z) {
+							H[i+1][n] = (-r - w*t) / x
+						} else {
+							H[i+1][n] = (-s - y*t) / z
+						}
+					}
+
+					// overflow control
+					t = math.Abs(H[i][n])
+					if (eps*t)*t > 1 {
+						for j := i; j <= n; j++ {
+							H[j][n] = H[j][n] / t
+						}
+					}
+				}
+			}
+		} else if q < 0 {
+			// complex vector
+			l := n - 1
+
+			// last vector component imaginary so matrix is triangular
+			if math.Abs(H[n][n-1]) > math.Abs(H[n-1][n]) {
+				H[n-1][n-1] = q / H[n][n-1]
+				H[n-1][n] = -(H[n][n] - p) / H[n][n-1]
+			} else {
+				H[n-1][n-1], H[n-1][n] = cdiv(0, -H[n-1][n], H[n-1][n-1]-p, q)
+			}
+			H[n][n-1] = 0
+			H[n][n] = 1
+			for i := n - 2; i >= 0; i-- {
+				var ra, sa, vr, vi float64
+				for j := l; j <= n; j++ {
+					ra = ra + H[i][j]*H[j][n-1]
+					sa = sa + H[i][j]*H[j][n]
+				}
+				w = H[i][i] - p
+
+				if e[i] < 0 {
+					z = w
+					r = ra
+					s = sa
+				} else {
+					l = i
+					if e[i] == 0 {
+						H[i][n-1], H[i][n] = cdiv(-ra, -sa, w, q)
+					} else {
+						// solve complex equations
+						x = H[i][i+1]
+						y = H[i+1][i]
+						vr = (d[i]-p)*(d[i]-p) + e[i]*e[i] - q*q
+						vi = (d[i] - p) * 2 * q
+						if vr == 0 && vi == 0 {
+							vr = eps * norm * (math.Abs(w) + math.Abs(q) +
+								math.Abs(x) + math.Abs(y) + math.Abs(z))
+						}
+						H[i][n-1], H[i][n] = cdiv(x*r-z*ra+q*sa, x*s-z*sa-q*ra, vr, vi)
+						if math.Abs(x) > (math.Abs(z) + math.Abs(q)) {
+							H[i+1][n-1] = (-ra - w*H[i][n-1] + q*H[i][n]) / x
+							H[i+1][n] = (-sa - w*H[i][n] - q*H[i][n-1]) / x
+						} else {
+							H[i+1][n-1], H[i+1][n] = cdiv(-r-y*H[i][n-1], -s-y*H[i][n], z, q)
+						}
+					}
+
+					// overflow control
+					t = max(math.Abs(H[i][n-1]), math.Abs(H[i][n]))
+					if (eps*t)*t > 1 {
+						for j := i; j <= n; j++ {
+							H[j][n-1] = H[j][n-1] / t
+							H[j][n] = H[j][n] / t
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// back transformation to get eigenvectors of original matrix
+	for j := nn - 1; j >= low; j-- {
+		for i := low; i <= high; i++ {
+			z = 0
+			for k := low; k <= minInt(j, high); k++ {
+				z = z + V[i][k]*H[k][j]
+			}
+			V[i][j] = z
+		}
+	}
+	return nil
+}
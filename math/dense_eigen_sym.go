@@ -0,0 +1,323 @@
+package math
+
+import (
+	"math"
+	"sort"
+)
+
+/*
+The eigendecomposition A = VDV' of a real symmetric matrix. The eigenvalues
+are sorted in decreasing order and the columns of V are the corresponding
+orthonormal eigenvectors.
+*/
+type EigenSym struct {
+	values  []float64
+	vectors *DenseMatrix
+}
+
+// upper bound on the QL iterations spent on a single eigenvalue
+const tql2MaxIter = 1000
+
+/*
+Computes all eigenvalues and eigenvectors of the symmetric matrix A by
+Householder reduction to tridiagonal form followed by the implicit QL method.
+Only the lower triangle of A is read. Returns ExceptionNoConvergence if the
+iteration fails, as it does when A holds NaN or infinite elements.
+*/
+func (A *DenseMatrix) EigenSym() (*EigenSym, error) {
+	if A.rows != A.cols {
+		return nil, ErrorDimensionMismatch
+	}
+	n := int(A.rows)
+
+	V := Zeros(A.rows, A.cols)
+	var i, j uint
+	for i = 0; i < A.rows; i++ {
+		for j = 0; j <= i; j++ {
+			v := A.elements[A.index(i, j)]
+			V.elements[i*V.step+j] = v
+			V.elements[j*V.step+i] = v
+		}
+	}
+
+	d := make([]float64, n)
+	e := make([]float64, n)
+	if n > 0 {
+		v := V.Arrays()
+		tred2(n, v, d, e)
+		if err := tql2(n, v, d, e); err != nil {
+			return nil, err
+		}
+	}
+
+	// sort in decreasing order, permuting the columns of V alike
+	order := make([]int, n)
+	for k := range order {
+		order[k] = k
+	}
+	sort.SliceStable(order, func(a, b int) bool { return d[order[a]] > d[order[b]] })
+
+	E := &EigenSym{values: make([]float64, n), vectors: Zeros(A.rows, A.cols)}
+	for k, o := range order {
+		E.values[k] = d[o]
+		for i = 0; i < A.rows; i++ {
+			E.vectors.elements[i*E.vectors.step+uint(k)] = V.elements[i*V.step+uint(o)]
+		}
+	}
+	return E, nil
+}
+
+/*
+Computes the k largest eigenvalues of the symmetric matrix A and their
+eigenvectors. The full decomposition is computed and then truncated; for
+large sparse matrices use an iterative solver instead.
+*/
+func (A *DenseMatrix) EigenSymTopK(k uint) (*EigenSym, error) {
+	E, err := A.EigenSym()
+	if err != nil {
+		return nil, err
+	}
+	if k > uint(len(E.values)) {
+		return nil, ErrorIllegalIndex
+	}
+	E.values = E.values[:k]
+	E.vectors = E.vectors.ColRange(0, k).Copy()
+	return E, nil
+}
+
+/*
+Returns a copy of the eigenvalues in decreasing order.
+*/
+func (E *EigenSym) Values() []float64 {
+	v := make([]float64, len(E.values))
+	copy(v, E.values)
+	return v
+}
+
+/*
+Returns a copy of the eigenvectors, stored as the columns of a matrix.
+*/
+func (E *EigenSym) Vectors() *DenseMatrix { return E.vectors.Copy() }
+
+/*
+Returns the eigenvalues as a diagonal matrix D.
+*/
+func (E *EigenSym) D() *DenseMatrix { return Diagonal(E.values) }
+
+/*
+Returns true if every eigenvalue is greater than tol. With tol = 0 this
+tests for positive definiteness.
+*/
+func (E *EigenSym) PositiveDefinite(tol float64) bool {
+	for _, v := range E.values {
+		if v <= tol {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Symmetric Householder reduction to tridiagonal form. On return V holds the
+orthogonal transformation, d the diagonal and e the subdiagonal in e[1:].
+Derived from the Algol procedures tred2 by Bowdler, Martin, Reinsch and
+Wilkinson, Handbook for Auto. Comp., Vol.ii-Linear Algebra, and the
+corresponding Fortran subroutine in EISPACK.
+*/
+func tred2(n int, V [][]float64, d, e []float64) {
+	for j := 0; j < n; j++ {
+		d[j] = V[n-1][j]
+	}
+
+	for i := n - 1; i > 0; i-- {
+		// scale to avoid under/overflow
+		scale := float64(0)
+		h := float64(0)
+		for k := 0; k < i; k++ {
+			scale += math.Abs(d[k])
+		}
+		if scale == 0 {
+			e[i] = d[i-1]
+			for j := 0; j < i; j++ {
+				d[j] = V[i-1][j]
+				V[i][j] = 0
+				V[j][i] = 0
+			}
+		} else {
+			// generate Householder vector
+			for k := 0; k < i; k++ {
+				d[k] /= scale
+				h += d[k] * d[k]
+			}
+			f := d[i-1]
+			g := math.Sqrt(h)
+			if f > 0 {
+				g = -g
+			}
+			e[i] = scale * g
+			h = h - f*g
+			d[i-1] = f - g
+			for j := 0; j < i; j++ {
+				e[j] = 0
+			}
+
+			// apply similarity transformation to remaining columns
+			for j := 0; j < i; j++ {
+				f = d[j]
+				V[j][i] = f
+				g = e[j] + V[j][j]*f
+				for k := j + 1; k <= i-1; k++ {
+					g += V[k][j] * d[k]
+					e[k] += V[k][j] * f
+				}
+				e[j] = g
+			}
+			f = 0
+			for j := 0; j < i; j++ {
+				e[j] /= h
+				f += e[j] * d[j]
+			}
+			hh := f / (h + h)
+			for j := 0; j < i; j++ {
+				e[j] -= hh * d[j]
+			}
+			for j := 0; j < i; j++ {
+				f = d[j]
+				g = e[j]
+				for k := j; k <= i-1; k++ {
+					V[k][j] -= f*e[k] + g*d[k]
+				}
+				d[j] = V[i-1][j]
+				V[i][j] = 0
+			}
+		}
+		d[i] = h
+	}
+
+	// accumulate transformations
+	for i := 0; i < n-1; i++ {
+		V[n-1][i] = V[i][i]
+		V[i][i] = 1
+		h := d[i+1]
+		if h != 0 {
+			for k := 0; k <= i; k++ {
+				d[k] = V[k][i+1] / h
+			}
+			for j := 0; j <= i; j++ {
+				g := float64(0)
+				for k := 0; k <= i; k++ {
+					g += V[k][i+1] * V[k][j]
+				}
+				for k := 0; k <= i; k++ {
+					V[k][j] -= g * d[k]
+				}
+			}
+		}
+		for k := 0; k <= i; k++ {
+			V[k][i+1] = 0
+		}
+	}
+	for j := 0; j < n; j++ {
+		d[j] = V[n-1][j]
+		V[n-1][j] = 0
+	}
+	V[n-1][n-1] = 1
+	e[0] = 0
+}
+
+/*
+Symmetric tridiagonal QL algorithm. On return d holds the eigenvalues, in no
+particular order, and V the eigenvectors. Derived from the Algol procedure
+tql2 by Bowdler, Martin, Reinsch and Wilkinson, Handbook for Auto. Comp.,
+Vol.ii-Linear Algebra, and the corresponding Fortran subroutine in EISPACK.
+Returns ExceptionNoConvergence if an eigenvalue takes more than tql2MaxIter
+iterations or the matrix is not finite.
+*/
+func tql2(n int, V [][]float64, d, e []float64) error {
+	for i := 1; i < n; i++ {
+		e[i-1] = e[i]
+	}
+	e[n-1] = 0
+
+	f := float64(0)
+	tst1 := float64(0)
+	for l := 0; l < n; l++ {
+		// find small subdiagonal element
+		tst1 = max(tst1, math.Abs(d[l])+math.Abs(e[l]))
+		m := l
+		for m < n {
+			if math.Abs(e[m]) <= eps*tst1 {
+				break
+			}
+			m++
+		}
+		// e[n-1] is zero, so only a NaN can get past it
+		if m == n {
+			return ExceptionNoConvergence
+		}
+
+		// if m == l, d[l] is an eigenvalue, otherwise iterate
+		if m > l {
+			for iter := 0; ; iter++ {
+				if iter >= tql2MaxIter {
+					return ExceptionNoConvergence
+				}
+				// compute implicit shift
+				g := d[l]
+				p := (d[l+1] - g) / (2 * e[l])
+				r := math.Hypot(p, 1)
+				if p < 0 {
+					r = -r
+				}
+				d[l] = e[l] / (p + r)
+				d[l+1] = e[l] * (p + r)
+				dl1 := d[l+1]
+				h := g - d[l]
+				for i := l + 2; i < n; i++ {
+					d[i] -= h
+				}
+				f += h
+
+				// implicit QL transformation
+				p = d[m]
+				c := float64(1)
+				c2 := c
+				c3 := c
+				el1 := e[l+1]
+				s := float64(0)
+				s2 := float64(0)
+				for i := m - 1; i >= l; i-- {
+					c3 = c2
+					c2 = c
+					s2 = s
+					g = c * e[i]
+					h = c * p
+					r = math.Hypot(p, e[i])
+					e[i+1] = s * r
+					s = e[i] / r
+					c = p / r
+					p = c*d[i] - s*g
+					d[i+1] = h + s*(c*g+s*d[i])
+
+					// accumulate transformation
+					for k := 0; k < n; k++ {
+						h = V[k][i+1]
+						V[k][i+1] = s*V[k][i] + c*h
+						V[k][i] = c*V[k][i] - s*h
+					}
+				}
+				p = -s * s2 * c3 * el1 * e[l] / dl1
+				e[l] = s * p
+				d[l] = c * p
+
+				// check for convergence
+				if math.Abs(e[l]) <= eps*tst1 {
+					break
+				}
+			}
+		}
+		d[l] += f
+		e[l] = 0
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package math
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEigenSym(t *testing.T) {
+	A := MakeDenseMatrix([]float64{
+		2, -1, 0, 0,
+		-1, 2, -1, 0,
+		0, -1, 2, -1,
+		0, 0, -1, 2}, 4, 4)
+
+	E, err := A.EigenSym()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the eigenvalues of the second difference matrix are 2-2cos(kπ/5)
+	values := E.Values()
+	for k := 0; k < 4; k++ {
+		expect := 2 - 2*math.Cos(float64(4-k)*math.Pi/5)
+		if math.Abs(values[k]-expect) > 1e-12 {
+			t.Errorf("values=%v", values)
+		}
+	}
+
+	V := E.Vectors()
+	if !ApproxEquals(Product(V.Transpose(), V), Eye(4), 1e-12) {
+		t.Errorf("V'V=%v", Product(V.Transpose(), V))
+	}
+	if !ApproxEquals(Product(V, E.D(), V.Transpose()), A, 1e-12) {
+		t.Errorf("VDV'=%v", Product(V, E.D(), V.Transpose()))
+	}
+	if !E.PositiveDefinite(0) {
+		t.Error()
+	}
+
+	T, err := A.EigenSymTopK(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(T.Values()) != 2 || T.Values()[0] != values[0] || T.Vectors().Cols() != 2 {
+		t.Errorf("top-k values=%v", T.Values())
+	}
+	T, err = A.EigenSymTopK(0)
+	if err != nil || len(T.Values()) != 0 || T.Vectors().Rows() != 4 || T.Vectors().Cols() != 0 {
+		t.Errorf("top-0: %v %v", T, err)
+	}
+	if _, err := A.EigenSymTopK(5); err != ErrorIllegalIndex {
+		t.Errorf("top-5: %v", err)
+	}
+
+	for _, x := range []float64{math.NaN(), math.Inf(1)} {
+		B := MakeDenseMatrix([]float64{1, 2, 0, 2, x, 1, 0, 1, 3}, 3, 3)
+		if _, err := B.EigenSym(); err != ExceptionNoConvergence {
+			t.Errorf("EigenSym with %v: %v", x, err)
+		}
+		if _, err := B.Eigen(); err == nil {
+			t.Errorf("Eigen with %v", x)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package math
+
+import (
+	"math/cmplx"
+	"testing"
+)
+
+func checkEigen(t *testing.T, A *DenseMatrix, E *Eigen) {
+	n := A.Rows()
+	values := E.Values()
+	vectors := E.Vectors()
+	var i, j uint
+	for k := range values {
+		x := vectors[k]
+		for i = 0; i < n; i++ {
+			var Ax complex128
+			for j = 0; j < n; j++ {
+				Ax += complex(A.Get(i, j), 0) * x[j]
+			}
+			if cmplx.Abs(Ax-values[k]*x[i]) > 1e-10 {
+				t.Errorf("eigenpair %d: λ=%v x=%v", k, values[k], x)
+				break
+			}
+		}
+	}
+}
+
+func TestEigenComplex(t *testing.T) {
+	A := MakeDenseMatrix([]float64{
+		0, -1, 0,
+		1, 0, 0,
+		0, 0, 2}, 3, 3)
+
+	E, err := A.Eigen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if E.IsReal() {
+		t.Errorf("values=%v", E.Values())
+	}
+	found := 0
+	for _, v := range E.Values() {
+		for _, w := range []complex128{1i, -1i, 2} {
+			if cmplx.Abs(v-w) < 1e-12 {
+				found++
+			}
+		}
+	}
+	if found != 3 {
+		t.Errorf("values=%v", E.Values())
+	}
+	checkEigen(t, A, E)
+}
+
+func TestEigenGeneral(t *testing.T) {
+	A := MakeDenseMatrix([]float64{
+		0.9, 0.075, 0.025, 0.3,
+		0.15, 0.8, 0.05, 0.1,
+		0.25, 0.25, 0.5, -2,
+		1, 2, 3, 4}, 4, 4)
+
+	E, err := A.Eigen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkEigen(t, A, E)
+
+	var tr complex128
+	for _, v := range E.Values() {
+		tr += v
+	}
+	if cmplx.Abs(tr-complex(A.Trace(), 0)) > 1e-10 {
+		t.Errorf("trace=%v", tr)
+	}
+}
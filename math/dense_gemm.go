@@ -0,0 +1,185 @@
+package math
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Tile sizes of the blocked product: an mc by kc panel of A and a kc by nc
+// panel of B are packed into contiguous buffers so the inner kernel streams
+// through memory that stays in cache.
+const (
+	gemmMC = 64
+	gemmKC = 256
+	gemmNC = 1024
+
+	// products with fewer multiply-adds than this run on one goroutine
+	gemmParallelThreshold = 1 << 16
+)
+
+/*
+Tuning parameters for the dense matrix product. The zero value is ready to
+use.
+*/
+type GemmOptions struct {
+	// Number of goroutines computing the product. Values below 1 select
+	// runtime.GOMAXPROCS(0).
+	Workers int
+}
+
+/*
+Computes C = alpha*op(A)*op(B) + beta*C with the default options, where
+op(X) is X or its transpose X' depending on transA and transB.
+*/
+func Gemm(transA, transB bool, alpha float64, A, B *DenseMatrix, beta float64, C *DenseMatrix) error {
+	return GemmOptions{}.Gemm(transA, transB, alpha, A, B, beta, C)
+}
+
+/*
+Computes C = alpha*op(A)*op(B) + beta*C, where op(X) is X or its transpose
+X' depending on transA and transB. C must not share storage with A or B.
+When beta is zero C is overwritten and its previous contents, even NaNs,
+are ignored.
+*/
+func (o GemmOptions) Gemm(transA, transB bool, alpha float64, A, B *DenseMatrix, beta float64, C *DenseMatrix) error {
+	m, k := A.rows, A.cols
+	if transA {
+		m, k = k, m
+	}
+	kb, n := B.rows, B.cols
+	if transB {
+		kb, n = n, kb
+	}
+	if k != kb || C.rows != m || C.cols != n {
+		return ErrorDimensionMismatch
+	}
+
+	if !C.rowContiguous() {
+		// a transposed view of C has contiguous rows, fill it with
+		// (op(A)op(B))' = op(B)'op(A)'
+		if Ct := C.T(); Ct.rowContiguous() {
+			return o.Gemm(!transB, !transA, alpha, B, A, beta, Ct)
+		}
+		T := C.Copy()
+		if err := o.Gemm(transA, transB, alpha, A, B, beta, T); err != nil {
+			return err
+		}
+		C.SetMatrix(0, 0, T)
+		return nil
+	}
+
+	if beta != 1 {
+		for i := uint(0); i < m; i++ {
+			Crow := C.elements[i*C.step : i*C.step+n]
+			if beta == 0 {
+				for c := range Crow {
+					Crow[c] = 0
+				}
+			} else {
+				for c := range Crow {
+					Crow[c] *= beta
+				}
+			}
+		}
+	}
+	if alpha == 0 || m == 0 || n == 0 || k == 0 {
+		return nil
+	}
+
+	workers := o.Workers
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if m*n*k < gemmParallelThreshold {
+		workers = 1
+	}
+	mblocks := int((m + gemmMC - 1) / gemmMC)
+	if workers > mblocks {
+		workers = mblocks
+	}
+
+	Bp := make([]float64, gemmKC*gemmNC)
+	Aps := make([][]float64, workers)
+	for w := range Aps {
+		Aps[w] = make([]float64, gemmMC*gemmKC)
+	}
+
+	for jc := uint(0); jc < n; jc += gemmNC {
+		nc := minUInt(gemmNC, n-jc)
+		for pc := uint(0); pc < k; pc += gemmKC {
+			kc := minUInt(gemmKC, k-pc)
+			packPanel(Bp, B, transB, pc, jc, kc, nc)
+
+			if workers == 1 {
+				for ic := uint(0); ic < m; ic += gemmMC {
+					gemmBlock(Aps[0], Bp, A, transA, alpha, C, ic, pc, jc, minUInt(gemmMC, m-ic), kc, nc)
+				}
+				continue
+			}
+
+			// workers split the row blocks of C, the packed B panel is shared
+			var wg sync.WaitGroup
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for b := w; b < mblocks; b += workers {
+						ic := uint(b) * gemmMC
+						gemmBlock(Aps[w], Bp, A, transA, alpha, C, ic, pc, jc, minUInt(gemmMC, m-ic), kc, nc)
+					}
+				}(w)
+			}
+			wg.Wait()
+		}
+	}
+	return nil
+}
+
+// Packs the rows by cols block of op(X) starting at (r0, c0) into buf,
+// row-major with stride cols.
+func packPanel(buf []float64, X *DenseMatrix, trans bool, r0, c0, rows, cols uint) {
+	var r, c uint
+	if trans {
+		// op(X)[r][c] = X[c][r]
+		for c = 0; c < cols; c++ {
+			index := X.index(c0+c, r0)
+			for r = 0; r < rows; r++ {
+				buf[r*cols+c] = X.elements[index]
+				index += X.inc
+			}
+		}
+		return
+	}
+	for r = 0; r < rows; r++ {
+		index := X.index(r0+r, c0)
+		if X.inc == 1 {
+			copy(buf[r*cols:r*cols+cols], X.elements[index:index+cols])
+			continue
+		}
+		for c = 0; c < cols; c++ {
+			buf[r*cols+c] = X.elements[index]
+			index += X.inc
+		}
+	}
+}
+
+// Accumulates alpha*op(A)[ic:ic+mc, pc:pc+kc]*Bp into C[ic:ic+mc, jc:jc+nc].
+func gemmBlock(Ap, Bp []float64, A *DenseMatrix, transA bool, alpha float64, C *DenseMatrix, ic, pc, jc, mc, kc, nc uint) {
+	packPanel(Ap, A, transA, ic, pc, mc, kc)
+
+	var i, p uint
+	for i = 0; i < mc; i++ {
+		Crow := C.elements[(ic+i)*C.step+jc : (ic+i)*C.step+jc+nc]
+		Arow := Ap[i*kc : i*kc+kc]
+		for p = 0; p < kc; p++ {
+			a := alpha * Arow[p]
+			if a == 0 {
+				continue
+			}
+			Brow := Bp[p*nc : p*nc+nc]
+			for j, b := range Brow {
+				Crow[j] += a * b
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package math
+
+import "testing"
+
+// naive reference product of op(A) and op(B)
+func naiveProduct(transA, transB bool, A, B *DenseMatrix) *DenseMatrix {
+	if transA {
+		A = A.Transpose()
+	}
+	if transB {
+		B = B.Transpose()
+	}
+	C := Zeros(A.Rows(), B.Cols())
+	var i, j, k uint
+	for i = 0; i < A.Rows(); i++ {
+		for j = 0; j < B.Cols(); j++ {
+			var s float64
+			for k = 0; k < A.Cols(); k++ {
+				s += A.Get(i, k) * B.Get(k, j)
+			}
+			C.Set(i, j, s)
+		}
+	}
+	return C
+}
+
+func TestGemm(t *testing.T) {
+	// sizes straddle the tile boundaries
+	var m, k, n uint = 131, 300, 70
+	for _, trans := range [][2]bool{{false, false}, {true, false}, {false, true}, {true, true}} {
+		A, B := Normals(m, k), Normals(k, n)
+		if trans[0] {
+			A = Normals(k, m)
+		}
+		if trans[1] {
+			B = Normals(n, k)
+		}
+		C0 := Normals(m, n)
+
+		for _, workers := range []int{1, 3} {
+			C := C0.Copy()
+			err := GemmOptions{Workers: workers}.Gemm(trans[0], trans[1], 2, A, B, -1, C)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expect := naiveProduct(trans[0], trans[1], A, B)
+			expect.Scale(2)
+			expect.Subtract(C0)
+			if !ApproxEquals(C, expect, 1e-10) {
+				t.Errorf("trans=%v workers=%d", trans, workers)
+			}
+		}
+	}
+}
+
+func TestGemmDimensionMismatch(t *testing.T) {
+	if err := Gemm(false, false, 1, Zeros(2, 3), Zeros(2, 3), 0, Zeros(2, 3)); err != ErrorDimensionMismatch {
+		t.Errorf("expected %v, got %v", ErrorDimensionMismatch, err)
+	}
+}
@@ -0,0 +1,348 @@
+package math
+
+import "math"
+
+// upper bound on the iterations of the condition number estimator
+const rcondMaxIter = 5
+
+/*
+The LU factorization with partial pivoting PLU = A of a square matrix. The
+factor is computed once and can be reused for any number of solves.
+*/
+type LU struct {
+	// L below the unit diagonal, U on and above it
+	lu *DenseMatrix
+
+	// row i of LU comes from row piv[i] of A
+	piv     []uint
+	pivsign float64
+
+	// one-norm of A, kept for the condition estimate
+	anorm float64
+}
+
+/*
+Computes the LU factorization of the square matrix A. A is left untouched.
+*/
+func (A *DenseMatrix) LU() (*LU, error) {
+	if A.rows != A.cols {
+		return nil, ErrorDimensionMismatch
+	}
+
+	F := new(LU)
+	F.anorm = denseOneNorm(A)
+	F.lu = MakeDenseCopy(A)
+	P := F.lu.LUInPlace()
+	F.piv = P.pivots
+	F.pivsign = P.pivotSign
+	return F, nil
+}
+
+// The maximum absolute column sum of A.
+func denseOneNorm(A *DenseMatrix) (norm float64) {
+	sums := make([]float64, A.cols)
+	var i, j uint
+	for i = 0; i < A.rows; i++ {
+		for j = 0; j < A.cols; j++ {
+			sums[j] += math.Abs(A.elements[A.index(i, j)])
+		}
+	}
+	for _, s := range sums {
+		norm = max(norm, s)
+	}
+	return
+}
+
+/*
+The dimension of the factorized matrix.
+*/
+func (F *LU) Size() uint { return F.lu.rows }
+
+/*
+Returns the unit lower triangular factor L.
+*/
+func (F *LU) L() *DenseMatrix {
+	n := F.lu.rows
+	L := Zeros(n, n)
+	var i, j uint
+	for i = 0; i < n; i++ {
+		for j = 0; j < i; j++ {
+			L.elements[i*L.step+j] = F.lu.elements[i*F.lu.step+j]
+		}
+		L.elements[i*L.step+i] = 1
+	}
+	return L
+}
+
+/*
+Returns the upper triangular factor U.
+*/
+func (F *LU) U() *DenseMatrix {
+	n := F.lu.rows
+	U := Zeros(n, n)
+	var i, j uint
+	for i = 0; i < n; i++ {
+		for j = i; j < n; j++ {
+			U.elements[i*U.step+j] = F.lu.elements[i*F.lu.step+j]
+		}
+	}
+	return U
+}
+
+/*
+Returns the pivot matrix P, st PLU=A.
+*/
+func (F *LU) P() *PivotMatrix {
+	piv := make([]uint, len(F.piv))
+	copy(piv, F.piv)
+	return MakePivotMatrix(piv, F.pivsign)
+}
+
+/*
+Returns true if U has a zero on its diagonal.
+*/
+func (F *LU) Singular() bool {
+	n := F.lu.rows
+	for i := uint(0); i < n; i++ {
+		if F.lu.elements[i*F.lu.step+i] == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Returns X such that AX=B, solving for every column of B at once.
+*/
+func (F *LU) Solve(B MatrixRO) (*DenseMatrix, error) {
+	n := F.lu.rows
+	if B.Rows() != n {
+		return nil, ErrorDimensionMismatch
+	}
+	if F.Singular() {
+		return nil, ExceptionSingular
+	}
+
+	m := B.Cols()
+	X := Zeros(n, m)
+	var i, j uint
+	for i = 0; i < n; i++ {
+		for j = 0; j < m; j++ {
+			X.elements[i*X.step+j] = B.Get(F.piv[i], j)
+		}
+	}
+	F.solveInPlace(X)
+	return X, nil
+}
+
+// Overwrites the row-permuted right hand sides X with the solution of LUY=X.
+func (F *LU) solveInPlace(X *DenseMatrix) {
+	LU := F.lu
+	n := LU.rows
+	m := X.cols
+	var i, j, k uint
+
+	// forward substitution with the unit lower triangle
+	for i = 1; i < n; i++ {
+		Xrowi := X.elements[i*X.step : i*X.step+m]
+		for k = 0; k < i; k++ {
+			lik := LU.elements[i*LU.step+k]
+			if lik == 0 {
+				continue
+			}
+			Xrowk := X.elements[k*X.step : k*X.step+m]
+			for j = 0; j < m; j++ {
+				Xrowi[j] -= lik * Xrowk[j]
+			}
+		}
+	}
+
+	// back substitution with the upper triangle
+	for i = n; i > 0; i-- {
+		r := i - 1
+		Xrowr := X.elements[r*X.step : r*X.step+m]
+		for k = r + 1; k < n; k++ {
+			urk := LU.elements[r*LU.step+k]
+			if urk == 0 {
+				continue
+			}
+			Xrowk := X.elements[k*X.step : k*X.step+m]
+			for j = 0; j < m; j++ {
+				Xrowr[j] -= urk * Xrowk[j]
+			}
+		}
+		urr := LU.elements[r*LU.step+r]
+		for j = 0; j < m; j++ {
+			Xrowr[j] /= urr
+		}
+	}
+}
+
+/*
+Returns X such that A'X=B, without forming the transpose of A.
+*/
+func (F *LU) SolveTranspose(B MatrixRO) (*DenseMatrix, error) {
+	n := F.lu.rows
+	if B.Rows() != n {
+		return nil, ErrorDimensionMismatch
+	}
+	if F.Singular() {
+		return nil, ExceptionSingular
+	}
+
+	LU := F.lu
+	m := B.Cols()
+	W := MakeDenseCopy(B)
+	var i, j, k uint
+
+	// forward substitution with U'
+	for i = 0; i < n; i++ {
+		Wrowi := W.elements[i*W.step : i*W.step+m]
+		for k = 0; k < i; k++ {
+			uki := LU.elements[k*LU.step+i]
+			if uki == 0 {
+				continue
+			}
+			Wrowk := W.elements[k*W.step : k*W.step+m]
+			for j = 0; j < m; j++ {
+				Wrowi[j] -= uki * Wrowk[j]
+			}
+		}
+		uii := LU.elements[i*LU.step+i]
+		for j = 0; j < m; j++ {
+			Wrowi[j] /= uii
+		}
+	}
+
+	// back substitution with the unit upper triangle L'
+	for i = n; i > 0; i-- {
+		r := i - 1
+		Wrowr := W.elements[r*W.step : r*W.step+m]
+		for k = r + 1; k < n; k++ {
+			lkr := LU.elements[k*LU.step+r]
+			if lkr == 0 {
+				continue
+			}
+			Wrowk := W.elements[k*W.step : k*W.step+m]
+			for j = 0; j < m; j++ {
+				Wrowr[j] -= lkr * Wrowk[j]
+			}
+		}
+	}
+
+	// undo the row pivoting
+	X := Zeros(n, m)
+	for i = 0; i < n; i++ {
+		copy(X.elements[F.piv[i]*X.step:F.piv[i]*X.step+m], W.elements[i*W.step:i*W.step+m])
+	}
+	return X, nil
+}
+
+/*
+The determinant of the factorized matrix.
+*/
+func (F *LU) Det() float64 {
+	return F.pivsign * product(F.lu.DiagonalCopy())
+}
+
+/*
+The natural logarithm of the absolute value of the determinant, and its sign.
+Unlike Det this does not overflow for large matrices. A singular matrix gives
+-Inf and a sign of 0.
+*/
+func (F *LU) LogDet() (logabs, sign float64) {
+	sign = F.pivsign
+	for _, v := range F.lu.DiagonalCopy() {
+		if v == 0 {
+			return math.Inf(-1), 0
+		}
+		if v < 0 {
+			sign = -sign
+		}
+		logabs += math.Log(math.Abs(v))
+	}
+	return
+}
+
+/*
+The inverse of the factorized matrix.
+*/
+func (F *LU) Inverse() (*DenseMatrix, error) {
+	return F.Solve(Eye(F.lu.rows))
+}
+
+/*
+Estimates the reciprocal condition number 1/(||A||*||A^-1||) in the one-norm,
+using Hager's method as refined by Higham. A value near 0 signals an
+ill-conditioned matrix, a singular matrix gives exactly 0.
+*/
+func (F *LU) RCond() float64 {
+	n := F.lu.rows
+	if n == 0 {
+		return 1
+	}
+	if F.Singular() || F.anorm == 0 {
+		return 0
+	}
+
+	vec := func(x []float64) *DenseMatrix { return MakeDenseMatrix(x, n, 1) }
+	oneNorm := func(x []float64) (s float64) {
+		for _, v := range x {
+			s += math.Abs(v)
+		}
+		return
+	}
+
+	x := make([]float64, n)
+	for i := range x {
+		x[i] = 1 / float64(n)
+	}
+
+	var est float64
+	last := -1
+	for iter := 0; iter < rcondMaxIter; iter++ {
+		y, _ := F.Solve(vec(x))
+		est = oneNorm(y.elements)
+
+		xi := make([]float64, n)
+		for i, v := range y.elements {
+			if v >= 0 {
+				xi[i] = 1
+			} else {
+				xi[i] = -1
+			}
+		}
+		z, _ := F.SolveTranspose(vec(xi))
+
+		jmax := 0
+		var ztx float64
+		for i, v := range z.elements {
+			ztx += v * x[i]
+			if math.Abs(v) > math.Abs(z.elements[jmax]) {
+				jmax = i
+			}
+		}
+		if math.Abs(z.elements[jmax]) <= ztx || jmax == last {
+			break
+		}
+		for i := range x {
+			x[i] = 0
+		}
+		x[jmax] = 1
+		last = jmax
+	}
+
+	// Higham's alternative vector guards against the worst cases of Hager's
+	if n > 1 {
+		for i := range x {
+			x[i] = 1 + float64(i)/float64(n-1)
+			if i%2 == 1 {
+				x[i] = -x[i]
+			}
+		}
+		y, _ := F.Solve(vec(x))
+		est = max(est, 2*oneNorm(y.elements)/(3*float64(n)))
+	}
+
+	return 1 / (F.anorm * est)
+}
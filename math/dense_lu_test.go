@@ -0,0 +1,72 @@
+package math
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLU(t *testing.T) {
+	A := MakeDenseMatrix([]float64{
+		0, 2, 1,
+		1, 1, 0,
+		3, 0, 4}, 3, 3)
+
+	F, err := A.LU()
+	if err != nil {
+		t.Fatal(err)
+	}
+	PLU, _ := F.P().Times(Product(F.L(), F.U()))
+	if !ApproxEquals(PLU, A, 1e-12) {
+		t.Errorf("PLU=%v", PLU)
+	}
+
+	if math.Abs(F.Det()-(-11)) > 1e-12 || math.Abs(A.Det()-(-11)) > 1e-12 {
+		t.Errorf("det=%v", F.Det())
+	}
+	logabs, sign := F.LogDet()
+	if math.Abs(logabs-math.Log(11)) > 1e-12 || sign != -1 {
+		t.Errorf("logdet=%v sign=%v", logabs, sign)
+	}
+
+	B := MakeDenseMatrix([]float64{1, 0, 2, 1, 3, -1}, 3, 2)
+	X, err := F.Solve(B)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ApproxEquals(Product(A, X), B, 1e-12) {
+		t.Errorf("X=%v", X)
+	}
+
+	Y, err := F.SolveTranspose(B)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ApproxEquals(Product(A.Transpose(), Y), B, 1e-12) {
+		t.Errorf("Y=%v", Y)
+	}
+
+	Ainv, err := A.Inverse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ApproxEquals(Product(A, Ainv), Eye(3), 1e-12) {
+		t.Errorf("inverse=%v", Ainv)
+	}
+
+	// the estimate is exact for small matrices
+	rcond := 1 / (denseOneNorm(A) * denseOneNorm(Ainv))
+	if math.Abs(F.RCond()-rcond) > 1e-12 {
+		t.Errorf("rcond=%v expected %v", F.RCond(), rcond)
+	}
+}
+
+func TestLUSingular(t *testing.T) {
+	A := MakeDenseMatrix([]float64{1, 2, 2, 4}, 2, 2)
+	F, _ := A.LU()
+	if _, err := F.Solve(Eye(2)); err != ExceptionSingular {
+		t.Errorf("expected %v, got %v", ExceptionSingular, err)
+	}
+	if F.RCond() != 0 {
+		t.Error()
+	}
+}
@@ -0,0 +1,320 @@
+package math
+
+import "math"
+
+const (
+	// upper bound on the iterations of the matrix square root
+	sqrtmMaxIter = 100
+
+	// the square root iteration converges quadratically, so one more step
+	// after M is this close to the identity reaches double precision
+	sqrtmTol = 1e-8
+
+	// upper bound on the square roots taken by the matrix logarithm
+	logmMaxSqrt = 64
+)
+
+// Padé coefficients of exp and the one-norm bounds up to which the degree
+// 3, 5, 7, 9 and 13 approximants are accurate to double precision. From
+// Higham, "The scaling and squaring method for the matrix exponential
+// revisited", SIAM J. Matrix Anal. Appl. 26(4), 2005.
+var (
+	expmTheta = []float64{
+		1.495585217958292e-2, 2.539398330063230e-1, 9.504178996162932e-1,
+		2.097847961257068}
+	expmCoef = [][]float64{
+		{120, 60, 12, 1},
+		{30240, 15120, 3360, 420, 30, 1},
+		{17297280, 8648640, 1995840, 277200, 25200, 1512, 56, 1},
+		{17643225600, 8821612800, 2075673600, 302702400, 30270240,
+			2162160, 110880, 3960, 90, 1},
+	}
+	expmTheta13 = 5.371920351148152
+	expmCoef13  = []float64{
+		64764752532480000, 32382376266240000, 7771770303897600,
+		1187353796428800, 129060195264000, 10559470521600, 670442572800,
+		33522128640, 1323241920, 40840800, 960960, 16380, 182, 1}
+)
+
+// Nodes and weights of the 8 point Gauss-Legendre rule on [0, 1]. Applied to
+// log(I+X) = integral of X(I+sX)^-1 over s in [0, 1] the rule gives the
+// [8/8] Padé approximant of the logarithm.
+var (
+	logmNodes = []float64{
+		0.01985507175123186, 0.10166676129318664, 0.23723379504183550,
+		0.40828267875217510, 0.59171732124782490, 0.76276620495816450,
+		0.89833323870681340, 0.98014492824876810}
+	logmWeights = []float64{
+		0.05061426814518813, 0.11119051722668724, 0.15685332293894363,
+		0.18134189168918100, 0.18134189168918100, 0.15685332293894363,
+		0.11119051722668724, 0.05061426814518813}
+)
+
+/*
+The matrix exponential of the square matrix A, computed by scaling and
+squaring with a Padé approximant of degree up to 13. For a generator Q of a
+continuous-time Markov chain, Scaled(Q, t).Expm() gives the transition
+probabilities over time t.
+*/
+func (A *DenseMatrix) Expm() (*DenseMatrix, error) {
+	if A.rows != A.cols {
+		return nil, ErrorDimensionMismatch
+	}
+	X := A.Copy()
+	norm := denseOneNorm(X)
+
+	for d, theta := range expmTheta {
+		if norm <= theta {
+			return expmPade(X, expmCoef[d])
+		}
+	}
+
+	// scale so that the degree 13 approximant is accurate, then square back
+	s := 0
+	if norm > expmTheta13 && !math.IsInf(norm, 0) {
+		s = int(math.Ceil(math.Log2(norm / expmTheta13)))
+		X.Scale(math.Ldexp(1, -s))
+	}
+	R, err := expmPade13(X)
+	if err != nil {
+		return nil, err
+	}
+	for ; s > 0; s-- {
+		R = denseMul(R, R)
+	}
+	return R, nil
+}
+
+// The degree len(b)-1 Padé approximant of exp(A), for an odd degree.
+func expmPade(A *DenseMatrix, b []float64) (*DenseMatrix, error) {
+	n := A.rows
+	m := len(b) - 1
+
+	// the even powers I, A^2, A^4, ...
+	pows := []*DenseMatrix{nil, denseMul(A, A)}
+	for len(pows) <= m/2 {
+		pows = append(pows, denseMul(pows[len(pows)-1], pows[1]))
+	}
+	even := make([]float64, len(pows))
+	odd := make([]float64, len(pows))
+	for k := range pows {
+		even[k] = b[2*k]
+		odd[k] = b[2*k+1]
+	}
+
+	U := denseMul(A, denseLinComb(n, odd, pows))
+	V := denseLinComb(n, even, pows)
+	return expmSolve(U, V)
+}
+
+// The degree 13 Padé approximant of exp(A), evaluated with six products.
+func expmPade13(A *DenseMatrix) (*DenseMatrix, error) {
+	n := A.rows
+	b := expmCoef13
+	A2 := denseMul(A, A)
+	A4 := denseMul(A2, A2)
+	A6 := denseMul(A4, A2)
+	pows := []*DenseMatrix{A6, A4, A2, nil}
+
+	W := denseMul(A6, denseLinComb(n, []float64{b[13], b[11], b[9]}, pows[:3]))
+	U := denseMul(A, denseLinComb(n, []float64{1, b[7], b[5], b[3], b[1]},
+		append([]*DenseMatrix{W}, pows...)))
+
+	Z := denseMul(A6, denseLinComb(n, []float64{b[12], b[10], b[8]}, pows[:3]))
+	V := denseLinComb(n, []float64{1, b[6], b[4], b[2], b[0]},
+		append([]*DenseMatrix{Z}, pows...))
+	return expmSolve(U, V)
+}
+
+// Solves (V-U)R = V+U, the rational part of a Padé approximant of exp.
+func expmSolve(U, V *DenseMatrix) (*DenseMatrix, error) {
+	n := U.rows
+	F, err := denseLinComb(n, []float64{1, -1}, []*DenseMatrix{V, U}).LU()
+	if err != nil {
+		return nil, err
+	}
+	return F.Solve(denseLinComb(n, []float64{1, 1}, []*DenseMatrix{V, U}))
+}
+
+/*
+The principal square root of the square matrix A, the unique square root
+whose eigenvalues have positive real part. It is computed by the product form
+of the Denman-Beavers iteration with determinant scaling. A must not have
+eigenvalues on the closed negative real axis; if it does, the iteration breaks
+down with ExceptionSingular or ExceptionNoConvergence.
+*/
+func (A *DenseMatrix) Sqrtm() (*DenseMatrix, error) {
+	if A.rows != A.cols {
+		return nil, ErrorDimensionMismatch
+	}
+	n := A.rows
+	if n == 0 {
+		return Zeros(0, 0), nil
+	}
+
+	// Y converges to the square root and M to the identity
+	Y := A.Copy()
+	M := A.Copy()
+	for iter := 0; iter < sqrtmMaxIter; iter++ {
+		converged := denseDistToIdentity(M) <= sqrtmTol
+
+		F, err := M.LU()
+		if err != nil {
+			return nil, err
+		}
+		Minv, err := F.Inverse()
+		if err != nil {
+			return nil, err
+		}
+		mu := float64(1)
+		if logabs, sign := F.LogDet(); sign != 0 {
+			mu = math.Exp(-logabs / float64(2*n))
+		}
+		mu2 := mu * mu
+
+		Y = denseMul(Y, denseLinComb(n, []float64{1, 1 / mu2}, []*DenseMatrix{nil, Minv}))
+		Y.Scale(mu / 2)
+		M = denseLinComb(n, []float64{0.5, mu2 / 4, 1 / (4 * mu2)}, []*DenseMatrix{nil, M, Minv})
+
+		if converged {
+			return Y, nil
+		}
+	}
+	return nil, ExceptionNoConvergence
+}
+
+/*
+The principal logarithm of the square matrix A, the unique logarithm whose
+eigenvalues have imaginary part in (-pi, pi). It is computed by inverse
+scaling and squaring: square roots are taken until A is close to the
+identity, where a Padé approximant is accurate. A must not have eigenvalues
+on the closed negative real axis, see Sqrtm.
+*/
+func (A *DenseMatrix) Logm() (*DenseMatrix, error) {
+	if A.rows != A.cols {
+		return nil, ErrorDimensionMismatch
+	}
+	n := A.rows
+
+	X := A.Copy()
+	k := 0
+	for denseDistToIdentity(X) > 0.25 {
+		if k == logmMaxSqrt {
+			return nil, ExceptionNoConvergence
+		}
+		var err error
+		if X, err = X.Sqrtm(); err != nil {
+			return nil, err
+		}
+		k++
+	}
+
+	// log(I+E) by quadrature, E and (I+sE)^-1 commute
+	E := denseLinComb(n, []float64{1, -1}, []*DenseMatrix{X, nil})
+	L := Zeros(n, n)
+	for q, s := range logmNodes {
+		F, err := denseLinComb(n, []float64{1, s}, []*DenseMatrix{nil, E}).LU()
+		if err != nil {
+			return nil, err
+		}
+		Y, err := F.Solve(E)
+		if err != nil {
+			return nil, err
+		}
+		L = denseLinComb(n, []float64{1, logmWeights[q]}, []*DenseMatrix{L, Y})
+	}
+	L.Scale(math.Ldexp(1, k))
+	return L, nil
+}
+
+/*
+Returns A^p for the square matrix A by repeated squaring. A negative p is a
+power of the inverse and gives ExceptionSingular for a singular A; A^0 is
+the identity.
+*/
+func (A *DenseMatrix) PowInt(p int) (*DenseMatrix, error) {
+	if A.rows != A.cols {
+		return nil, ErrorDimensionMismatch
+	}
+	B := A.Copy()
+	if p < 0 {
+		var err error
+		if B, err = B.Inverse(); err != nil {
+			return nil, err
+		}
+		p = -p
+	}
+
+	R := Eye(A.rows)
+	for ; p > 0; p >>= 1 {
+		if p&1 == 1 {
+			R = denseMul(R, B)
+		}
+		if p > 1 {
+			B = denseMul(B, B)
+		}
+	}
+	return R, nil
+}
+
+/*
+Returns A^p for the square matrix A and real p. Integer powers are computed
+by PowInt; otherwise A^p = A^floor(p) exp(f log(A)), where f is the
+fractional part of p, which requires A to have no eigenvalues on the closed
+negative real axis.
+*/
+func (A *DenseMatrix) Pow(p float64) (*DenseMatrix, error) {
+	if A.rows != A.cols {
+		return nil, ErrorDimensionMismatch
+	}
+	ip, frac := math.Modf(p)
+	if frac < 0 {
+		ip, frac = ip-1, frac+1
+	}
+	P, err := A.PowInt(int(ip))
+	if err != nil || frac == 0 {
+		return P, err
+	}
+
+	var F *DenseMatrix
+	if frac == 0.5 {
+		F, err = A.Sqrtm()
+	} else if F, err = A.Logm(); err == nil {
+		F.Scale(frac)
+		F, err = F.Expm()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return denseMul(P, F), nil
+}
+
+// The product of two matrices of matching dimensions.
+func denseMul(A, B *DenseMatrix) *DenseMatrix {
+	C := Zeros(A.rows, B.cols)
+	Gemm(false, false, 1, A, B, 0, C)
+	return C
+}
+
+// Returns the n by n matrix sum of c[k]*X[k], where a nil X[k] stands for the
+// identity. The other X[k] must be contiguous n by n matrices.
+func denseLinComb(n uint, c []float64, X []*DenseMatrix) *DenseMatrix {
+	C := Zeros(n, n)
+	for k, Xk := range X {
+		if Xk == nil {
+			for i := uint(0); i < n; i++ {
+				C.elements[i*C.step+i] += c[k]
+			}
+			continue
+		}
+		for e, v := range Xk.elements {
+			C.elements[e] += c[k] * v
+		}
+	}
+	return C
+}
+
+// The one-norm of A-I.
+func denseDistToIdentity(A *DenseMatrix) float64 {
+	return denseOneNorm(denseLinComb(A.rows, []float64{1, -1}, []*DenseMatrix{A, nil}))
+}
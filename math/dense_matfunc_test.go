@@ -0,0 +1,88 @@
+package math
+
+import (
+	"math"
+	"testing"
+)
+
+func TestExpm(t *testing.T) {
+	// nilpotent, exact after two terms
+	N := MakeDenseMatrix([]float64{0, 1, 0, 0}, 2, 2)
+	E, err := N.Expm()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ApproxEquals(E, MakeDenseMatrix([]float64{1, 1, 0, 1}, 2, 2), 1e-15) {
+		t.Errorf("exp(N)=%v", E)
+	}
+
+	// a rotation generator large enough to need scaling and squaring
+	R := MakeDenseMatrix([]float64{0, -10, 10, 0}, 2, 2)
+	E, _ = R.Expm()
+	c, s := math.Cos(10), math.Sin(10)
+	if !ApproxEquals(E, MakeDenseMatrix([]float64{c, -s, s, c}, 2, 2), 1e-12) {
+		t.Errorf("exp(R)=%v", E)
+	}
+
+	// a stiff two state Markov generator
+	a, b := 1000.0, 1.0
+	Q := MakeDenseMatrix([]float64{-a, a, b, -b}, 2, 2)
+	P, _ := Scaled(Q, 0.5).Expm()
+	d := math.Exp(-(a + b) * 0.5)
+	expect := MakeDenseMatrix([]float64{
+		b + a*d, a - a*d,
+		b - b*d, a + b*d}, 2, 2)
+	expect.Scale(1 / (a + b))
+	if !ApproxEquals(P, expect, 1e-12) {
+		t.Errorf("P=%v, expected %v", P, expect)
+	}
+}
+
+func TestSqrtmLogm(t *testing.T) {
+	A := MakeDenseMatrix([]float64{4, 1, 0, 9}, 2, 2)
+	S, err := A.Sqrtm()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ApproxEquals(S, MakeDenseMatrix([]float64{2, 0.2, 0, 3}, 2, 2), 1e-12) {
+		t.Errorf("sqrt(A)=%v", S)
+	}
+
+	B := MakeDenseMatrix([]float64{
+		5, 1, 2,
+		0, 3, 1,
+		1, 1, 4}, 3, 3)
+	L, err := B.Logm()
+	if err != nil {
+		t.Fatal(err)
+	}
+	E, _ := L.Expm()
+	if !ApproxEquals(E, B, 1e-12) {
+		t.Errorf("exp(log(B))=%v", E)
+	}
+
+	if _, err := Scaled(Eye(2), -1).Sqrtm(); err == nil {
+		t.Errorf("sqrt(-I): %v", err)
+	}
+}
+
+func TestPow(t *testing.T) {
+	A := MakeDenseMatrix([]float64{2, 1, 1, 3}, 2, 2)
+	P, _ := A.PowInt(3)
+	if !ApproxEquals(P, Product(A, A, A), 1e-12) {
+		t.Errorf("A^3=%v", P)
+	}
+	P, _ = A.PowInt(-2)
+	if !ApproxEquals(Product(P, A, A), Eye(2), 1e-12) {
+		t.Errorf("A^-2=%v", P)
+	}
+	P, _ = A.Pow(1.5)
+	S, _ := A.Sqrtm()
+	if !ApproxEquals(P, Product(A, S), 1e-12) {
+		t.Errorf("A^1.5=%v", P)
+	}
+	P, _ = A.Pow(1.0 / 3)
+	if !ApproxEquals(Product(P, P, P), A, 1e-12) {
+		t.Errorf("A^(1/3)=%v", P)
+	}
+}
@@ -0,0 +1,288 @@
+package math
+
+import "math"
+
+/*
+The Householder QR factorization AP = QR of an m by n matrix. P is the
+identity unless the factorization was computed with column pivoting.
+*/
+type QR struct {
+	// Householder vectors below the diagonal, R above it
+	qr *DenseMatrix
+
+	// the diagonal of R
+	rdiag []float64
+
+	// column permutation, nil when no pivoting was done
+	piv []uint
+}
+
+/*
+Computes the Householder QR factorization of A. A is left untouched.
+*/
+func (A *DenseMatrix) QR() *QR {
+	return A.qr(false)
+}
+
+/*
+Computes the column-pivoted Householder QR factorization of A. At every step
+the remaining column with the largest norm is moved to the front, so the
+diagonal of R is non-increasing in magnitude and the rank is revealed.
+*/
+func (A *DenseMatrix) QRPivoted() *QR {
+	return A.qr(true)
+}
+
+func (A *DenseMatrix) qr(pivoting bool) *QR {
+	m, n := A.rows, A.cols
+	QRm := MakeDenseCopy(A)
+	a := QRm.elements
+	step := QRm.step
+	kmax := minUInt(m, n)
+	rdiag := make([]float64, kmax)
+
+	var piv []uint
+	if pivoting {
+		piv = make([]uint, n)
+		for j := uint(0); j < n; j++ {
+			piv[j] = j
+		}
+	}
+
+	var i, j, k uint
+	for k = 0; k < kmax; k++ {
+		if pivoting {
+			p, pnorm := k, float64(-1)
+			for j = k; j < n; j++ {
+				var s float64
+				for i = k; i < m; i++ {
+					v := a[i*step+j]
+					s += v * v
+				}
+				if s > pnorm {
+					p, pnorm = j, s
+				}
+			}
+			if p != k {
+				for i = 0; i < m; i++ {
+					a[i*step+k], a[i*step+p] = a[i*step+p], a[i*step+k]
+				}
+				piv[k], piv[p] = piv[p], piv[k]
+			}
+		}
+
+		var nrm float64
+		for i = k; i < m; i++ {
+			nrm = math.Hypot(nrm, a[i*step+k])
+		}
+
+		if nrm != 0 {
+			if a[k*step+k] < 0 {
+				nrm = -nrm
+			}
+			for i = k; i < m; i++ {
+				a[i*step+k] /= nrm
+			}
+			a[k*step+k] += 1
+
+			for j = k + 1; j < n; j++ {
+				var s float64
+				for i = k; i < m; i++ {
+					s += a[i*step+k] * a[i*step+j]
+				}
+				s = -s / a[k*step+k]
+				for i = k; i < m; i++ {
+					a[i*step+j] += s * a[i*step+k]
+				}
+			}
+		}
+		rdiag[k] = -nrm
+	}
+
+	return &QR{qr: QRm, rdiag: rdiag, piv: piv}
+}
+
+/*
+Returns true if the factorization was computed with column pivoting.
+*/
+func (F *QR) Pivoted() bool { return F.piv != nil }
+
+/*
+Returns the column permutation P such that AP = QR.
+*/
+func (F *QR) P() *PivotMatrix {
+	n := F.qr.cols
+	pivots := make([]uint, n)
+	var j uint
+	for j = 0; j < n; j++ {
+		if F.piv != nil {
+			pivots[j] = F.piv[j]
+		} else {
+			pivots[j] = j
+		}
+	}
+	sign := float64(1)
+	seen := make([]bool, n)
+	for j = 0; j < n; j++ {
+		if seen[j] {
+			continue
+		}
+		// every cycle of length l contributes l-1 transpositions
+		for k := j; !seen[k]; k = pivots[k] {
+			seen[k] = true
+			if pivots[k] != j {
+				sign = -sign
+			}
+		}
+	}
+	return MakePivotMatrix(pivots, sign)
+}
+
+/*
+Returns the upper triangular factor R, of size min(m,n) by n.
+*/
+func (F *QR) R() *DenseMatrix {
+	n := F.qr.cols
+	k := uint(len(F.rdiag))
+	R := Zeros(k, n)
+	var i, j uint
+	for i = 0; i < k; i++ {
+		R.elements[i*R.step+i] = F.rdiag[i]
+		for j = i + 1; j < n; j++ {
+			R.elements[i*R.step+j] = F.qr.elements[i*F.qr.step+j]
+		}
+	}
+	return R
+}
+
+/*
+Returns the thin orthonormal factor Q, of size m by min(m,n).
+*/
+func (F *QR) Q() *DenseMatrix {
+	m := F.qr.rows
+	k := uint(len(F.rdiag))
+	Q := Zeros(m, k)
+	var i uint
+	for i = 0; i < k; i++ {
+		Q.elements[i*Q.step+i] = 1
+	}
+	F.applyQ(Q)
+	return Q
+}
+
+// Overwrites X with QX, X has m rows.
+func (F *QR) applyQ(X *DenseMatrix) {
+	for k := uint(len(F.rdiag)); k > 0; k-- {
+		F.reflect(k-1, X)
+	}
+}
+
+// Overwrites X with Q'X.
+func (F *QR) applyQTranspose(X *DenseMatrix) {
+	for k := uint(0); k < uint(len(F.rdiag)); k++ {
+		F.reflect(k, X)
+	}
+}
+
+// Applies the kth Householder reflection to every column of X.
+func (F *QR) reflect(k uint, X *DenseMatrix) {
+	a := F.qr.elements
+	step := F.qr.step
+	m := F.qr.rows
+
+	vkk := a[k*step+k]
+	if vkk == 0 {
+		// the column was already zero, no reflection was made
+		return
+	}
+	var i, j uint
+	for j = 0; j < X.cols; j++ {
+		var s float64
+		for i = k; i < m; i++ {
+			s += a[i*step+k] * X.elements[i*X.step+j]
+		}
+		s = -s / vkk
+		for i = k; i < m; i++ {
+			X.elements[i*X.step+j] += s * a[i*step+k]
+		}
+	}
+}
+
+/*
+The numerical rank of A, the number of diagonal entries of R above
+max(m,n)*eps*max|R(k,k)|. This is only reliable for a pivoted factorization.
+*/
+func (F *QR) Rank() uint {
+	var big float64
+	for _, d := range F.rdiag {
+		big = max(big, math.Abs(d))
+	}
+	tol := float64(maxUInt(F.qr.rows, F.qr.cols)) * eps * big
+	var r uint
+	for _, d := range F.rdiag {
+		if math.Abs(d) > tol {
+			r++
+		}
+	}
+	return r
+}
+
+/*
+Returns true if R, and hence A, has full column rank.
+*/
+func (F *QR) FullRank() bool {
+	return uint(len(F.rdiag)) == F.qr.cols && F.Rank() == F.qr.cols
+}
+
+/*
+Returns the X minimizing ||AX - B|| in the Frobenius norm, one column per
+column of B. For an unpivoted factorization the leading min(m,n) columns of
+A must be linearly independent, otherwise ExceptionSingular is returned.
+With pivoting a rank deficient A gives the basic solution, which has at most
+Rank() non-zero entries per column.
+*/
+func (F *QR) SolveLeastSquares(b MatrixRO) (*DenseMatrix, error) {
+	m, n := F.qr.rows, F.qr.cols
+	if b.Rows() != m {
+		return nil, ErrorDimensionMismatch
+	}
+
+	r := uint(len(F.rdiag))
+	if F.piv != nil {
+		r = F.Rank()
+	} else if F.Rank() < r {
+		return nil, ExceptionSingular
+	}
+
+	Y := MakeDenseCopy(b)
+	F.applyQTranspose(Y)
+
+	nrhs := Y.cols
+	a := F.qr.elements
+	step := F.qr.step
+	X := Zeros(n, nrhs)
+
+	var i, j, l uint
+	for j = 0; j < nrhs; j++ {
+		for l = r; l > 0; l-- {
+			i = l - 1
+			s := Y.elements[i*Y.step+j]
+			for k := i + 1; k < r; k++ {
+				s -= a[i*step+k] * X.elements[k*X.step+j]
+			}
+			X.elements[i*X.step+j] = s / F.rdiag[i]
+		}
+	}
+
+	if F.piv == nil {
+		return X, nil
+	}
+
+	// undo the column pivoting, x = Pz
+	Xp := Zeros(n, nrhs)
+	for i = 0; i < n; i++ {
+		copy(Xp.elements[F.piv[i]*Xp.step:F.piv[i]*Xp.step+nrhs],
+			X.elements[i*X.step:i*X.step+nrhs])
+	}
+	return Xp, nil
+}
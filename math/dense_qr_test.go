@@ -0,0 +1,73 @@
+package math
+
+import (
+	"testing"
+
+	"github.com/hezila/hezila/utils"
+)
+
+func TestQR(t *testing.T) {
+	A := MakeDenseMatrix([]float64{
+		12, -51, 4,
+		6, 167, -68,
+		-4, 24, -41,
+		1, 2, 3}, 4, 3)
+
+	F := A.QR()
+	Q, R := F.Q(), F.R()
+	if !ApproxEquals(Product(Q, R), A, 1e-10) {
+		t.Errorf("QR=%v", Product(Q, R))
+	}
+	if !ApproxEquals(Product(Q.Transpose(), Q), Eye(3), 1e-12) {
+		t.Errorf("Q'Q=%v", Product(Q.Transpose(), Q))
+	}
+	utils.Expect(t, "3", F.Rank())
+}
+
+func TestQRLeastSquares(t *testing.T) {
+	// fit y = 1 + 2x exactly
+	A := MakeDenseMatrix([]float64{
+		1, 0,
+		1, 1,
+		1, 2,
+		1, 3}, 4, 2)
+	b := MakeDenseMatrix([]float64{1, 3, 5, 7}, 4, 1)
+
+	x, err := A.QR().SolveLeastSquares(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ApproxEquals(x, MakeDenseMatrix([]float64{1, 2}, 2, 1), 1e-12) {
+		t.Errorf("x=%v", x)
+	}
+}
+
+func TestQRPivotedRank(t *testing.T) {
+	// the third column is the sum of the first two
+	A := MakeDenseMatrix([]float64{
+		1, 2, 3,
+		4, 5, 9,
+		7, 8, 15,
+		1, 0, 1}, 4, 3)
+
+	F := A.QRPivoted()
+	utils.Expect(t, "2", F.Rank())
+
+	AP := Product(A, F.P().DenseMatrix())
+	if !ApproxEquals(Product(F.Q(), F.R()), AP, 1e-10) {
+		t.Error()
+	}
+
+	b := MakeDenseMatrix([]float64{3, 9, 15, 1}, 4, 1)
+	x, err := F.SolveLeastSquares(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ApproxEquals(Product(A, x), b, 1e-10) {
+		t.Errorf("x=%v", x)
+	}
+
+	if _, err := A.QR().SolveLeastSquares(b); err != ExceptionSingular {
+		t.Errorf("expected %v, got %v", ExceptionSingular, err)
+	}
+}
@@ -0,0 +1,373 @@
+package math
+
+import (
+	"math"
+	"sort"
+)
+
+// upper bound on the Jacobi sweeps, convergence is quadratic so this is
+// never reached in practice
+const svdMaxSweeps = 100
+
+/*
+The singular value decomposition A = USV' of an m by n matrix. S holds the
+min(m,n) singular values in decreasing order. For a thin decomposition U is
+m by min(m,n) and V is n by min(m,n); for a full one U and V are square.
+*/
+type SVD struct {
+	u *DenseMatrix
+	s []float64
+	v *DenseMatrix
+	// the shape of A
+	rows, cols uint
+}
+
+/*
+Computes the thin singular value decomposition of A with the one-sided
+Jacobi method. Returns ExceptionNoConvergence if A holds NaN or infinite
+elements, or if the method does not converge.
+*/
+func (A *DenseMatrix) SVD() (*SVD, error) {
+	return svd(A, true, false)
+}
+
+/*
+Computes the full singular value decomposition of A, U is m by m and V is
+n by n. Errors as SVD.
+*/
+func (A *DenseMatrix) FullSVD() (*SVD, error) {
+	return svd(A, true, true)
+}
+
+// The singular values of A alone, without accumulating U and V.
+func svdValues(A *DenseMatrix) ([]float64, error) {
+	F, err := svd(A, false, false)
+	if err != nil {
+		return nil, err
+	}
+	return F.s, nil
+}
+
+func finite(A *DenseMatrix) bool {
+	var i, j uint
+	for i = 0; i < A.rows; i++ {
+		for j = 0; j < A.cols; j++ {
+			if v := A.elements[A.index(i, j)]; math.IsNaN(v) || math.IsInf(v, 0) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// The decomposition of A, or only its singular values if vectors is false,
+// in which case u and v are nil.
+func svd(A *DenseMatrix, vectors, full bool) (*SVD, error) {
+	if A.rows < A.cols {
+		F, err := svd(A.Transpose(), vectors, full)
+		if err != nil {
+			return nil, err
+		}
+		F.u, F.v = F.v, F.u
+		F.rows, F.cols = F.cols, F.rows
+		return F, nil
+	}
+	if !finite(A) {
+		return nil, ExceptionNoConvergence
+	}
+
+	m, n := A.rows, A.cols
+	F := &SVD{rows: m, cols: n}
+	if n == 0 {
+		if vectors {
+			F.u, F.v = Eye(m), Zeros(0, 0)
+		}
+		return F, nil
+	}
+
+	// rows of W are the columns of A, rows of Vt the columns of V, so the
+	// rotations below run over contiguous memory
+	W := A.Transpose()
+	var Vt *DenseMatrix
+	if vectors {
+		Vt = Eye(n)
+	}
+
+	var p, q, i uint
+	converged := false
+	for sweep := 0; sweep < svdMaxSweeps && !converged; sweep++ {
+		converged = true
+		for p = 0; p+1 < n; p++ {
+			wp := W.elements[p*W.step : p*W.step+m]
+			for q = p + 1; q < n; q++ {
+				wq := W.elements[q*W.step : q*W.step+m]
+
+				var alpha, beta, gamma float64
+				for i = 0; i < m; i++ {
+					alpha += wp[i] * wp[i]
+					beta += wq[i] * wq[i]
+					gamma += wp[i] * wq[i]
+				}
+				if gamma == 0 || math.Abs(gamma) <= eps*math.Sqrt(alpha*beta) {
+					continue
+				}
+				converged = false
+
+				zeta := (beta - alpha) / (2 * gamma)
+				t := 1 / (math.Abs(zeta) + math.Sqrt(1+zeta*zeta))
+				if zeta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(1+t*t)
+				s := c * t
+
+				rotate(wp, wq, c, s)
+				if vectors {
+					rotate(Vt.elements[p*Vt.step:p*Vt.step+n], Vt.elements[q*Vt.step:q*Vt.step+n], c, s)
+				}
+			}
+		}
+	}
+	if !converged {
+		return nil, ExceptionNoConvergence
+	}
+
+	// the singular values are the column norms, sorted in decreasing order
+	sv := make([]float64, n)
+	order := make([]int, n)
+	for p = 0; p < n; p++ {
+		sv[p] = norm2(W.elements[p*W.step : p*W.step+m])
+		order[p] = int(p)
+	}
+	sort.SliceStable(order, func(a, b int) bool { return sv[order[a]] > sv[order[b]] })
+
+	F.s = make([]float64, n)
+	for p = 0; p < n; p++ {
+		F.s[p] = sv[order[p]]
+	}
+	if !vectors {
+		return F, nil
+	}
+
+	ucols := n
+	if full {
+		ucols = m
+	}
+	Ut := Zeros(ucols, m)
+	V := Zeros(n, n)
+	tol := float64(m) * eps * F.s[0]
+
+	var filled uint
+	for p = 0; p < n; p++ {
+		o := uint(order[p])
+		for i = 0; i < n; i++ {
+			V.elements[i*V.step+p] = Vt.elements[o*Vt.step+i]
+		}
+		if sv[o] > tol {
+			wo := W.elements[o*W.step : o*W.step+m]
+			up := Ut.elements[p*Ut.step : p*Ut.step+m]
+			for i = 0; i < m; i++ {
+				up[i] = wo[i] / sv[o]
+			}
+			filled = p + 1
+		}
+	}
+
+	// rank deficient columns and the extra columns of a full U are
+	// completed to an orthonormal basis
+	completeBasis(Ut, filled)
+
+	F.u, F.v = Ut.Transpose(), V
+	return F, nil
+}
+
+// Applies the plane rotation [c -s; s c] to the pair of rows x, y.
+func rotate(x, y []float64, c, s float64) {
+	for i := range x {
+		xi, yi := x[i], y[i]
+		x[i] = c*xi - s*yi
+		y[i] = s*xi + c*yi
+	}
+}
+
+func norm2(x []float64) (n float64) {
+	for _, v := range x {
+		n = math.Hypot(n, v)
+	}
+	return
+}
+
+// Fills rows k.. of Q with vectors orthonormal to its first k rows, which
+// must be orthonormal already.
+func completeBasis(Q *DenseMatrix, k uint) {
+	cols := Q.cols
+	cand := make([]float64, cols)
+	var e, r, i uint
+	for r = k; r < Q.rows; r++ {
+		row := Q.elements[r*Q.step : r*Q.step+cols]
+		for ; e < cols; e++ {
+			for c := range cand {
+				cand[c] = 0
+			}
+			cand[e] = 1
+			// orthogonalize twice for numerical safety
+			for pass := 0; pass < 2; pass++ {
+				for i = 0; i < r; i++ {
+					qi := Q.elements[i*Q.step : i*Q.step+cols]
+					var d float64
+					for c := range cand {
+						d += qi[c] * cand[c]
+					}
+					for c := range cand {
+						cand[c] -= d * qi[c]
+					}
+				}
+			}
+			if nrm := norm2(cand); nrm > 0.5 {
+				for c := range cand {
+					row[c] = cand[c] / nrm
+				}
+				e++
+				break
+			}
+		}
+	}
+}
+
+/*
+Returns a copy of the left singular vectors U.
+*/
+func (F *SVD) U() *DenseMatrix { return F.u.Copy() }
+
+/*
+Returns a copy of the right singular vectors V.
+*/
+func (F *SVD) V() *DenseMatrix { return F.v.Copy() }
+
+/*
+Returns a copy of the singular values, in decreasing order.
+*/
+func (F *SVD) Values() []float64 {
+	s := make([]float64, len(F.s))
+	copy(s, F.s)
+	return s
+}
+
+/*
+Returns the singular values as a diagonal matrix S, shaped so that A = USV'.
+*/
+func (F *SVD) S() *DenseMatrix {
+	S := Zeros(F.u.cols, F.v.cols)
+	for i, v := range F.s {
+		S.elements[uint(i)*S.step+uint(i)] = v
+	}
+	return S
+}
+
+/*
+The number of singular values greater than tol. A non-positive tol selects
+the default max(m,n)*eps*S(0,0).
+*/
+func (F *SVD) Rank(tol float64) (r uint) {
+	if tol <= 0 {
+		tol = F.defaultTol()
+	}
+	for _, v := range F.s {
+		if v > tol {
+			r++
+		}
+	}
+	return
+}
+
+func (F *SVD) defaultTol() float64 {
+	if len(F.s) == 0 {
+		return 0
+	}
+	return float64(maxUInt(F.rows, F.cols)) * eps * F.s[0]
+}
+
+/*
+The spectral norm, the largest singular value.
+*/
+func (F *SVD) TwoNorm() float64 {
+	if len(F.s) == 0 {
+		return 0
+	}
+	return F.s[0]
+}
+
+/*
+The ratio of the largest to the smallest singular value. Returns +Inf for a
+singular matrix.
+*/
+func (F *SVD) ConditionNumber() float64 {
+	if len(F.s) == 0 {
+		return 0
+	}
+	smin := F.s[len(F.s)-1]
+	if smin == 0 {
+		return math.Inf(1)
+	}
+	return F.s[0] / smin
+}
+
+/*
+The Moore-Penrose pseudo-inverse VS⁺U', where singular values not above the
+default rank tolerance are treated as zero.
+*/
+func (F *SVD) PseudoInverse() *DenseMatrix {
+	m, n := F.u.rows, F.v.rows
+	r := F.Rank(0)
+	P := Zeros(n, m)
+	var i, j, k uint
+	for k = 0; k < r; k++ {
+		inv := 1 / F.s[k]
+		for i = 0; i < n; i++ {
+			vik := F.v.elements[i*F.v.step+k] * inv
+			if vik == 0 {
+				continue
+			}
+			Prow := P.elements[i*P.step : i*P.step+m]
+			for j = 0; j < m; j++ {
+				Prow[j] += vik * F.u.elements[j*F.u.step+k]
+			}
+		}
+	}
+	return P
+}
+
+/*
+The Moore-Penrose pseudo-inverse of A. Errors as SVD.
+*/
+func (A *DenseMatrix) PseudoInverse() (*DenseMatrix, error) {
+	F, err := A.SVD()
+	if err != nil {
+		return nil, err
+	}
+	return F.PseudoInverse(), nil
+}
+
+/*
+The numerical rank of A, the number of singular values greater than tol. A
+non-positive tol selects the default max(m,n)*eps*||A||. Errors as SVD.
+*/
+func (A *DenseMatrix) Rank(tol float64) (uint, error) {
+	s, err := svdValues(A)
+	if err != nil {
+		return 0, err
+	}
+	return (&SVD{s: s, rows: A.rows, cols: A.cols}).Rank(tol), nil
+}
+
+/*
+The condition number of A in the spectral norm, NaN if A holds NaN or
+infinite elements.
+*/
+func (A *DenseMatrix) ConditionNumber() float64 {
+	s, err := svdValues(A)
+	if err != nil {
+		return math.NaN()
+	}
+	return (&SVD{s: s}).ConditionNumber()
+}
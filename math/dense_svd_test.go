@@ -0,0 +1,111 @@
+package math
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hezila/hezila/utils"
+)
+
+func checkSVD(t *testing.T, A *DenseMatrix, F *SVD) {
+	U, S, V := F.U(), F.S(), F.V()
+	if !ApproxEquals(Product(U, S, V.Transpose()), A, 1e-10) {
+		t.Errorf("USV'=%v", Product(U, S, V.Transpose()))
+	}
+	if !ApproxEquals(Product(U.Transpose(), U), Eye(U.Cols()), 1e-10) {
+		t.Errorf("U'U=%v", Product(U.Transpose(), U))
+	}
+	if !ApproxEquals(Product(V.Transpose(), V), Eye(V.Cols()), 1e-10) {
+		t.Errorf("V'V=%v", Product(V.Transpose(), V))
+	}
+	s := F.Values()
+	for i := 1; i < len(s); i++ {
+		if s[i] > s[i-1] {
+			t.Errorf("singular values not sorted: %v", s)
+		}
+	}
+}
+
+func TestSVD(t *testing.T) {
+	A := MakeDenseMatrix([]float64{
+		1, 0, 0, 0, 2,
+		0, 0, 3, 0, 0,
+		0, 0, 0, 0, 0,
+		0, 2, 0, 0, 0}, 4, 5)
+
+	F, err := A.SVD()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSVD(t, A, F)
+	utils.Expect(t, "3", F.Rank(0))
+	if math.Abs(F.Values()[1]-math.Sqrt(5)) > 1e-12 {
+		t.Errorf("values=%v", F.Values())
+	}
+	// the values alone take the same rotations
+	s, _ := svdValues(A)
+	for k, v := range F.Values() {
+		if s[k] != v {
+			t.Errorf("values only=%v", s)
+		}
+	}
+
+	G, _ := A.FullSVD()
+	checkSVD(t, A, G)
+	utils.Expect(t, "4", G.U().Cols())
+	utils.Expect(t, "5", G.V().Cols())
+}
+
+func TestSVDTall(t *testing.T) {
+	A := MakeDenseMatrix([]float64{
+		2, 4,
+		1, 3,
+		0, 0,
+		0, 0}, 4, 2)
+	F, _ := A.SVD()
+	checkSVD(t, A, F)
+	F, _ = A.FullSVD()
+	checkSVD(t, A, F)
+
+	if math.Abs(A.TwoNorm()-5.4649857042190426) > 1e-12 {
+		t.Errorf("norm=%v", A.TwoNorm())
+	}
+	if math.Abs(A.SparseMatrix().TwoNorm()-A.TwoNorm()) > 1e-9 {
+		t.Errorf("sparse norm=%v", A.SparseMatrix().TwoNorm())
+	}
+}
+
+func TestPseudoInverse(t *testing.T) {
+	A := MakeDenseMatrix([]float64{
+		1, 2,
+		2, 4,
+		3, 6}, 3, 2)
+	P, err := A.PseudoInverse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ApproxEquals(Product(A, P, A), A, 1e-10) {
+		t.Errorf("APA=%v", Product(A, P, A))
+	}
+	if !ApproxEquals(Product(P, A, P), P, 1e-10) {
+		t.Errorf("PAP=%v", Product(P, A, P))
+	}
+	r, _ := A.Rank(0)
+	utils.Expect(t, "1", r)
+	utils.Expect(t, "2", Diagonal([]float64{4, -2}).ConditionNumber())
+
+	// NaN gives an error or NaN, not a plausible zero
+	B := MakeDenseMatrix([]float64{1, math.NaN(), 0, 1}, 2, 2)
+	if _, err := B.SVD(); err != ExceptionNoConvergence {
+		t.Errorf("SVD: %v", err)
+	}
+	if _, err := B.Rank(0); err != ExceptionNoConvergence {
+		t.Errorf("Rank: %v", err)
+	}
+	if _, err := B.PseudoInverse(); err != ExceptionNoConvergence {
+		t.Errorf("PseudoInverse: %v", err)
+	}
+	if !math.IsNaN(B.TwoNorm()) || !math.IsNaN(B.T().ConditionNumber()) {
+		t.Errorf("norm %v, condition %v", B.TwoNorm(), B.ConditionNumber())
+	}
+}
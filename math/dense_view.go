@@ -0,0 +1,113 @@
+package math
+
+/*
+Views share storage with the matrix they are taken from: writes through a
+view show up in the original and vice versa. Every DenseMatrix operation
+accepts views directly, without materializing them; use Copy to get an
+independent, contiguous matrix.
+*/
+
+// Builds a view over M's storage whose element (r, c) is element
+// (i + r*rowStride, j + c*colStride) of M.
+func (M *DenseMatrix) view(i, j, rows, cols, rowStride, colStride uint) *DenseMatrix {
+	V := new(DenseMatrix)
+	V.rows = rows
+	V.cols = cols
+	V.step = M.step * rowStride
+	V.inc = M.inc * colStride
+
+	start := M.index(i, j)
+	if rows == 0 || cols == 0 {
+		V.elements = M.elements[start:start]
+	} else {
+		V.elements = M.elements[start : start+V.index(rows-1, cols-1)+1]
+	}
+	return V
+}
+
+/*
+Returns a view of the rows by cols block starting at i, j.
+*/
+func (M *DenseMatrix) View(i, j, rows, cols uint) *DenseMatrix {
+	if i+rows > M.rows || j+cols > M.cols {
+		panic(ErrorIllegalIndex)
+	}
+	return M.view(i, j, rows, cols, 1, 1)
+}
+
+/*
+Returns a view of every rowStride-th row and colStride-th column of M,
+starting at i, j, with rows rows and cols columns.
+*/
+func (M *DenseMatrix) StridedView(i, j, rows, cols, rowStride, colStride uint) *DenseMatrix {
+	if rowStride == 0 || colStride == 0 ||
+		(rows > 0 && i+(rows-1)*rowStride >= M.rows) ||
+		(cols > 0 && j+(cols-1)*colStride >= M.cols) {
+		panic(ErrorIllegalIndex)
+	}
+	return M.view(i, j, rows, cols, rowStride, colStride)
+}
+
+/*
+Returns a view of rows i0 up to, but not including, i1.
+*/
+func (M *DenseMatrix) RowRange(i0, i1 uint) *DenseMatrix {
+	if i0 > i1 {
+		panic(ErrorIllegalIndex)
+	}
+	return M.View(i0, 0, i1-i0, M.cols)
+}
+
+/*
+Returns a view of columns j0 up to, but not including, j1.
+*/
+func (M *DenseMatrix) ColRange(j0, j1 uint) *DenseMatrix {
+	if j0 > j1 {
+		panic(ErrorIllegalIndex)
+	}
+	return M.View(0, j0, M.rows, j1-j0)
+}
+
+/*
+Returns a 1 by cols view of row i.
+*/
+func (M *DenseMatrix) RowView(i uint) *DenseMatrix { return M.View(i, 0, 1, M.cols) }
+
+/*
+Returns a rows by 1 view of column j.
+*/
+func (M *DenseMatrix) ColView(j uint) *DenseMatrix { return M.View(0, j, M.rows, 1) }
+
+/*
+Returns a min(rows, cols) by 1 view of the main diagonal.
+*/
+func (M *DenseMatrix) DiagonalView() *DenseMatrix {
+	n := minUInt(M.rows, M.cols)
+	D := M.view(0, 0, n, 1, 1, 1)
+	D.step = M.step + M.inc
+	if n > 0 {
+		D.elements = M.elements[:D.index(n-1, 0)+1]
+	}
+	return D
+}
+
+/*
+Returns a transposed view: element (i, j) of the view is element (j, i) of M.
+*/
+func (M *DenseMatrix) T() *DenseMatrix {
+	V := new(DenseMatrix)
+	V.rows = M.cols
+	V.cols = M.rows
+	V.step = M.inc
+	V.inc = M.step
+	V.elements = M.elements
+	return V
+}
+
+/*
+Returns true if M is laid out row after row with no gaps, as a freshly
+allocated matrix is.
+*/
+func (M *DenseMatrix) Contiguous() bool {
+	return M.rowContiguous() && (M.step == M.cols || M.rows <= 1)
+}
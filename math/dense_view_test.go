@@ -0,0 +1,71 @@
+package math
+
+import (
+	"testing"
+
+	"github.com/hezila/hezila/utils"
+)
+
+func TestViews(t *testing.T) {
+	A := MakeDenseMatrix([]float64{
+		1, 2, 3, 4,
+		5, 6, 7, 8,
+		9, 10, 11, 12}, 3, 4)
+
+	V := A.View(1, 1, 2, 2)
+	utils.Expect(t, "{ 6,  7,\n 10, 11}", V.String())
+	V.Set(0, 0, -6)
+	utils.Expect(t, "-6", A.Get(1, 1))
+
+	T := A.T()
+	utils.Expect(t, "4", T.Get(3, 0))
+	if !Equals(T, A.Transpose()) {
+		t.Errorf("T=%v", T)
+	}
+
+	S := A.StridedView(0, 1, 2, 2, 2, 2)
+	utils.Expect(t, "{ 2,  4,\n 10, 12}", S.String())
+
+	D := A.DiagonalView()
+	utils.Expect(t, "{ 1,\n -6,\n 11}", D.String())
+	D.Scale(2)
+	utils.Expect(t, "22", A.Get(2, 2))
+
+	utils.Expect(t, "{ 3,\n  7,\n 22}", A.ColView(2).String())
+	utils.Expect(t, "false", A.ColView(2).Contiguous())
+	utils.Expect(t, "true", A.RowRange(1, 3).Contiguous())
+}
+
+func TestViewArithmetic(t *testing.T) {
+	A := MakeDenseMatrix([]float64{
+		1, 2, 3,
+		4, 5, 6,
+		7, 8, 10}, 3, 3)
+
+	// the transposed view factorizes and multiplies like the transpose
+	At := A.T()
+	if !ApproxEquals(Product(At, A), Product(A.Transpose(), A), 1e-12) {
+		t.Error()
+	}
+	b := MakeDenseMatrix([]float64{1, 2, 3}, 3, 1)
+	x, err := At.Solve(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ApproxEquals(Product(A.Transpose(), x), b, 1e-12) {
+		t.Errorf("x=%v", x)
+	}
+
+	// a product written into a transposed view of C
+	C := Zeros(3, 3)
+	if err := Gemm(false, false, 1, A, A, 0, C.T()); err != nil {
+		t.Fatal(err)
+	}
+	if !ApproxEquals(C, Product(A, A).Transpose(), 1e-12) {
+		t.Errorf("C=%v", C)
+	}
+
+	B := A.Copy()
+	B.ColRange(1, 3).Add(Ones(3, 2))
+	utils.Expect(t, "{ 1,  3,  4,\n  4,  6,  7,\n  7,  9, 11}", B.String())
+}
@@ -17,6 +17,10 @@ const (
 	exceptionSingular
 	// The matrix provided is not positive semi-definite.
 	exceptionNotSPD
+	// An iterative method did not converge.
+	exceptionNoConvergence
+	// A parameter is outside of its valid range.
+	errorIllegalArgument
 )
 
 type error_ int
@@ -35,6 +39,10 @@ func (e error_) Error() string {
 		return "Matrix is singular"
 	case exceptionNotSPD:
 		return "Matrix is not positive semidefinite"
+	case exceptionNoConvergence:
+		return "Iteration did not converge"
+	case errorIllegalArgument:
+		return "Argument out of range"
 	}
 	return fmt.Sprintf("Unknown error code %d", e)
 }
@@ -56,4 +64,44 @@ var (
 	ExceptionSingular error_ = error_(exceptionSingular)
 	// The matrix provided is not positive semi-definite.
 	ExceptionNotSPD error_ = error_(exceptionNotSPD)
+	// An iterative method did not converge within its iteration limit.
+	ExceptionNoConvergence error_ = error_(exceptionNoConvergence)
+	// A parameter is outside of its valid range.
+	ErrorIllegalArgument error_ = error_(errorIllegalArgument)
 )
+
+/*
+Runs f and returns the error it panicked with, if that error is one of the
+values above. This turns the panics raised by Get, Set and the other
+unchecked operations into ordinary errors. Any other panic is passed on.
+*/
+func Try(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(error_)
+			if !ok {
+				panic(r)
+			}
+			err = e
+		}
+	}()
+	f()
+	return
+}
+
+/*
+A malformed input to one of the readers. Line counts from 1, and is 0 when
+the problem is not tied to a line, such as a missing entry at the end.
+*/
+type ParseError struct {
+	Format string
+	Line   int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Format, e.Msg)
+	}
+	return fmt.Sprintf("%s: line %d: %s", e.Format, e.Line, e.Msg)
+}
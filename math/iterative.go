@@ -0,0 +1,535 @@
+package math
+
+import "math"
+
+const (
+	// default relative residual the iterative solvers stop at
+	iterativeTol = 1e-8
+
+	// default Krylov subspace dimension between GMRES restarts
+	gmresRestart = 30
+)
+
+/*
+A linear map given only by its action on vectors, which is all the iterative
+solvers need. Use AsOperator to wrap a matrix.
+*/
+type LinearOperator interface {
+	// The dimensions of the operator as a matrix.
+	Dimension() (rows, cols uint)
+
+	// Overwrites y with Ax. y and x do not share storage.
+	Apply(y, x []float64)
+}
+
+/*
+Wraps a matrix as a LinearOperator. CSR, CSC and dense matrices are used as
+they are; any other matrix is converted to CSR form once, so a SparseMatrix
+is never densified.
+*/
+func AsOperator(A MatrixRO) LinearOperator {
+	switch Am := A.(type) {
+	case *CSRMatrix:
+		return csrOperator{Am}
+	case *CSCMatrix:
+		return cscOperator{Am}
+	case *DenseMatrix:
+		return denseOperator{Am}
+	}
+	return csrOperator{MakeCSRCopy(A)}
+}
+
+type csrOperator struct{ *CSRMatrix }
+
+func (A csrOperator) Apply(y, x []float64) { A.gather(y, x) }
+
+type cscOperator struct{ *CSCMatrix }
+
+func (A cscOperator) Apply(y, x []float64) { A.scatter(y, x) }
+
+type denseOperator struct{ *DenseMatrix }
+
+func (A denseOperator) Apply(y, x []float64) {
+	Gemm(false, false, 1, A.DenseMatrix, MakeDenseMatrix(x, A.cols, 1), 0, MakeDenseMatrix(y, A.rows, 1))
+}
+
+/*
+An approximation M of the system matrix that is cheap to solve with. The
+iterative solvers converge faster on M^-1 A than on A.
+*/
+type Preconditioner interface {
+	// Overwrites z with the solution of Mz = r. z and r do not share
+	// storage.
+	Precondition(z, r []float64)
+}
+
+/*
+Settings of the iterative solvers. The zero value is ready to use.
+*/
+type IterativeOptions struct {
+	// Stop once the residual norm is at most Tol times the norm of b.
+	// Zero selects 1e-8.
+	Tol float64
+
+	// Upper bound on the iterations. Zero selects ten times the dimension.
+	MaxIter int
+
+	// Initial guess, the zero vector if nil.
+	X0 []float64
+
+	// Preconditioner, none if nil. CG and MINRES need a symmetric positive
+	// definite one.
+	Precond Preconditioner
+
+	// Iterations between GMRES restarts. Zero selects 30.
+	Restart int
+}
+
+/*
+The outcome of an iterative solve.
+*/
+type IterativeResult struct {
+	// The approximate solution.
+	X []float64
+
+	// The number of iterations taken.
+	Iterations int
+
+	// The relative residual norm of the initial guess followed by that after
+	// every iteration. These are the norms the solver tracks, which can
+	// drift from those of b-Ax in finite precision.
+	History []float64
+
+	// True if the tolerance was met.
+	Converged bool
+}
+
+/*
+Solves Ax=b with the conjugate gradient method with the default options.
+See IterativeOptions.CG.
+*/
+func CG(A LinearOperator, b []float64) (*IterativeResult, error) {
+	return IterativeOptions{}.CG(A, b)
+}
+
+/*
+Solves Ax=b with the minimal residual method with the default options. See
+IterativeOptions.MINRES.
+*/
+func MINRES(A LinearOperator, b []float64) (*IterativeResult, error) {
+	return IterativeOptions{}.MINRES(A, b)
+}
+
+/*
+Solves Ax=b with the stabilized biconjugate gradient method with the default
+options. See IterativeOptions.BiCGSTAB.
+*/
+func BiCGSTAB(A LinearOperator, b []float64) (*IterativeResult, error) {
+	return IterativeOptions{}.BiCGSTAB(A, b)
+}
+
+/*
+Solves Ax=b with the restarted generalized minimal residual method with the
+default options. See IterativeOptions.GMRES.
+*/
+func GMRES(A LinearOperator, b []float64) (*IterativeResult, error) {
+	return IterativeOptions{}.GMRES(A, b)
+}
+
+// Common setup of the solvers: checks the dimensions, fills in defaults and
+// returns the initial guess, its residual b-Ax and the norm of b.
+func (o *IterativeOptions) start(A LinearOperator, b []float64) (x, r []float64, bnorm float64, err error) {
+	rows, cols := A.Dimension()
+	if rows != cols || uint(len(b)) != rows || (o.X0 != nil && uint(len(o.X0)) != cols) {
+		return nil, nil, 0, ErrorDimensionMismatch
+	}
+	if o.Tol <= 0 {
+		o.Tol = iterativeTol
+	}
+	if o.MaxIter <= 0 {
+		o.MaxIter = 10 * len(b)
+	}
+	if o.Restart <= 0 {
+		o.Restart = gmresRestart
+	}
+
+	x = make([]float64, len(b))
+	r = make([]float64, len(b))
+	copy(r, b)
+	if o.X0 != nil {
+		copy(x, o.X0)
+		A.Apply(r, x)
+		for i := range r {
+			r[i] = b[i] - r[i]
+		}
+	}
+	return x, r, vecNorm(b), nil
+}
+
+// Applies the preconditioner, or copies r if there is none.
+func (o *IterativeOptions) precondition(z, r []float64) {
+	if o.Precond == nil {
+		copy(z, r)
+		return
+	}
+	o.Precond.Precondition(z, r)
+}
+
+// The residual norm relative to that of b, or the plain norm if b is zero.
+func relativeResidual(rnorm, bnorm float64) float64 {
+	if bnorm == 0 {
+		return rnorm
+	}
+	return rnorm / bnorm
+}
+
+// Records a residual norm and tells whether it meets the tolerance.
+func (o *IterativeOptions) record(res *IterativeResult, rnorm, bnorm float64) bool {
+	rel := relativeResidual(rnorm, bnorm)
+	res.History = append(res.History, rel)
+	res.Converged = rel <= o.Tol
+	return res.Converged
+}
+
+// The error to return with a finished result.
+func (res *IterativeResult) err() error {
+	if res.Converged {
+		return nil
+	}
+	return ExceptionNoConvergence
+}
+
+/*
+Solves Ax=b for a symmetric positive definite A with the preconditioned
+conjugate gradient method. Returns ExceptionNotSPD if A turns out not to be
+positive definite, and ExceptionNoConvergence along with the last iterate if
+the tolerance is not met within MaxIter iterations.
+*/
+func (o IterativeOptions) CG(A LinearOperator, b []float64) (*IterativeResult, error) {
+	x, r, bnorm, err := o.start(A, b)
+	if err != nil {
+		return nil, err
+	}
+	res := &IterativeResult{X: x}
+	if o.record(res, vecNorm(r), bnorm) {
+		return res, nil
+	}
+
+	n := len(b)
+	z := make([]float64, n)
+	q := make([]float64, n)
+	o.precondition(z, r)
+	p := append([]float64(nil), z...)
+	rz := vecDot(r, z)
+
+	for res.Iterations < o.MaxIter {
+		res.Iterations++
+		A.Apply(q, p)
+		pq := vecDot(p, q)
+		if pq <= 0 {
+			return res, ExceptionNotSPD
+		}
+		alpha := rz / pq
+		vecAxpy(alpha, p, x)
+		vecAxpy(-alpha, q, r)
+		if o.record(res, vecNorm(r), bnorm) {
+			break
+		}
+
+		o.precondition(z, r)
+		rzNew := vecDot(r, z)
+		beta := rzNew / rz
+		rz = rzNew
+		for i := range p {
+			p[i] = z[i] + beta*p[i]
+		}
+	}
+	return res, res.err()
+}
+
+/*
+Solves Ax=b for a symmetric, possibly indefinite, A with the minimal residual
+method of Paige and Saunders. With a preconditioner M the tracked residual
+norms are in the norm induced by M^-1, relative to the same norm of b.
+Returns ExceptionNotSPD if the preconditioner is not positive definite, and
+ExceptionNoConvergence along with the last iterate if the tolerance is not
+met within MaxIter iterations.
+*/
+func (o IterativeOptions) MINRES(A LinearOperator, b []float64) (*IterativeResult, error) {
+	x, r1, bnorm, err := o.start(A, b)
+	if err != nil {
+		return nil, err
+	}
+	n := len(b)
+	y := make([]float64, n)
+	if o.Precond != nil {
+		o.precondition(y, b)
+		bnorm = math.Sqrt(vecDot(b, y))
+	}
+
+	o.precondition(y, r1)
+	beta1 := vecDot(r1, y)
+	if beta1 < 0 {
+		return nil, ExceptionNotSPD
+	}
+	beta1 = math.Sqrt(beta1)
+	res := &IterativeResult{X: x}
+	if o.record(res, beta1, bnorm) {
+		return res, nil
+	}
+
+	r2 := append([]float64(nil), r1...)
+	v := make([]float64, n)
+	w := make([]float64, n)
+	w1 := make([]float64, n)
+	w2 := make([]float64, n)
+	var oldb, dbar, epsln float64
+	beta, phibar := beta1, beta1
+	cs, sn := float64(-1), float64(0)
+
+	for res.Iterations < o.MaxIter {
+		res.Iterations++
+
+		// Lanczos step
+		for i := range v {
+			v[i] = y[i] / beta
+		}
+		A.Apply(y, v)
+		if res.Iterations >= 2 {
+			vecAxpy(-beta/oldb, r1, y)
+		}
+		alfa := vecDot(v, y)
+		vecAxpy(-alfa/beta, r2, y)
+		r1, r2 = r2, r1
+		copy(r2, y)
+		o.precondition(y, r2)
+		oldb = beta
+		beta = vecDot(r2, y)
+		if beta < 0 {
+			return res, ExceptionNotSPD
+		}
+		beta = math.Sqrt(beta)
+
+		// apply the previous rotation and compute the next one
+		oldeps := epsln
+		delta := cs*dbar + sn*alfa
+		gbar := sn*dbar - cs*alfa
+		epsln = sn * beta
+		dbar = -cs * beta
+		gamma := math.Max(math.Hypot(gbar, beta), eps)
+		cs = gbar / gamma
+		sn = beta / gamma
+		phi := cs * phibar
+		phibar = sn * phibar
+
+		// update the solution
+		w1, w2, w = w2, w, w1
+		for i := range w {
+			w[i] = (v[i] - oldeps*w1[i] - delta*w2[i]) / gamma
+		}
+		vecAxpy(phi, w, x)
+
+		if o.record(res, phibar, bnorm) || beta == 0 {
+			break
+		}
+	}
+	return res, res.err()
+}
+
+/*
+Solves Ax=b for a general A with the right-preconditioned stabilized
+biconjugate gradient method of van der Vorst. Returns
+ExceptionNoConvergence along with the last iterate if the method breaks down
+or the tolerance is not met within MaxIter iterations.
+*/
+func (o IterativeOptions) BiCGSTAB(A LinearOperator, b []float64) (*IterativeResult, error) {
+	x, r, bnorm, err := o.start(A, b)
+	if err != nil {
+		return nil, err
+	}
+	res := &IterativeResult{X: x}
+	if o.record(res, vecNorm(r), bnorm) {
+		return res, nil
+	}
+
+	n := len(b)
+	rhat := append([]float64(nil), r...)
+	p := make([]float64, n)
+	v := make([]float64, n)
+	phat := make([]float64, n)
+	shat := make([]float64, n)
+	t := make([]float64, n)
+	rho, alpha, omega := float64(1), float64(1), float64(1)
+
+	for res.Iterations < o.MaxIter {
+		res.Iterations++
+		rhoNew := vecDot(rhat, r)
+		if rhoNew == 0 {
+			break
+		}
+		beta := (rhoNew / rho) * (alpha / omega)
+		rho = rhoNew
+		for i := range p {
+			p[i] = r[i] + beta*(p[i]-omega*v[i])
+		}
+		o.precondition(phat, p)
+		A.Apply(v, phat)
+		rv := vecDot(rhat, v)
+		if rv == 0 {
+			break
+		}
+		alpha = rho / rv
+
+		// r now holds the intermediate residual s
+		vecAxpy(-alpha, v, r)
+		vecAxpy(alpha, phat, x)
+		if snorm := vecNorm(r); relativeResidual(snorm, bnorm) <= o.Tol {
+			o.record(res, snorm, bnorm)
+			break
+		}
+
+		o.precondition(shat, r)
+		A.Apply(t, shat)
+		tt := vecDot(t, t)
+		if tt == 0 {
+			break
+		}
+		omega = vecDot(t, r) / tt
+		vecAxpy(omega, shat, x)
+		vecAxpy(-omega, t, r)
+		if o.record(res, vecNorm(r), bnorm) || omega == 0 {
+			break
+		}
+	}
+	return res, res.err()
+}
+
+/*
+Solves Ax=b for a general A with the right-preconditioned GMRES method,
+restarted every Restart iterations. The Arnoldi basis is orthogonalized by
+modified Gram-Schmidt and the least squares problem solved by Givens
+rotations, so the tracked residual norms are those of b-Ax. Returns
+ExceptionNoConvergence along with the last iterate if the tolerance is not
+met within MaxIter iterations.
+*/
+func (o IterativeOptions) GMRES(A LinearOperator, b []float64) (*IterativeResult, error) {
+	x, r, bnorm, err := o.start(A, b)
+	if err != nil {
+		return nil, err
+	}
+	res := &IterativeResult{X: x}
+	beta := vecNorm(r)
+	if o.record(res, beta, bnorm) {
+		return res, nil
+	}
+
+	n, m := len(b), o.Restart
+	V := make([][]float64, m+1)
+	Z := make([][]float64, m)
+	for j := range V {
+		V[j] = make([]float64, n)
+	}
+	for j := range Z {
+		Z[j] = make([]float64, n)
+	}
+	H := make([][]float64, m+1)
+	for j := range H {
+		H[j] = make([]float64, m)
+	}
+	cs := make([]float64, m)
+	sn := make([]float64, m)
+	g := make([]float64, m+1)
+
+	for res.Iterations < o.MaxIter {
+		for i := range V[0] {
+			V[0][i] = r[i] / beta
+		}
+		for i := range g {
+			g[i] = 0
+		}
+		g[0] = beta
+
+		k := 0
+		for k < m && res.Iterations < o.MaxIter {
+			res.Iterations++
+			w := V[k+1]
+			o.precondition(Z[k], V[k])
+			A.Apply(w, Z[k])
+			for i := 0; i <= k; i++ {
+				H[i][k] = vecDot(w, V[i])
+				vecAxpy(-H[i][k], V[i], w)
+			}
+			hnext := vecNorm(w)
+			H[k+1][k] = hnext
+			if hnext != 0 {
+				for i := range w {
+					w[i] /= hnext
+				}
+			}
+
+			for i := 0; i < k; i++ {
+				H[i][k], H[i+1][k] = cs[i]*H[i][k]+sn[i]*H[i+1][k], -sn[i]*H[i][k]+cs[i]*H[i+1][k]
+			}
+			d := math.Hypot(H[k][k], H[k+1][k])
+			if d == 0 {
+				break
+			}
+			cs[k], sn[k] = H[k][k]/d, H[k+1][k]/d
+			H[k][k], H[k+1][k] = d, 0
+			g[k], g[k+1] = cs[k]*g[k], -sn[k]*g[k]
+			k++
+
+			// a zero subdiagonal means the solution lies in the basis
+			if o.record(res, math.Abs(g[k]), bnorm) || hnext == 0 {
+				break
+			}
+		}
+		if k == 0 {
+			break
+		}
+
+		// x += Z y with H y = g
+		yk := make([]float64, k)
+		for i := k - 1; i >= 0; i-- {
+			s := g[i]
+			for j := i + 1; j < k; j++ {
+				s -= H[i][j] * yk[j]
+			}
+			yk[i] = s / H[i][i]
+		}
+		for j := 0; j < k; j++ {
+			vecAxpy(yk[j], Z[j], x)
+		}
+		if res.Converged {
+			break
+		}
+
+		// restart from the true residual
+		A.Apply(r, x)
+		for i := range r {
+			r[i] = b[i] - r[i]
+		}
+		beta = vecNorm(r)
+		if beta == 0 {
+			res.Converged = true
+			break
+		}
+	}
+	return res, res.err()
+}
+
+func vecDot(x, y []float64) (s float64) {
+	for i, v := range x {
+		s += v * y[i]
+	}
+	return
+}
+
+func vecNorm(x []float64) float64 { return math.Sqrt(vecDot(x, x)) }
+
+// y += alpha*x
+func vecAxpy(alpha float64, x, y []float64) {
+	for i, v := range x {
+		y[i] += alpha * v
+	}
+}
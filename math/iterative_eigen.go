@@ -0,0 +1,432 @@
+package math
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	// default relative residual of the Ritz pairs the eigensolvers stop at
+	eigsTol = 1e-10
+
+	// default bound on the restarts of the eigensolvers
+	eigsMaxRestarts = 300
+
+	// default minimum Krylov subspace dimension of the eigensolvers
+	eigsMinNCV = 20
+)
+
+/*
+The part of the spectrum an iterative eigensolver looks for. Largest and
+smallest compare the real parts, the magnitude variants the absolute values.
+Eigenvalues at the ends of the spectrum converge fastest; smallest magnitude
+ones inside it may need many restarts.
+*/
+type EigenWhich int
+
+const (
+	EigenLargest EigenWhich = iota
+	EigenSmallest
+	EigenLargestMagnitude
+	EigenSmallestMagnitude
+)
+
+/*
+Settings of the iterative eigensolvers. The zero value is ready to use and
+looks for the largest eigenvalues.
+*/
+type EigenOptions struct {
+	// Which eigenvalues to compute.
+	Which EigenWhich
+
+	// Dimension of the Krylov subspace, at least k+2 and at most the
+	// dimension of the operator. Zero selects max(2k+1, 20).
+	NCV int
+
+	// Stop once every wanted Ritz pair has a residual of at most Tol times
+	// its eigenvalue. Zero selects 1e-10.
+	Tol float64
+
+	// Upper bound on the restarts. Zero selects 300.
+	MaxRestarts int
+
+	// Starting vector, a fixed pseudo-random one if nil.
+	V0 []float64
+}
+
+/*
+Computes k eigenvalues of the symmetric operator A and their eigenvectors
+with the default options for the given part of the spectrum. See
+EigenOptions.Lanczos.
+*/
+func Lanczos(A LinearOperator, k uint, which EigenWhich) (*EigenSym, error) {
+	return EigenOptions{Which: which}.Lanczos(A, k)
+}
+
+/*
+Computes k eigenvalues of the operator A and their eigenvectors with the
+default options for the given part of the spectrum. See
+EigenOptions.Arnoldi.
+*/
+func Arnoldi(A LinearOperator, k uint, which EigenWhich) (*Eigen, error) {
+	return EigenOptions{Which: which}.Arnoldi(A, k)
+}
+
+/*
+Computes the k largest eigenvalues of the symmetric matrix A and their
+eigenvectors with the Lanczos method, without densifying A.
+*/
+func (A *SparseMatrix) EigenSymTopK(k uint) (*EigenSym, error) {
+	return Lanczos(AsOperator(A), k, EigenLargest)
+}
+
+/*
+Computes k eigenvalues of the symmetric operator A and their eigenvectors by
+the restarted Lanczos method, using only products with A. The Lanczos
+vectors are kept orthogonal by full reorthogonalization. Restarts keep the
+best Ritz vectors, which is equivalent to implicit restarting with exact
+shifts. The result has its eigenvalues in decreasing order, like EigenSym.
+Like any single vector Krylov method it may return only one copy of a
+multiple eigenvalue. Returns ExceptionNoConvergence if the restarts run out.
+*/
+func (o EigenOptions) Lanczos(A LinearOperator, k uint) (*EigenSym, error) {
+	units, V, err := o.krylovSchur(A, k, true)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(units, func(s, t int) bool { return units[s].re > units[t].re })
+	n := uint(len(V[0]))
+	E := &EigenSym{values: make([]float64, len(units)), vectors: Zeros(n, uint(len(units)))}
+	for c, u := range units {
+		E.values[c] = u.re
+		E.vectors.FillCol(uint(c), ritzVector(V, u.yr))
+	}
+	return E, nil
+}
+
+/*
+Computes k eigenvalues of the operator A and their eigenvectors by the
+restarted Arnoldi method, using only products with A. Restarts keep the
+invariant subspace of the best Ritz vectors, in the manner of the
+Krylov-Schur method. Complex eigenvalues come in conjugate pairs as in
+Eigen, so one more than k are returned when the k-th would be split from its
+conjugate. Eigenvalues are ordered from the best match of which down.
+Returns ExceptionNoConvergence if the restarts run out.
+*/
+func (o EigenOptions) Arnoldi(A LinearOperator, k uint) (*Eigen, error) {
+	units, V, err := o.krylovSchur(A, k, false)
+	if err != nil {
+		return nil, err
+	}
+	n := uint(len(V[0]))
+	kk := uint(0)
+	for _, u := range units {
+		kk += u.size()
+	}
+	E := &Eigen{wr: make([]float64, kk), wi: make([]float64, kk), v: Zeros(n, kk)}
+	c := uint(0)
+	for _, u := range units {
+		E.wr[c] = u.re
+		E.wi[c] = u.im
+		E.v.FillCol(c, ritzVector(V, u.yr))
+		if u.yi != nil {
+			E.wr[c+1] = u.re
+			E.wi[c+1] = -u.im
+			E.v.FillCol(c+1, ritzVector(V, u.yi))
+		}
+		c += u.size()
+	}
+	return E, nil
+}
+
+/*
+A Ritz value of the projected matrix with its vector, split in real and
+imaginary parts. A complex conjugate pair is one unit with im > 0.
+*/
+type ritzUnit struct {
+	re, im float64
+	yr, yi []float64
+}
+
+func (u *ritzUnit) size() uint {
+	if u.yi != nil {
+		return 2
+	}
+	return 1
+}
+
+// The vector with coordinates y in the basis V.
+func ritzVector(V [][]float64, y []float64) []float64 {
+	x := make([]float64, len(V[0]))
+	for i, yi := range y {
+		vecAxpy(yi, V[i], x)
+	}
+	return x
+}
+
+/*
+The Krylov-Schur iteration shared by Lanczos and Arnoldi. It maintains
+A V[:m] = V[:m] H[:m] + V[m] H[m], where V has orthonormal columns and the
+last row of H holds the residual coefficients. Each cycle extends the basis
+to m vectors, computes the Ritz pairs of the m by m projection, and restarts
+from the invariant subspace of the best ones. Returns the wanted Ritz units
+and the basis their vectors refer to.
+*/
+func (o EigenOptions) krylovSchur(A LinearOperator, k uint, symmetric bool) ([]ritzUnit, [][]float64, error) {
+	rows, cols := A.Dimension()
+	if rows != cols || (o.V0 != nil && uint(len(o.V0)) != rows) {
+		return nil, nil, ErrorDimensionMismatch
+	}
+	n := rows
+	if k > n {
+		return nil, nil, ErrorIllegalIndex
+	}
+	if o.Which < EigenLargest || o.Which > EigenSmallestMagnitude {
+		return nil, nil, ErrorIllegalArgument
+	}
+	if k == 0 {
+		return nil, [][]float64{make([]float64, n)}, nil
+	}
+	if o.Tol <= 0 {
+		o.Tol = eigsTol
+	}
+	if o.MaxRestarts <= 0 {
+		o.MaxRestarts = eigsMaxRestarts
+	}
+	m := uint(o.NCV)
+	if o.NCV <= 0 {
+		m = maxUInt(2*k+1, eigsMinNCV)
+	}
+	m = minUInt(m, n)
+	if m < k+2 && m < n {
+		return nil, nil, ErrorIllegalArgument
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	V := make([][]float64, m+1)
+	for i := range V {
+		V[i] = make([]float64, n)
+	}
+	H := make([][]float64, m+1)
+	for i := range H {
+		H[i] = make([]float64, m)
+	}
+	h := make([]float64, m+1)
+
+	// Orthogonalizes w against V[:j] twice, adding the coefficients to h,
+	// and returns the norm left.
+	orthogonalize := func(w []float64, j uint) float64 {
+		for pass := 0; pass < 2; pass++ {
+			for i := uint(0); i < j; i++ {
+				c := vecDot(V[i], w)
+				h[i] += c
+				vecAxpy(-c, V[i], w)
+			}
+		}
+		return vecNorm(w)
+	}
+	// Fills V[j] with a random unit vector orthogonal to V[:j]; zero if
+	// there is none.
+	randomVector := func(j uint) {
+		for try := 0; try < 3; try++ {
+			for r := range V[j] {
+				V[j][r] = 2*rng.Float64() - 1
+			}
+			before := vecNorm(V[j])
+			if beta := orthogonalize(V[j], j); beta > 1e-8*before {
+				for r := range V[j] {
+					V[j][r] /= beta
+				}
+				return
+			}
+		}
+		for r := range V[j] {
+			V[j][r] = 0
+		}
+	}
+
+	l := uint(0)
+	if o.V0 != nil && vecNorm(o.V0) > 0 {
+		copy(V[0], o.V0)
+		nrm := vecNorm(V[0])
+		for r := range V[0] {
+			V[0][r] /= nrm
+		}
+	} else {
+		randomVector(0)
+	}
+
+	eps23 := math.Pow(eps, 2.0/3.0)
+	for restart := 0; ; restart++ {
+		// extend the Krylov decomposition to m vectors
+		for j := l; j < m; j++ {
+			w := V[j+1]
+			A.Apply(w, V[j])
+			before := vecNorm(w)
+			for i := range h {
+				h[i] = 0
+			}
+			beta := orthogonalize(w, j+1)
+			for i := uint(0); i <= j; i++ {
+				H[i][j] = h[i]
+			}
+			if beta > 10*eps*before && beta > 0 {
+				H[j+1][j] = beta
+				for r := range w {
+					w[r] /= beta
+				}
+			} else {
+				// an invariant subspace: carry on with a new direction
+				H[j+1][j] = 0
+				if j+1 < n {
+					randomVector(j + 1)
+				} else {
+					for r := range w {
+						w[r] = 0
+					}
+				}
+			}
+		}
+
+		units, err := ritzUnits(H, m, symmetric)
+		if err != nil {
+			return nil, nil, err
+		}
+		sortRitzUnits(units, o.Which)
+
+		// the residual of Ritz vector V[:m]y is |H[m]y|
+		b := H[m]
+		wanted, kk, converged := 0, uint(0), true
+		for kk < k {
+			u := &units[wanted]
+			res := math.Abs(vecDot(b, u.yr))
+			nrm := vecNorm(u.yr)
+			if u.yi != nil {
+				res = math.Hypot(res, vecDot(b, u.yi))
+				nrm = math.Hypot(nrm, vecNorm(u.yi))
+			}
+			if res > o.Tol*math.Max(math.Hypot(u.re, u.im), eps23)*nrm {
+				converged = false
+			}
+			kk += u.size()
+			wanted++
+		}
+		if converged {
+			return units[:wanted], V[:m], nil
+		}
+		if restart >= o.MaxRestarts {
+			return nil, nil, ExceptionNoConvergence
+		}
+
+		// keep about half of the unwanted vectors, never splitting a pair
+		p := kk + (m-kk)/2
+		var Q [][]float64
+		for _, u := range units {
+			if uint(len(Q))+u.size() > p || uint(len(Q))+u.size() >= m {
+				break
+			}
+			Q = append(Q, append([]float64(nil), u.yr...))
+			if u.yi != nil {
+				Q = append(Q, append([]float64(nil), u.yi...))
+			}
+		}
+		l = uint(len(Q))
+		if l == 0 {
+			return nil, nil, ExceptionNoConvergence
+		}
+		// an orthonormal basis of the kept invariant subspace of H
+		for c := range Q {
+			for pass := 0; pass < 2; pass++ {
+				for d := 0; d < c; d++ {
+					vecAxpy(-vecDot(Q[d], Q[c]), Q[d], Q[c])
+				}
+			}
+			nrm := vecNorm(Q[c])
+			for i := range Q[c] {
+				Q[c][i] /= nrm
+			}
+		}
+
+		// V[:l] = V[:m]Q, H[:l] = Q'H[:m]Q and H[l] = H[m]Q
+		HQ := make([][]float64, l)
+		newV := make([][]float64, l)
+		for c := range Q {
+			HQ[c] = make([]float64, m)
+			for i := uint(0); i < m; i++ {
+				HQ[c][i] = vecDot(H[i], Q[c])
+			}
+			newV[c] = ritzVector(V[:m], Q[c])
+		}
+		bQ := make([]float64, l)
+		for c := range Q {
+			bQ[c] = vecDot(b, Q[c])
+		}
+		for i := range H {
+			for j := range H[i] {
+				H[i][j] = 0
+			}
+		}
+		for r := uint(0); r < l; r++ {
+			for c := uint(0); c < l; c++ {
+				H[r][c] = vecDot(Q[r], HQ[c])
+			}
+			H[l][r] = bQ[r]
+		}
+		V[l], V[m] = V[m], V[l]
+		for c := uint(0); c < l; c++ {
+			copy(V[c], newV[c])
+		}
+	}
+}
+
+// The Ritz units of the leading m by m block of H.
+func ritzUnits(H [][]float64, m uint, symmetric bool) ([]ritzUnit, error) {
+	Hm := Zeros(m, m)
+	var i, j uint
+	for i = 0; i < m; i++ {
+		for j = 0; j < m; j++ {
+			Hm.elements[i*Hm.step+j] = H[i][j]
+		}
+	}
+	var units []ritzUnit
+	if symmetric {
+		E, err := Hm.EigenSym()
+		if err != nil {
+			return nil, err
+		}
+		for c, v := range E.values {
+			units = append(units, ritzUnit{re: v, yr: E.vectors.ColCopy(uint(c))})
+		}
+		return units, nil
+	}
+	E, err := Hm.Eigen()
+	if err != nil {
+		return nil, err
+	}
+	for c := uint(0); c < m; c++ {
+		switch {
+		case E.wi[c] > 0:
+			units = append(units, ritzUnit{re: E.wr[c], im: E.wi[c], yr: E.v.ColCopy(c), yi: E.v.ColCopy(c + 1)})
+		case E.wi[c] == 0:
+			units = append(units, ritzUnit{re: E.wr[c], yr: E.v.ColCopy(c)})
+		}
+	}
+	return units, nil
+}
+
+// Sorts the Ritz units from the best match of which down.
+func sortRitzUnits(units []ritzUnit, which EigenWhich) {
+	key := func(u *ritzUnit) float64 {
+		switch which {
+		case EigenSmallest:
+			return u.re
+		case EigenLargestMagnitude:
+			return -math.Hypot(u.re, u.im)
+		case EigenSmallestMagnitude:
+			return math.Hypot(u.re, u.im)
+		}
+		return -u.re
+	}
+	sort.SliceStable(units, func(s, t int) bool { return key(&units[s]) < key(&units[t]) })
+}
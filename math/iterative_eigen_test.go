@@ -0,0 +1,109 @@
+package math
+
+import (
+	"math"
+	"math/cmplx"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestLanczos(t *testing.T) {
+	// the Laplacian plus a diagonal that splits its multiple eigenvalues
+	A := laplacian2D(20, 0)
+	for i := uint(0); i < A.Rows(); i++ {
+		A.Set(i, i, A.Get(i, i)+0.1*math.Sin(float64(i)))
+	}
+	D, err := A.DenseMatrix().EigenSym()
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := D.Values()
+	n := len(all)
+
+	for _, which := range []EigenWhich{EigenLargest, EigenSmallest} {
+		E, err := Lanczos(AsOperator(A), 4, which)
+		if err != nil {
+			t.Fatalf("which %d: %v", which, err)
+		}
+		want := all[:4]
+		if which == EigenSmallest {
+			want = all[n-4:]
+		}
+		V := E.Vectors()
+		for c, v := range E.Values() {
+			if math.Abs(v-want[c]) > 1e-8 {
+				t.Errorf("which %d: eigenvalue %d is %v, expected %v", which, c, v, want[c])
+			}
+			x := V.ColCopy(uint(c))
+			y, _ := A.MulVec(x)
+			vecAxpy(-v, x, y)
+			if vecNorm(y) > 1e-7 {
+				t.Errorf("which %d: residual %g", which, vecNorm(y))
+			}
+		}
+	}
+
+	E, err := A.SparseMatrix().EigenSymTopK(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for c, v := range E.Values() {
+		if math.Abs(v-all[c]) > 1e-8 {
+			t.Errorf("EigenSymTopK: %v, expected %v", E.Values(), all[:3])
+		}
+	}
+	if _, err := A.SparseMatrix().EigenSymTopK(uint(n + 1)); err != ErrorIllegalIndex {
+		t.Errorf("expected %v, got %v", ErrorIllegalIndex, err)
+	}
+}
+
+func TestArnoldi(t *testing.T) {
+	// a random sparse matrix, whose largest eigenvalues include complex pairs
+	rng := rand.New(rand.NewSource(7))
+	n := uint(150)
+	S := ZerosSparse(n, n)
+	for p := 0; p < 6*int(n); p++ {
+		S.Set(uint(rng.Intn(int(n))), uint(rng.Intn(int(n))), rng.NormFloat64())
+	}
+	D, err := S.DenseMatrix().Eigen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	all := D.Values()
+	sort.Slice(all, func(s, t int) bool { return cmplx.Abs(all[s]) > cmplx.Abs(all[t]) })
+
+	E, err := Arnoldi(AsOperator(S), 6, EigenLargestMagnitude)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals := E.Values()
+	if len(vals) < 6 || len(vals) > 7 {
+		t.Fatalf("%d eigenvalues", len(vals))
+	}
+	for c, v := range vals {
+		found := false
+		for _, w := range all[:len(vals)] {
+			found = found || cmplx.Abs(v-w) < 1e-8
+		}
+		if !found {
+			t.Errorf("eigenvalue %d is %v, expected one of %v", c, v, all[:len(vals)])
+		}
+	}
+
+	A := S.DenseMatrix()
+	for c, x := range E.Vectors() {
+		var r float64
+		var i, j uint
+		for i = 0; i < n; i++ {
+			var s complex128
+			for j = 0; j < n; j++ {
+				s += complex(A.Get(i, j), 0) * x[j]
+			}
+			r = math.Hypot(r, cmplx.Abs(s-vals[c]*x[i]))
+		}
+		if r > 1e-7 {
+			t.Errorf("eigenpair %d: residual %g", c, r)
+		}
+	}
+}
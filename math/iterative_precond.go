@@ -0,0 +1,175 @@
+package math
+
+/*
+The Jacobi preconditioner M = diag(A).
+*/
+type Jacobi struct {
+	inv []float64
+}
+
+/*
+Makes the Jacobi preconditioner of the square matrix A. Returns
+ExceptionSingular if the diagonal has a zero.
+*/
+func NewJacobi(A MatrixRO) (*Jacobi, error) {
+	if A.Rows() != A.Cols() {
+		return nil, ErrorDimensionMismatch
+	}
+	P := &Jacobi{inv: make([]float64, A.Rows())}
+	for i := range P.inv {
+		d := A.Get(uint(i), uint(i))
+		if d == 0 {
+			return nil, ExceptionSingular
+		}
+		P.inv[i] = 1 / d
+	}
+	return P, nil
+}
+
+func (P *Jacobi) Precondition(z, r []float64) {
+	for i, v := range r {
+		z[i] = v * P.inv[i]
+	}
+}
+
+/*
+The symmetric successive over-relaxation preconditioner
+M = (D+wL) D^-1 (D+wU) / (w(2-w)), where A = L+D+U is split into its strict
+lower triangle, diagonal and strict upper triangle. For a symmetric positive
+definite A and 0 < w < 2, M is symmetric positive definite too.
+*/
+type SSOR struct {
+	a     *CSRMatrix
+	diag  []uint
+	omega float64
+}
+
+/*
+Makes the SSOR preconditioner of the square matrix A with relaxation factor
+omega. Returns ErrorIllegalArgument unless omega lies in (0, 2), and
+ExceptionSingular if the diagonal has a zero.
+*/
+func NewSSOR(A MatrixRO, omega float64) (*SSOR, error) {
+	if A.Rows() != A.Cols() {
+		return nil, ErrorDimensionMismatch
+	}
+	if !(omega > 0 && omega < 2) {
+		return nil, ErrorIllegalArgument
+	}
+	R, ok := A.(*CSRMatrix)
+	if !ok {
+		R = MakeCSRCopy(A)
+	}
+	diag, err := csrDiagonal(R)
+	if err != nil {
+		return nil, err
+	}
+	return &SSOR{a: R, diag: diag, omega: omega}, nil
+}
+
+func (P *SSOR) Precondition(z, r []float64) {
+	A, w := P.a, P.omega
+
+	// (D+wL) u = w(2-w) r
+	for i := range z {
+		s := w * (2 - w) * r[i]
+		for p := A.indptr[i]; p < P.diag[i]; p++ {
+			s -= w * A.values[p] * z[A.indices[p]]
+		}
+		z[i] = s / A.values[P.diag[i]]
+	}
+	// (D+wU) z = Du
+	for i := len(z) - 1; i >= 0; i-- {
+		d := A.values[P.diag[i]]
+		s := d * z[i]
+		for p := P.diag[i] + 1; p < A.indptr[i+1]; p++ {
+			s -= w * A.values[p] * z[A.indices[p]]
+		}
+		z[i] = s / d
+	}
+}
+
+/*
+The incomplete LU factorization without fill-in, LU ~ A where L and U keep
+the sparsity pattern of A.
+*/
+type ILU0 struct {
+	// unit lower triangle L and upper triangle U in the pattern of A
+	lu   *CSRMatrix
+	diag []uint
+}
+
+/*
+Computes the ILU(0) factorization of the square matrix A. Returns
+ExceptionSingular if a pivot is zero or missing from the pattern.
+*/
+func NewILU0(A MatrixRO) (*ILU0, error) {
+	if A.Rows() != A.Cols() {
+		return nil, ErrorDimensionMismatch
+	}
+	F := MakeCSRCopy(A)
+	diag, err := csrDiagonal(F)
+	if err != nil {
+		return nil, err
+	}
+
+	// pos[j] is one past the position of column j in the current row, zero
+	// if it is not in the pattern
+	n := F.rows
+	pos := make([]uint, n)
+	for i := uint(0); i < n; i++ {
+		lo, hi := F.indptr[i], F.indptr[i+1]
+		for p := lo; p < hi; p++ {
+			pos[F.indices[p]] = p + 1
+		}
+		for p := lo; p < diag[i]; p++ {
+			k := F.indices[p]
+			F.values[p] /= F.values[diag[k]]
+			lik := F.values[p]
+			for q := diag[k] + 1; q < F.indptr[k+1]; q++ {
+				if t := pos[F.indices[q]]; t != 0 {
+					F.values[t-1] -= lik * F.values[q]
+				}
+			}
+		}
+		if F.values[diag[i]] == 0 {
+			return nil, ExceptionSingular
+		}
+		for p := lo; p < hi; p++ {
+			pos[F.indices[p]] = 0
+		}
+	}
+	return &ILU0{lu: F, diag: diag}, nil
+}
+
+func (P *ILU0) Precondition(z, r []float64) {
+	F := P.lu
+	for i := range z {
+		s := r[i]
+		for p := F.indptr[i]; p < P.diag[i]; p++ {
+			s -= F.values[p] * z[F.indices[p]]
+		}
+		z[i] = s
+	}
+	for i := len(z) - 1; i >= 0; i-- {
+		s := z[i]
+		for p := P.diag[i] + 1; p < F.indptr[i+1]; p++ {
+			s -= F.values[p] * z[F.indices[p]]
+		}
+		z[i] = s / F.values[P.diag[i]]
+	}
+}
+
+// The positions of the diagonal entries of the rows of A. Returns
+// ExceptionSingular if one is missing or zero.
+func csrDiagonal(A *CSRMatrix) ([]uint, error) {
+	diag := make([]uint, A.rows)
+	for i := range diag {
+		p, ok := A.find(uint(i), uint(i))
+		if !ok || A.values[p] == 0 {
+			return nil, ExceptionSingular
+		}
+		diag[i] = p
+	}
+	return diag, nil
+}
@@ -0,0 +1,130 @@
+package math
+
+import (
+	"math"
+	"testing"
+)
+
+// The five point Laplacian on a k by k grid, plus c times a centered first
+// difference in x, which makes it nonsymmetric.
+func laplacian2D(k uint, c float64) *CSRMatrix {
+	n := k * k
+	S := ZerosSparse(n, n)
+	for i := uint(0); i < k; i++ {
+		for j := uint(0); j < k; j++ {
+			p := i*k + j
+			S.Set(p, p, 4)
+			if i > 0 {
+				S.Set(p, p-k, -1)
+			}
+			if i+1 < k {
+				S.Set(p, p+k, -1)
+			}
+			if j > 0 {
+				S.Set(p, p-1, -1-c)
+			}
+			if j+1 < k {
+				S.Set(p, p+1, -1+c)
+			}
+		}
+	}
+	return S.CSR()
+}
+
+func checkSolution(t *testing.T, name string, A *CSRMatrix, b []float64, res *IterativeResult, err error) {
+	if err != nil {
+		t.Errorf("%s: %v after %d iterations", name, err, res.Iterations)
+		return
+	}
+	r, _ := A.MulVec(res.X)
+	for i := range r {
+		r[i] = b[i] - r[i]
+	}
+	if rel := vecNorm(r) / vecNorm(b); rel > 1e-7 {
+		t.Errorf("%s: relative residual %g", name, rel)
+	}
+	if len(res.History) != res.Iterations+1 || res.History[len(res.History)-1] > 1e-8 {
+		t.Errorf("%s: history %v", name, res.History)
+	}
+}
+
+func TestKrylovSymmetric(t *testing.T) {
+	A := laplacian2D(20, 0)
+	b := make([]float64, A.rows)
+	for i := range b {
+		b[i] = math.Sin(float64(i))
+	}
+	op := AsOperator(A)
+
+	res, err := CG(op, b)
+	checkSolution(t, "CG", A, b, res, err)
+	plain := res.Iterations
+
+	jacobi, _ := NewJacobi(A)
+	ssor, _ := NewSSOR(A, 1.5)
+	ilu, _ := NewILU0(A)
+	for _, P := range []Preconditioner{jacobi, ssor, ilu} {
+		o := IterativeOptions{Precond: P}
+		res, err := o.CG(op, b)
+		checkSolution(t, "PCG", A, b, res, err)
+		if _, ok := P.(*Jacobi); !ok && res.Iterations >= plain {
+			t.Errorf("%T did not speed up CG: %d iterations", P, res.Iterations)
+		}
+		res, err = o.MINRES(op, b)
+		if err != nil {
+			t.Errorf("PMINRES %T: %v", P, err)
+		}
+	}
+
+	res, err = MINRES(op, b)
+	checkSolution(t, "MINRES", A, b, res, err)
+
+	// shifted to be indefinite, where CG breaks down
+	S := A.Copy()
+	S.Subtract(Scaled(EyeSparse(A.rows), 1.7).CSR())
+	if _, err := CG(AsOperator(S), b); err == nil {
+		t.Error("CG converged on an indefinite matrix")
+	}
+	res, err = MINRES(AsOperator(S), b)
+	checkSolution(t, "MINRES indefinite", S, b, res, err)
+}
+
+func TestKrylovNonsymmetric(t *testing.T) {
+	A := laplacian2D(20, 0.4)
+	b := make([]float64, A.rows)
+	for i := range b {
+		b[i] = 1
+	}
+	op := AsOperator(A.CSC())
+
+	res, err := BiCGSTAB(op, b)
+	checkSolution(t, "BiCGSTAB", A, b, res, err)
+	res, err = GMRES(op, b)
+	checkSolution(t, "GMRES", A, b, res, err)
+
+	ilu, _ := NewILU0(A)
+	o := IterativeOptions{Precond: ilu, Restart: 10, X0: b}
+	res, err = o.GMRES(op, b)
+	checkSolution(t, "GMRES(10) ILU", A, b, res, err)
+	res, err = o.BiCGSTAB(op, b)
+	if err != nil {
+		t.Errorf("BiCGSTAB ILU: %v", err)
+	}
+
+	o = IterativeOptions{MaxIter: 3}
+	if res, err := o.GMRES(op, b); err != ExceptionNoConvergence || res.Iterations != 3 {
+		t.Errorf("GMRES with 3 iterations: %v", err)
+	}
+
+	// rhat is orthogonal to Ap in the first step
+	swap := AsOperator(MakeDenseMatrix([]float64{0, 1, 1, 0}, 2, 2))
+	res, err = BiCGSTAB(swap, []float64{1, 0})
+	if err != ExceptionNoConvergence || res.X[0] != 0 || res.X[1] != 0 {
+		t.Errorf("BiCGSTAB breakdown: x=%v %v", res.X, err)
+	}
+
+	// a dense operator gives the same answer
+	D := A.DenseMatrix()
+	res, err = GMRES(AsOperator(D), b)
+	checkSolution(t, "dense GMRES", A, b, res, err)
+}
@@ -0,0 +1,616 @@
+package math
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+/*
+Binary and JSON encodings of the matrix and vector types. encoding/gob uses
+the binary one, so the types can also be sent as gob values.
+
+The binary layout starts with a version byte and a kind byte, followed by
+the dimensions as uvarints. Dense matrices then list their elements row after
+row as little endian IEEE 754 numbers, float32 for DenseMatrix32. Sparse
+matrices, in any of their three forms, give the number of stored elements,
+their row-major positions i*cols+j as increasing uvarint deltas, and their
+values. Pivot matrices give the sign as one byte and the pivots as uvarints.
+Dense vectors give their length and elements, sparse ones their stored
+elements as sparse matrices do.
+
+JSON has no NaN or infinities, so matrices holding them cannot be marshaled
+to JSON.
+*/
+
+const marshalVersion = 1
+
+// The kinds of the binary layout.
+const (
+	marshalDense byte = 1 + iota
+	marshalDense32
+	marshalSparse
+	marshalPivot
+	marshalVector
+	marshalSparseVector
+)
+
+// Appends the binary layout to a byte slice.
+type binWriter struct {
+	b   []byte
+	tmp [binary.MaxVarintLen64]byte
+}
+
+func newBinWriter(kind byte) *binWriter {
+	return &binWriter{b: []byte{marshalVersion, kind}}
+}
+
+func (w *binWriter) uvarint(x uint64) {
+	n := binary.PutUvarint(w.tmp[:], x)
+	w.b = append(w.b, w.tmp[:n]...)
+}
+
+func (w *binWriter) putFloat64(v float64) {
+	binary.LittleEndian.PutUint64(w.tmp[:8], math.Float64bits(v))
+	w.b = append(w.b, w.tmp[:8]...)
+}
+
+func (w *binWriter) putFloat32(v float32) {
+	binary.LittleEndian.PutUint32(w.tmp[:4], math.Float32bits(v))
+	w.b = append(w.b, w.tmp[:4]...)
+}
+
+// Reads the binary layout. The first error sticks, and later reads return
+// zeros.
+type binReader struct {
+	b   []byte
+	err error
+}
+
+func (r *binReader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = &ParseError{Format: "binary", Msg: fmt.Sprintf(format, args...)}
+	}
+}
+
+// Checks the version and the kind.
+func newBinReader(data []byte, kinds ...byte) (*binReader, byte) {
+	r := &binReader{b: data}
+	if len(data) < 2 {
+		r.fail("truncated")
+		return r, 0
+	}
+	if data[0] != marshalVersion {
+		r.fail("unknown version %d", data[0])
+		return r, 0
+	}
+	r.b = data[2:]
+	for _, k := range kinds {
+		if data[1] == k {
+			return r, k
+		}
+	}
+	r.fail("unexpected kind %d", data[1])
+	return r, 0
+}
+
+func (r *binReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	x, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		r.fail("truncated")
+		return 0
+	}
+	r.b = r.b[n:]
+	return x
+}
+
+// A dimension, below 2^32 so that products of two do not overflow
+func (r *binReader) dim() uint {
+	d := r.uvarint()
+	if d >= 1<<32 {
+		r.fail("dimension %d too large", d)
+		return 0
+	}
+	return uint(d)
+}
+
+// A count of items of size bytes each, checked against the data left.
+func (r *binReader) count(n uint64, size int) int {
+	if r.err == nil && n > uint64(len(r.b)/size) {
+		r.fail("truncated")
+	}
+	if r.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+func (r *binReader) float64s(n uint64) []float64 {
+	vs := make([]float64, r.count(n, 8))
+	for k := range vs {
+		vs[k] = math.Float64frombits(binary.LittleEndian.Uint64(r.b[8*k:]))
+	}
+	r.b = r.b[8*len(vs):]
+	return vs
+}
+
+func (r *binReader) float32s(n uint64) []float32 {
+	vs := make([]float32, r.count(n, 4))
+	for k := range vs {
+		vs[k] = math.Float32frombits(binary.LittleEndian.Uint32(r.b[4*k:]))
+	}
+	r.b = r.b[4*len(vs):]
+	return vs
+}
+
+// Increasing positions below limit, as written by binWriter.positions
+func (r *binReader) positions(n int, limit uint64) []uint64 {
+	ps := make([]uint64, n)
+	var p uint64
+	for k := range ps {
+		d := r.uvarint()
+		if k > 0 && d == 0 {
+			r.fail("positions not increasing")
+		}
+		if p += d; p >= limit || p < d {
+			r.fail("position out of range")
+		}
+		ps[k] = p
+	}
+	return ps
+}
+
+func (w *binWriter) positions(ps []uint64) {
+	var last uint64
+	for _, p := range ps {
+		w.uvarint(p - last)
+		last = p
+	}
+}
+
+// Fails unless all the data has been read.
+func (r *binReader) done() error {
+	if r.err == nil && len(r.b) != 0 {
+		r.fail("%d trailing bytes", len(r.b))
+	}
+	return r.err
+}
+
+func (A *DenseMatrix) MarshalBinary() ([]byte, error) {
+	w := newBinWriter(marshalDense)
+	w.uvarint(uint64(A.rows))
+	w.uvarint(uint64(A.cols))
+	var i, j uint
+	for i = 0; i < A.rows; i++ {
+		for j = 0; j < A.cols; j++ {
+			w.putFloat64(A.elements[A.index(i, j)])
+		}
+	}
+	return w.b, nil
+}
+
+/*
+Replaces A with the matrix encoded by MarshalBinary. Malformed data returns
+a *ParseError and leaves A as it is.
+*/
+func (A *DenseMatrix) UnmarshalBinary(data []byte) error {
+	r, _ := newBinReader(data, marshalDense)
+	rows, cols := r.dim(), r.dim()
+	elements := r.float64s(uint64(rows) * uint64(cols))
+	if err := r.done(); err != nil {
+		return err
+	}
+	*A = *MakeDenseMatrix(elements, rows, cols)
+	return nil
+}
+
+func (A *DenseMatrix32) MarshalBinary() ([]byte, error) {
+	w := newBinWriter(marshalDense32)
+	w.uvarint(uint64(A.rows))
+	w.uvarint(uint64(A.cols))
+	for _, v := range A.elements {
+		w.putFloat32(v)
+	}
+	return w.b, nil
+}
+
+// See DenseMatrix.UnmarshalBinary.
+func (A *DenseMatrix32) UnmarshalBinary(data []byte) error {
+	r, _ := newBinReader(data, marshalDense32)
+	rows, cols := r.dim(), r.dim()
+	elements := r.float32s(uint64(rows) * uint64(cols))
+	if err := r.done(); err != nil {
+		return err
+	}
+	*A = *MakeDenseMatrix32(elements, rows, cols)
+	return nil
+}
+
+// The sparse layout of the non-zeros of A.
+func marshalSparseTriplets(A MatrixRO) []byte {
+	rows, cols := A.Rows(), A.Cols()
+	is, js, vs := triplets(A)
+	ps := make([]uint64, len(vs))
+	for k := range vs {
+		ps[k] = uint64(is[k])*uint64(cols) + uint64(js[k])
+	}
+	order := make([]int, len(vs))
+	for k := range order {
+		order[k] = k
+	}
+	sort.Slice(order, func(p, q int) bool { return ps[order[p]] < ps[order[q]] })
+
+	w := newBinWriter(marshalSparse)
+	w.uvarint(uint64(rows))
+	w.uvarint(uint64(cols))
+	w.uvarint(uint64(len(vs)))
+	sorted := make([]uint64, len(vs))
+	for k, o := range order {
+		sorted[k] = ps[o]
+	}
+	w.positions(sorted)
+	for _, o := range order {
+		w.putFloat64(vs[o])
+	}
+	return w.b
+}
+
+// Decodes the sparse layout into triplets.
+func unmarshalSparseTriplets(data []byte) (rows, cols uint, is, js []uint, vs []float64, err error) {
+	r, _ := newBinReader(data, marshalSparse)
+	rows, cols = r.dim(), r.dim()
+	// every stored element takes at least nine bytes
+	nnz := r.count(r.uvarint(), 9)
+	ps := r.positions(nnz, uint64(rows)*uint64(cols))
+	vs = r.float64s(uint64(nnz))
+	if err = r.done(); err != nil {
+		return
+	}
+	is, js = make([]uint, nnz), make([]uint, nnz)
+	for k, p := range ps {
+		is[k], js[k] = uint(p/uint64(cols)), uint(p%uint64(cols))
+	}
+	return
+}
+
+func (A *SparseMatrix) MarshalBinary() ([]byte, error) { return marshalSparseTriplets(A), nil }
+
+// See DenseMatrix.UnmarshalBinary. The data may come from any of the sparse
+// forms.
+func (A *SparseMatrix) UnmarshalBinary(data []byte) error {
+	rows, cols, is, js, vs, err := unmarshalSparseTriplets(data)
+	if err != nil {
+		return err
+	}
+	elements := make(map[uint]float64, len(vs))
+	for k, v := range vs {
+		elements[is[k]*cols+js[k]] = v
+	}
+	*A = *MakeSparseMatrix(elements, rows, cols)
+	return nil
+}
+
+func (A *CSRMatrix) MarshalBinary() ([]byte, error) { return marshalSparseTriplets(A), nil }
+
+// See SparseMatrix.UnmarshalBinary.
+func (A *CSRMatrix) UnmarshalBinary(data []byte) error {
+	rows, cols, is, js, vs, err := unmarshalSparseTriplets(data)
+	if err != nil {
+		return err
+	}
+	*A = CSRMatrix{compressTriplets(rows, is, js, vs)}
+	A.rows, A.cols = rows, cols
+	return nil
+}
+
+func (A *CSCMatrix) MarshalBinary() ([]byte, error) { return marshalSparseTriplets(A), nil }
+
+// See SparseMatrix.UnmarshalBinary.
+func (A *CSCMatrix) UnmarshalBinary(data []byte) error {
+	rows, cols, is, js, vs, err := unmarshalSparseTriplets(data)
+	if err != nil {
+		return err
+	}
+	*A = CSCMatrix{compressTriplets(cols, js, is, vs)}
+	A.rows, A.cols = rows, cols
+	return nil
+}
+
+func (P *PivotMatrix) MarshalBinary() ([]byte, error) {
+	w := newBinWriter(marshalPivot)
+	w.uvarint(uint64(len(P.pivots)))
+	sign := byte(0)
+	if P.pivotSign < 0 {
+		sign = 1
+	}
+	w.b = append(w.b, sign)
+	for _, p := range P.pivots {
+		w.uvarint(uint64(p))
+	}
+	return w.b, nil
+}
+
+// Checks that pivots is a permutation.
+func validPivots(pivots []uint) bool {
+	seen := make([]bool, len(pivots))
+	for _, p := range pivots {
+		if p >= uint(len(pivots)) || seen[p] {
+			return false
+		}
+		seen[p] = true
+	}
+	return true
+}
+
+// See DenseMatrix.UnmarshalBinary.
+func (P *PivotMatrix) UnmarshalBinary(data []byte) error {
+	r, _ := newBinReader(data, marshalPivot)
+	n := r.count(r.uvarint(), 1)
+	sign := 1.0
+	if r.count(1, 1) == 1 {
+		if r.b[0] == 1 {
+			sign = -1
+		}
+		r.b = r.b[1:]
+	}
+	pivots := make([]uint, n)
+	for k := range pivots {
+		pivots[k] = uint(r.uvarint())
+	}
+	if r.err == nil && !validPivots(pivots) {
+		r.fail("pivots are not a permutation")
+	}
+	if err := r.done(); err != nil {
+		return err
+	}
+	*P = *MakePivotMatrix(pivots, sign)
+	return nil
+}
+
+func (v *Vector) MarshalBinary() ([]byte, error) {
+	if !v.isSparse {
+		w := newBinWriter(marshalVector)
+		w.uvarint(uint64(len(v.values)))
+		for _, x := range v.values {
+			w.putFloat64(x)
+		}
+		return w.b, nil
+	}
+	w := newBinWriter(marshalSparseVector)
+	w.uvarint(uint64(len(v.indexes)))
+	ps := make([]uint64, len(v.indexes))
+	for k, i := range v.indexes {
+		ps[k] = uint64(i)
+	}
+	w.positions(ps)
+	for _, x := range v.values {
+		w.putFloat64(x)
+	}
+	return w.b, nil
+}
+
+// See DenseMatrix.UnmarshalBinary. The vector becomes dense or sparse as
+// encoded.
+func (v *Vector) UnmarshalBinary(data []byte) error {
+	r, kind := newBinReader(data, marshalVector, marshalSparseVector)
+	var w Vector
+	if kind == marshalVector {
+		w.values = r.float64s(r.uvarint())
+	} else {
+		w.isSparse = true
+		nnz := r.count(r.uvarint(), 9)
+		for _, p := range r.positions(nnz, uint64(^uint(0)>>1)) {
+			w.indexes = append(w.indexes, int(p))
+		}
+		w.values = r.float64s(uint64(nnz))
+	}
+	if err := r.done(); err != nil {
+		return err
+	}
+	*v = w
+	return nil
+}
+
+// The JSON forms.
+type denseJSON struct {
+	Rows uint      `json:"rows"`
+	Cols uint      `json:"cols"`
+	Data []float64 `json:"data"`
+}
+
+type dense32JSON struct {
+	Rows uint      `json:"rows"`
+	Cols uint      `json:"cols"`
+	Data []float32 `json:"data"`
+}
+
+type sparseJSON struct {
+	Rows uint      `json:"rows"`
+	Cols uint      `json:"cols"`
+	I    []uint    `json:"i"`
+	J    []uint    `json:"j"`
+	V    []float64 `json:"v"`
+}
+
+type pivotJSON struct {
+	Pivots []uint  `json:"pivots"`
+	Sign   float64 `json:"sign"`
+}
+
+type vectorJSON struct {
+	Values  []float64 `json:"values"`
+	Indexes []int     `json:"indexes,omitempty"`
+	Sparse  bool      `json:"sparse,omitempty"`
+}
+
+func jsonError(format string, args ...interface{}) error {
+	return &ParseError{Format: "json", Msg: fmt.Sprintf(format, args...)}
+}
+
+// The number of elements of a rows by cols matrix, false if it overflows.
+func elementCount(rows, cols uint) (uint64, bool) {
+	hi, lo := bits.Mul64(uint64(rows), uint64(cols))
+	return lo, hi == 0
+}
+
+// Marshals A as {"rows": 2, "cols": 2, "data": [1, 2, 3, 4]}, row after row.
+func (A *DenseMatrix) MarshalJSON() ([]byte, error) {
+	return json.Marshal(denseJSON{A.rows, A.cols, MakeDenseCopy(A).elements})
+}
+
+func (A *DenseMatrix) UnmarshalJSON(data []byte) error {
+	var d denseJSON
+	if err := json.Unmarshal(data, &d); err != nil {
+		return err
+	}
+	if n, ok := elementCount(d.Rows, d.Cols); !ok || uint64(len(d.Data)) != n {
+		return jsonError("%d elements for %dx%d", len(d.Data), d.Rows, d.Cols)
+	}
+	*A = *MakeDenseMatrix(d.Data, d.Rows, d.Cols)
+	return nil
+}
+
+// See DenseMatrix.MarshalJSON.
+func (A *DenseMatrix32) MarshalJSON() ([]byte, error) {
+	return json.Marshal(dense32JSON{A.rows, A.cols, A.elements})
+}
+
+func (A *DenseMatrix32) UnmarshalJSON(data []byte) error {
+	var d dense32JSON
+	if err := json.Unmarshal(data, &d); err != nil {
+		return err
+	}
+	if n, ok := elementCount(d.Rows, d.Cols); !ok || uint64(len(d.Data)) != n {
+		return jsonError("%d elements for %dx%d", len(d.Data), d.Rows, d.Cols)
+	}
+	*A = *MakeDenseMatrix32(d.Data, d.Rows, d.Cols)
+	return nil
+}
+
+// Marshals the non-zeros of A as {"rows": 2, "cols": 2, "i": [0], "j": [1],
+// "v": [5]}.
+func sparseMarshalJSON(A MatrixRO) ([]byte, error) {
+	is, js, vs := triplets(A)
+	if vs == nil {
+		is, js, vs = []uint{}, []uint{}, []float64{}
+	}
+	return json.Marshal(sparseJSON{A.Rows(), A.Cols(), is, js, vs})
+}
+
+func sparseUnmarshalJSON(data []byte) (*sparseJSON, error) {
+	var s sparseJSON
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if len(s.I) != len(s.V) || len(s.J) != len(s.V) {
+		return nil, jsonError("%d, %d and %d triplet indexes and values", len(s.I), len(s.J), len(s.V))
+	}
+	if _, ok := elementCount(s.Rows, s.Cols); !ok {
+		return nil, jsonError("%dx%d too large", s.Rows, s.Cols)
+	}
+	for k := range s.V {
+		if s.I[k] >= s.Rows || s.J[k] >= s.Cols {
+			return nil, jsonError("element (%d, %d) outside %dx%d", s.I[k], s.J[k], s.Rows, s.Cols)
+		}
+	}
+	return &s, nil
+}
+
+func (A *SparseMatrix) MarshalJSON() ([]byte, error) { return sparseMarshalJSON(A) }
+
+// Duplicate positions are summed.
+func (A *SparseMatrix) UnmarshalJSON(data []byte) error {
+	s, err := sparseUnmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+	B := ZerosSparse(s.Rows, s.Cols)
+	for k, v := range s.V {
+		B.Set(s.I[k], s.J[k], B.Get(s.I[k], s.J[k])+v)
+	}
+	*A = *B
+	return nil
+}
+
+func (A *CSRMatrix) MarshalJSON() ([]byte, error) { return sparseMarshalJSON(A) }
+
+// Duplicate positions are summed.
+func (A *CSRMatrix) UnmarshalJSON(data []byte) error {
+	s, err := sparseUnmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+	*A = CSRMatrix{compressTriplets(s.Rows, s.I, s.J, s.V)}
+	A.rows, A.cols = s.Rows, s.Cols
+	return nil
+}
+
+func (A *CSCMatrix) MarshalJSON() ([]byte, error) { return sparseMarshalJSON(A) }
+
+// Duplicate positions are summed.
+func (A *CSCMatrix) UnmarshalJSON(data []byte) error {
+	s, err := sparseUnmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+	*A = CSCMatrix{compressTriplets(s.Cols, s.J, s.I, s.V)}
+	A.rows, A.cols = s.Rows, s.Cols
+	return nil
+}
+
+// Marshals P as {"pivots": [1, 0], "sign": -1}.
+func (P *PivotMatrix) MarshalJSON() ([]byte, error) {
+	return json.Marshal(pivotJSON{P.pivots, P.pivotSign})
+}
+
+func (P *PivotMatrix) UnmarshalJSON(data []byte) error {
+	var p pivotJSON
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	if !validPivots(p.Pivots) || (p.Sign != 1 && p.Sign != -1) {
+		return jsonError("not a pivot matrix")
+	}
+	*P = *MakePivotMatrix(p.Pivots, p.Sign)
+	return nil
+}
+
+// Marshals a dense vector as {"values": [1, 2]} and a sparse one as
+// {"values": [1, 2], "indexes": [3, 7], "sparse": true}.
+func (v *Vector) MarshalJSON() ([]byte, error) {
+	j := vectorJSON{Values: v.values, Sparse: v.isSparse}
+	if v.isSparse {
+		j.Indexes = v.indexes
+	}
+	if j.Values == nil {
+		j.Values = []float64{}
+	}
+	return json.Marshal(j)
+}
+
+// Sparse indexes may come in any order; duplicates are summed.
+func (v *Vector) UnmarshalJSON(data []byte) error {
+	var j vectorJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if !j.Sparse {
+		if j.Indexes != nil {
+			return jsonError("indexes of a dense vector")
+		}
+		*v = Vector{values: j.Values}
+		return nil
+	}
+	w, err := NewSparseVectorFrom(j.Indexes, j.Values)
+	if err != nil {
+		return jsonError("bad sparse vector: %v", err)
+	}
+	*v = *w
+	return nil
+}
@@ -0,0 +1,187 @@
+package math
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/hezila/hezila/utils"
+)
+
+func TestMarshalBinary(t *testing.T) {
+	A := MakeDenseMatrix([]float64{1, -2, math.Inf(1), 0, 5e-300, 6}, 2, 3)
+	data, _ := A.T().MarshalBinary()
+	if len(data) != 2+2+6*8 {
+		t.Errorf("dense layout of %d bytes", len(data))
+	}
+	B := new(DenseMatrix)
+	if err := B.UnmarshalBinary(data); err != nil || !Equals(B, A.T()) {
+		t.Errorf("B=%v %v", B, err)
+	}
+
+	S := ZerosSparse(1000, 1000)
+	S.Set(0, 999, 1)
+	S.Set(999, 0, 2)
+	S.Set(500, 500, 3)
+	data, _ = S.MarshalBinary()
+	if len(data) > 64 {
+		t.Errorf("sparse layout of %d bytes", len(data))
+	}
+	S2 := new(SparseMatrix)
+	C := new(CSCMatrix)
+	if err := S2.UnmarshalBinary(data); err != nil || !Equals(S2, S) {
+		t.Errorf("S2=%v", err)
+	}
+	if err := C.UnmarshalBinary(data); err != nil || C.NNZ() != 3 || !Equals(C, S) {
+		t.Errorf("C=%v", err)
+	}
+	// a CSR matrix writes the same layout
+	if csr, _ := S.CSR().MarshalBinary(); !bytes.Equal(csr, data) {
+		t.Error("CSR and SparseMatrix layouts differ")
+	}
+
+	P := MakePivotMatrix([]uint{2, 0, 1}, 1)
+	data, _ = P.MarshalBinary()
+	P2 := new(PivotMatrix)
+	if err := P2.UnmarshalBinary(data); err != nil || !Equals(P2, P) || P2.pivotSign != 1 {
+		t.Errorf("P2=%v %v", P2, err)
+	}
+	data[len(data)-1] = 2
+	if err := P2.UnmarshalBinary(data); err == nil {
+		t.Error("read pivots that are not a permutation")
+	}
+
+	A32 := A.DenseMatrix32()
+	data, _ = A32.MarshalBinary()
+	B32 := new(DenseMatrix32)
+	if err := B32.UnmarshalBinary(data); err != nil || !Equals(B32, A32) {
+		t.Errorf("B32=%v %v", B32, err)
+	}
+	if err := B.UnmarshalBinary(data); err == nil {
+		t.Error("read a DenseMatrix32 into a DenseMatrix")
+	}
+
+	for _, bad := range [][]byte{nil, {2, marshalDense}, {1, marshalDense, 2, 2, 0}, {1, marshalDense, 0, 0, 0}} {
+		if err := B.UnmarshalBinary(bad); err == nil {
+			t.Errorf("read %v", bad)
+		} else if _, ok := err.(*ParseError); !ok {
+			t.Errorf("%T %v", err, err)
+		}
+	}
+	// 2^32 by 2^32 elements would wrap around to none
+	huge := []byte{1, marshalDense, 0x80, 0x80, 0x80, 0x80, 0x10, 0x80, 0x80, 0x80, 0x80, 0x10}
+	if err := B.UnmarshalBinary(huge); err == nil {
+		t.Errorf("read %dx%d", B.Rows(), B.Cols())
+	}
+	huge[1] = marshalSparse
+	if err := S2.UnmarshalBinary(append(huge, 0)); err == nil {
+		t.Errorf("read sparse %dx%d", S2.Rows(), S2.Cols())
+	}
+	if !Equals(B, A.T()) {
+		t.Error("a failed read changed B")
+	}
+}
+
+func TestMarshalVector(t *testing.T) {
+	sv, _ := NewSparseVectorFrom([]int{1 << 30, 7}, []float64{1, 2})
+	dv := NewVector(3)
+	dv.SetValues([]float64{1, 0, -1})
+	for _, v := range []*Vector{sv, dv, NewSparseVector()} {
+		data, _ := v.MarshalBinary()
+		w := new(Vector)
+		if err := w.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		js, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u := new(Vector)
+		if err := json.Unmarshal(js, u); err != nil {
+			t.Fatal(err)
+		}
+		for _, x := range []*Vector{w, u} {
+			if x.IsSparse() != v.IsSparse() || x.NNZ() != v.NNZ() || x.Dot(v) != v.Dot(v) {
+				t.Errorf("%s: %v", js, x)
+			}
+		}
+	}
+	js, _ := json.Marshal(sv)
+	utils.Expect(t, `{"values":[2,1],"indexes":[7,1073741824],"sparse":true}`, string(js))
+}
+
+func TestMarshalJSON(t *testing.T) {
+	A := MakeDenseMatrix([]float64{1, 2.5, 3, 4}, 2, 2)
+	js, err := json.Marshal(A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	utils.Expect(t, `{"rows":2,"cols":2,"data":[1,2.5,3,4]}`, string(js))
+	B := new(DenseMatrix)
+	if err := json.Unmarshal(js, B); err != nil || !Equals(B, A) {
+		t.Errorf("B=%v %v", B, err)
+	}
+	if err := json.Unmarshal([]byte(`{"rows":2,"cols":2,"data":[1]}`), B); err == nil {
+		t.Error("read 1 element for 2x2")
+	}
+	if err := json.Unmarshal([]byte(`{"rows":4294967296,"cols":4294967296,"data":[]}`), B); err == nil {
+		t.Errorf("read %dx%d", B.Rows(), B.Cols())
+	}
+
+	S := ZerosSparse(2, 3)
+	S.Set(1, 2, 5)
+	js, _ = json.Marshal(S)
+	utils.Expect(t, `{"rows":2,"cols":3,"i":[1],"j":[2],"v":[5]}`, string(js))
+	R := new(CSRMatrix)
+	if err := json.Unmarshal(js, R); err != nil || R.NNZ() != 1 || !Equals(R, S) {
+		t.Errorf("R=%v %v", R, err)
+	}
+	if err := json.Unmarshal([]byte(`{"rows":2,"cols":3,"i":[2],"j":[0],"v":[1]}`), R); err == nil {
+		t.Error("read an element out of bounds")
+	}
+
+	P := new(PivotMatrix)
+	if err := json.Unmarshal([]byte(`{"pivots":[1,0],"sign":-1}`), P); err != nil || P.Get(1, 0) != 1 {
+		t.Errorf("P=%v %v", P, err)
+	}
+	if err := json.Unmarshal([]byte(`{"pivots":[1,1],"sign":-1}`), P); err == nil {
+		t.Error("read pivots that are not a permutation")
+	}
+
+	// a matrix in a struct, as for a checkpoint
+	type checkpoint struct {
+		Step    int
+		Weights *DenseMatrix32
+	}
+	c := checkpoint{7, A.DenseMatrix32()}
+	js, _ = json.Marshal(c)
+	var c2 checkpoint
+	if err := json.Unmarshal(js, &c2); err != nil || c2.Step != 7 || !Equals(c2.Weights, A) {
+		t.Errorf("%s: %v", js, err)
+	}
+}
+
+func TestGob(t *testing.T) {
+	type model struct {
+		W *DenseMatrix
+		S *SparseMatrix
+		P *PivotMatrix
+		V *Vector
+	}
+	v, _ := NewSparseVectorFrom([]int{2}, []float64{3})
+	m := model{Eye(3), EyeSparse(4), MakePivotMatrix([]uint{1, 0}, -1), v}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(m); err != nil {
+		t.Fatal(err)
+	}
+	var m2 model
+	if err := gob.NewDecoder(&buf).Decode(&m2); err != nil {
+		t.Fatal(err)
+	}
+	if !Equals(m2.W, m.W) || !Equals(m2.S, m.S) || !Equals(m2.P, m.P) || m2.V.Get(2) != 3 || !m2.V.IsSparse() {
+		t.Errorf("m2=%v", m2)
+	}
+}
@@ -0,0 +1,577 @@
+package math
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+/*
+Reading and writing matrices in MATLAB syntax, as pasted from an Octave or
+MATLAB session. ParseMatlab reads
+
+	[1 2 3; 4 5 6]          rows by semicolons or newlines
+	[1, -2e-3, Inf, NaN]    elements by spaces or commas
+	1:0.5:3                 ranges, with an optional step
+	zeros(2,3) ones(2) eye(3)
+	[A B; C D]              nested concatenation of any of the above
+
+along with the {1, 2,\n 3, 4} layout of String. An optional "name =" before
+the matrix and a ";" after it are skipped, and so are comments starting with
+% or #. Arithmetic is not supported.
+
+ParseMatlabComplex reads the same syntax with imaginary numbers such as 2i,
+-1.5j, i or NaNi, and complex ones written without spaces such as 1-2i. As
+in MATLAB, [1 -2i] has two elements; [1 - 2i] is arithmetic.
+*/
+
+// Token kinds besides the punctuation characters, which stand for themselves
+const (
+	matlabEnd    = 0
+	matlabNumber = 'n'
+	matlabImag   = 'i'
+	matlabName   = 'a'
+	matlabBreak  = '\n'
+)
+
+type matlabToken struct {
+	kind  byte
+	text  string
+	value float64
+	// whether whitespace precedes the token
+	space bool
+	pos   int
+}
+
+// The parser builds complex blocks; ParseMatlab takes their real parts.
+type matlabParser struct {
+	src  string
+	toks []matlabToken
+	k    int
+	vars map[string]MatrixRO
+	// whether imaginary numbers are read
+	complex bool
+}
+
+// Panics with a ParseError located at byte pos of the source.
+func (p *matlabParser) fail(pos int, format string, args ...interface{}) {
+	line := 1 + strings.Count(p.src[:pos], "\n")
+	col := pos - strings.LastIndex(p.src[:pos], "\n")
+	panic(&ParseError{Format: "matlab", Line: line,
+		Msg: fmt.Sprintf("column %d: ", col) + fmt.Sprintf(format, args...)})
+}
+
+// Splits the source into tokens. Newlines are row breaks inside brackets
+// and whitespace elsewhere.
+func (p *matlabParser) tokenize() {
+	src := p.src
+	depth, paren := 0, 0
+	space := false
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			space = true
+			continue
+		case c == '%' || c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(src[i:], "..."):
+			// continuation: the rest of the line is ignored
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			i++
+			space = true
+			continue
+		case c == '\n':
+			i++
+			if depth == 0 || paren > 0 {
+				space = true
+				continue
+			}
+			p.toks = append(p.toks, matlabToken{kind: matlabBreak, space: space, pos: i - 1})
+			space = false
+			continue
+		}
+
+		t := matlabToken{space: space, pos: i}
+		space = false
+		switch {
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			j := i
+			for j < len(src) && (isDigit(src[j]) || src[j] == '.') {
+				j++
+			}
+			if j < len(src) && (src[j] == 'e' || src[j] == 'E') {
+				k := j + 1
+				if k < len(src) && (src[k] == '+' || src[k] == '-') {
+					k++
+				}
+				if k < len(src) && isDigit(src[k]) {
+					for j = k; j < len(src) && isDigit(src[j]); j++ {
+					}
+				}
+			}
+			v, err := strconv.ParseFloat(src[i:j], 64)
+			if err != nil {
+				p.fail(i, "bad number %q", src[i:j])
+			}
+			t.kind, t.text, t.value = matlabNumber, src[i:j], v
+			// an imaginary number such as 2i or 1.5e3j
+			if p.complex && j < len(src) && (src[j] == 'i' || src[j] == 'j') &&
+				!(j+1 < len(src) && (isLetter(src[j+1]) || isDigit(src[j+1]))) {
+				t.kind = matlabImag
+				j++
+				t.text = src[i:j]
+			}
+			i = j
+		case isLetter(c):
+			j := i
+			for j < len(src) && (isLetter(src[j]) || isDigit(src[j])) {
+				j++
+			}
+			t.kind, t.text = matlabName, src[i:j]
+			i = j
+		case strings.IndexByte("[]{}(),;:+-=", c) >= 0:
+			switch c {
+			case '[', '{':
+				depth++
+			case ']', '}':
+				depth--
+			case '(':
+				paren++
+			case ')':
+				paren--
+			}
+			t.kind, t.text = c, src[i:i+1]
+			i++
+		default:
+			p.fail(i, "unexpected %q", c)
+		}
+		p.toks = append(p.toks, t)
+	}
+	p.toks = append(p.toks, matlabToken{kind: matlabEnd, text: "end of input", space: space, pos: len(src)})
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+func isLetter(c byte) bool { return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') }
+
+func (p *matlabParser) peek() matlabToken { return p.toks[p.k] }
+
+func (p *matlabParser) next() matlabToken {
+	t := p.toks[p.k]
+	if t.kind != matlabEnd {
+		p.k++
+	}
+	return t
+}
+
+func (p *matlabParser) expect(kind byte) matlabToken {
+	t := p.next()
+	if t.kind != kind {
+		p.fail(t.pos, "expected %q, found %q", kind, t.text)
+	}
+	return t
+}
+
+// The whole input: an optional assignment, one element and an optional
+// semicolon.
+func (p *matlabParser) parse() *CDenseMatrix {
+	if len(p.toks) > 2 && p.toks[0].kind == matlabName && p.toks[1].kind == '=' {
+		p.k = 2
+	}
+	A := p.element()
+	if p.peek().kind == ';' {
+		p.next()
+	}
+	if t := p.peek(); t.kind != matlabEnd {
+		p.fail(t.pos, "unexpected %q", t.text)
+	}
+	return A
+}
+
+// A bracketed matrix, a call, a variable, a number or a range.
+func (p *matlabParser) element() *CDenseMatrix {
+	t := p.peek()
+	switch t.kind {
+	case '[':
+		p.next()
+		return p.concat(']')
+	case '{':
+		p.next()
+		return p.concat('}')
+	case matlabName:
+		if _, _, ok := p.value(t); ok {
+			return p.numberOrRange()
+		}
+		if p.toks[p.k+1].kind == '(' {
+			return p.call()
+		}
+		p.next()
+		if A, ok := p.vars[t.text]; ok {
+			return MakeCDenseCopy(A)
+		}
+		p.fail(t.pos, "undefined %q", t.text)
+	case matlabNumber, matlabImag, '+', '-':
+		return p.numberOrRange()
+	}
+	p.fail(t.pos, "unexpected %q", t.text)
+	return nil
+}
+
+func matlabConstant(name string) (float64, bool) {
+	switch name {
+	case "Inf", "inf":
+		return math.Inf(1), true
+	case "NaN", "nan":
+		return math.NaN(), true
+	case "pi":
+		return math.Pi, true
+	}
+	return 0, false
+}
+
+// The value of a number or constant token, and whether it is imaginary.
+// The imaginary unit i or j and constants such as Infi are only read in
+// complex mode.
+func (p *matlabParser) value(t matlabToken) (v complex128, imaginary, ok bool) {
+	switch t.kind {
+	case matlabNumber:
+		return complex(t.value, 0), false, true
+	case matlabImag:
+		return complex(0, t.value), true, true
+	case matlabName:
+		if x, ok := matlabConstant(t.text); ok {
+			return complex(x, 0), false, true
+		}
+		if !p.complex {
+			break
+		}
+		n := len(t.text)
+		if last := t.text[n-1]; last != 'i' && last != 'j' {
+			break
+		}
+		if n == 1 {
+			return 1i, true, true
+		}
+		if x, ok := matlabConstant(t.text[:n-1]); ok {
+			return complex(0, x), true, true
+		}
+	}
+	return 0, false, false
+}
+
+// A number or constant with an optional sign, and whether it is imaginary.
+func (p *matlabParser) signed() (complex128, bool) {
+	t := p.next()
+	negative := false
+	if t.kind == '+' || t.kind == '-' {
+		negative = t.kind == '-'
+		t = p.next()
+		if t.space {
+			p.fail(t.pos, "arithmetic is not supported")
+		}
+	}
+	v, imaginary, ok := p.value(t)
+	if !ok {
+		p.fail(t.pos, "expected a number, found %q", t.text)
+	}
+	if negative {
+		// not -1*v, which turns the zero part of -Inf into NaN
+		v = complex(-real(v), -imag(v))
+	}
+	return v, imaginary
+}
+
+// A signed number or constant, or in complex mode a real and an imaginary
+// one joined without spaces, as in 1-2i.
+func (p *matlabParser) scalar() complex128 {
+	v, imaginary := p.signed()
+	if !p.complex || imaginary {
+		return v
+	}
+	sign, im := p.peek(), p.toks[p.k+1]
+	if (sign.kind == '+' || sign.kind == '-') && !sign.space && !im.space {
+		if _, imaginary, _ := p.value(im); imaginary {
+			w, _ := p.signed()
+			v = complex(real(v), imag(w))
+		}
+	}
+	return v
+}
+
+// A scalar that must be real, as the bounds of a range or a size.
+func (p *matlabParser) realScalar() float64 {
+	t := p.peek()
+	v := p.scalar()
+	if imag(v) != 0 {
+		p.fail(t.pos, "expected a real number, found %s", formatComplex(v))
+	}
+	return real(v)
+}
+
+// A number, or the row vector a:b or a:step:b.
+func (p *matlabParser) numberOrRange() *CDenseMatrix {
+	first := p.peek()
+	c := p.scalar()
+	if p.peek().kind != ':' {
+		return MakeCDenseMatrix([]complex128{c}, 1, 1)
+	}
+	if imag(c) != 0 {
+		p.fail(first.pos, "expected a real number, found %s", formatComplex(c))
+	}
+	a := real(c)
+	t := p.next()
+	step, b := 1.0, p.realScalar()
+	if p.peek().kind == ':' {
+		p.next()
+		step, b = b, p.realScalar()
+	}
+	q := (b - a) / step
+	if step == 0 || !(q >= 0) {
+		return CZeros(1, 0)
+	}
+	// tolerate the rounding of a step such as 0.1
+	q = math.Floor(q + 4*eps*math.Max(1, q))
+	if q >= 1<<26 {
+		p.fail(t.pos, "range too long")
+	}
+	n := uint(q) + 1
+	R := CZeros(1, n)
+	var k uint
+	for k = 0; k < n; k++ {
+		R.elements[k] = complex(a+float64(k)*step, 0)
+	}
+	if math.Abs(real(R.elements[n-1])-b) <= 4*eps*math.Abs(b) {
+		R.elements[n-1] = complex(b, 0)
+	}
+	return R
+}
+
+// zeros, ones or eye with zero, one or two sizes.
+func (p *matlabParser) call() *CDenseMatrix {
+	name := p.next()
+	p.expect('(')
+	var dims []uint
+	for p.peek().kind != ')' {
+		if len(dims) > 0 {
+			p.expect(',')
+		}
+		t := p.peek()
+		v := p.realScalar()
+		if v < 0 || v != math.Floor(v) || v >= 1<<26 {
+			p.fail(t.pos, "bad size %v", v)
+		}
+		dims = append(dims, uint(v))
+	}
+	p.next()
+
+	var m, n uint
+	switch len(dims) {
+	case 0:
+		m, n = 1, 1
+	case 1:
+		m, n = dims[0], dims[0]
+	case 2:
+		m, n = dims[0], dims[1]
+	default:
+		p.fail(name.pos, "too many arguments to %s", name.text)
+	}
+	if uint64(m)*uint64(n) >= 1<<26 {
+		p.fail(name.pos, "%dx%d matrix too large", m, n)
+	}
+	A := CZeros(m, n)
+	switch name.text {
+	case "zeros":
+		return A
+	case "ones":
+		for k := range A.elements {
+			A.elements[k] = 1
+		}
+		return A
+	case "eye":
+		for k := uint(0); k < minUInt(m, n); k++ {
+			A.elements[k*n+k] = 1
+		}
+		return A
+	}
+	p.fail(name.pos, "unknown function %q", name.text)
+	return nil
+}
+
+// The rows of a bracketed matrix up to the closing bracket, concatenated.
+func (p *matlabParser) concat(closing byte) *CDenseMatrix {
+	var rows []*CDenseMatrix
+	var row []*CDenseMatrix
+	rowPos := p.peek().pos
+	for {
+		t := p.peek()
+		switch t.kind {
+		case closing, ';', matlabBreak:
+			p.next()
+			rows = append(rows, p.hcat(row, rowPos))
+			row = nil
+			rowPos = p.peek().pos
+			if t.kind == closing {
+				return p.vcat(rows, t.pos)
+			}
+		case ',':
+			p.next()
+		case '+', '-':
+			// a sign after an element starts a new one only as in [1 -2] or
+			// [1, -2]
+			sep := p.toks[p.k-1].kind == ',' || (t.space && !p.toks[p.k+1].space)
+			if len(row) > 0 && !sep {
+				p.fail(t.pos, "arithmetic is not supported")
+			}
+			row = append(row, p.element())
+		case matlabEnd:
+			p.fail(t.pos, "missing %q", closing)
+		default:
+			row = append(row, p.element())
+		}
+	}
+}
+
+// The blocks side by side. Empty blocks are skipped.
+func (p *matlabParser) hcat(blocks []*CDenseMatrix, pos int) *CDenseMatrix {
+	var rows, cols uint
+	var nonEmpty []*CDenseMatrix
+	for _, B := range blocks {
+		if B.rows*B.cols == 0 {
+			continue
+		}
+		if len(nonEmpty) > 0 && B.rows != rows {
+			p.fail(pos, "row heights %d and %d do not agree", rows, B.rows)
+		}
+		rows = B.rows
+		cols += B.cols
+		nonEmpty = append(nonEmpty, B)
+	}
+	A := CZeros(rows, cols)
+	var off, i uint
+	for _, B := range nonEmpty {
+		for i = 0; i < B.rows; i++ {
+			copy(A.elements[i*cols+off:], B.elements[i*B.cols:(i+1)*B.cols])
+		}
+		off += B.cols
+	}
+	return A
+}
+
+// The blocks one below the other. Empty blocks are skipped.
+func (p *matlabParser) vcat(blocks []*CDenseMatrix, pos int) *CDenseMatrix {
+	var rows, cols uint
+	var nonEmpty []*CDenseMatrix
+	for _, B := range blocks {
+		if B.rows*B.cols == 0 {
+			continue
+		}
+		if len(nonEmpty) > 0 && B.cols != cols {
+			p.fail(pos, "row widths %d and %d do not agree", cols, B.cols)
+		}
+		cols = B.cols
+		rows += B.rows
+		nonEmpty = append(nonEmpty, B)
+	}
+	A := CZeros(rows, cols)
+	off := 0
+	for _, B := range nonEmpty {
+		off += copy(A.elements[off:], B.elements)
+	}
+	return A
+}
+
+// Runs the parser, turning its panics into errors.
+func (p *matlabParser) run() (A *CDenseMatrix, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			pe, ok := r.(*ParseError)
+			if !ok {
+				panic(r)
+			}
+			A, err = nil, pe
+		}
+	}()
+	p.tokenize()
+	return p.parse(), nil
+}
+
+/*
+Takes a matlab-style matrix representation, e.g. [a b c; d e f], see above.
+A malformed input returns a *ParseError.
+*/
+func ParseMatlab(txt string) (*DenseMatrix, error) {
+	return ParseMatlabWith(txt, nil)
+}
+
+/*
+Like ParseMatlab, with the names in vars standing for their matrices, so
+that [A B; C D] concatenates four blocks.
+*/
+func ParseMatlabWith(txt string, vars map[string]MatrixRO) (*DenseMatrix, error) {
+	A, err := (&matlabParser{src: txt, vars: vars}).run()
+	if err != nil {
+		return nil, err
+	}
+	return A.Real(), nil
+}
+
+/*
+Takes a matlab-style representation of a complex matrix, e.g.
+[1+2i 3; -i 4j], in the syntax of ParseMatlab. The layout of String is read
+as well. A malformed input returns a *ParseError.
+*/
+func ParseMatlabComplex(txt string) (*CDenseMatrix, error) {
+	return (&matlabParser{src: txt, complex: true}).run()
+}
+
+// Formats v with prec significant digits, or with the fewest digits that
+// read back exactly if prec is negative. Infinities and NaN are spelt the
+// MATLAB way.
+func formatMatlabFloat(v float64, prec int) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', prec, 64)
+}
+
+/*
+Formats M in the syntax ParseMatlab reads, e.g. [1 2; 3 4]. Elements are
+written with prec significant digits, or with the fewest digits that read
+back to the same float64 if prec is negative; ParseMatlab(FormatMatlab(M,
+-1)) equals M exactly. An empty matrix other than 0x0 is written as
+zeros(rows,cols) so that its shape reads back.
+*/
+func FormatMatlab(M MatrixRO, prec int) string {
+	if M.Rows()*M.Cols() == 0 && M.Rows()+M.Cols() > 0 {
+		return fmt.Sprintf("zeros(%d,%d)", M.Rows(), M.Cols())
+	}
+	var b strings.Builder
+	b.WriteByte('[')
+	var i, j uint
+	for i = 0; i < M.Rows(); i++ {
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		for j = 0; j < M.Cols(); j++ {
+			if j > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(formatMatlabFloat(M.Get(i, j), prec))
+		}
+	}
+	b.WriteByte(']')
+	return b.String()
+}
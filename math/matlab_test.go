@@ -0,0 +1,91 @@
+package math
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hezila/hezila/utils"
+)
+
+func TestParseMatlabSyntax(t *testing.T) {
+	I := Eye(2)
+	cases := []struct {
+		txt  string
+		want *DenseMatrix
+	}{
+		{"[1,2;3,4]", MakeDenseMatrix([]float64{1, 2, 3, 4}, 2, 2)},
+		{"[-1 2e-3 +.5 1E+2]", MakeDenseMatrix([]float64{-1, 2e-3, 0.5, 100}, 1, 4)},
+		{"[1, -2]", MakeDenseMatrix([]float64{1, -2}, 1, 2)},
+		{"1:0.5:3", MakeDenseMatrix([]float64{1, 1.5, 2, 2.5, 3}, 1, 5)},
+		{"[0:0.1:0.3; 3 : -1 : 0]", MakeDenseMatrix([]float64{0, 0.1, 0.2, 0.3, 3, 2, 1, 0}, 2, 4)},
+		{"[zeros(1,2) ones(1); eye(3)]", MakeDenseMatrix([]float64{0, 0, 1, 1, 0, 0, 0, 1, 0, 0, 0, 1}, 4, 3)},
+		{"[eye(2) zeros(2, 1); ones(1,3)]", MakeDenseMatrix([]float64{1, 0, 0, 0, 1, 0, 1, 1, 1}, 3, 3)},
+		{"[[1 2; 3 4] I; I, [5 6; 7 8]]", MakeDenseMatrix([]float64{1, 2, 1, 0, 3, 4, 0, 1, 1, 0, 5, 6, 0, 1, 7, 8}, 4, 4)},
+		{"[I I]", MakeDenseMatrix([]float64{1, 0, 1, 0, 0, 1, 0, 1}, 2, 4)},
+		{"A = [1 2 ... continued\n 3 4 % comment\n];", MakeDenseMatrix([]float64{1, 2, 3, 4}, 1, 4)},
+		{"[1 2\n3 4\n]", MakeDenseMatrix([]float64{1, 2, 3, 4}, 2, 2)},
+		{"{1, 2,\n 3, 4}", MakeDenseMatrix([]float64{1, 2, 3, 4}, 2, 2)},
+		{"[]", Zeros(0, 0)},
+		{"[1 [] 2 3:2]", MakeDenseMatrix([]float64{1, 2}, 1, 2)},
+		{"[1 2; 3]", nil},
+		{"[1 - 2]", nil},
+		{"[1-2]", nil},
+		{"[1 2", nil},
+		{"[1 2] 3", nil},
+		{"[B]", nil},
+		{"foo(2)", nil},
+		{"zeros(2.5)", nil},
+		{"zeros(60000000,60000000)", nil},
+	}
+	vars := map[string]MatrixRO{"I": I}
+	for _, c := range cases {
+		A, err := ParseMatlabWith(c.txt, vars)
+		if c.want == nil {
+			if _, ok := err.(*ParseError); !ok {
+				t.Errorf("%q: %v %v", c.txt, A, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", c.txt, err)
+		} else if A.Rows() != c.want.Rows() || A.Cols() != c.want.Cols() || !Equals(A, c.want) {
+			t.Errorf("%q: %v", c.txt, A)
+		}
+	}
+
+	A, err := ParseMatlab("[Inf -inf NaN pi]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsInf(A.Get(0, 0), 1) || !math.IsInf(A.Get(0, 1), -1) || !math.IsNaN(A.Get(0, 2)) || A.Get(0, 3) != math.Pi {
+		t.Errorf("constants: %v", A)
+	}
+
+	_, err = ParseMatlab("[1 2\n 3 x]")
+	utils.Expect(t, "matlab: line 2: column 4: undefined \"x\"", err.Error())
+}
+
+func TestFormatMatlab(t *testing.T) {
+	A := MakeDenseMatrix([]float64{0.1 + 0.2, -1e-300, math.Inf(-1), math.NaN(), 1 / 3.0, 12345678}, 2, 3)
+	B, err := ParseMatlab(FormatMatlab(A, -1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	C, err := ParseMatlab(String(A))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for p, v := range A.elements {
+		if !(v == B.elements[p] && v == C.elements[p]) && !(math.IsNaN(v) && math.IsNaN(B.elements[p]) && math.IsNaN(C.elements[p])) {
+			t.Errorf("element %d: %v, %v and %v", p, v, B.elements[p], C.elements[p])
+		}
+	}
+	utils.Expect(t, "[0.3 -1e-300 -Inf; NaN 0.333 1.23e+07]", FormatMatlab(A, 3))
+	utils.Expect(t, "[]", FormatMatlab(Zeros(0, 0), -1))
+	for _, E := range []*DenseMatrix{Zeros(3, 0), Zeros(0, 3)} {
+		F, err := ParseMatlab(FormatMatlab(E, -1))
+		if err != nil || F.Rows() != E.Rows() || F.Cols() != E.Cols() {
+			t.Errorf("%dx%d read as %v %v", E.Rows(), E.Cols(), F, err)
+		}
+	}
+}
@@ -1,12 +1,5 @@
 package math
 
-import (
-	"errors"
-	"fmt"
-	"strconv"
-	"strings"
-)
-
 /*
 Read-only matrix interface defines matrix operations that do not change the element value.
 */
@@ -35,11 +28,15 @@ type MatrixRO interface {
 	// The trace of this matrix
 	Trace() float64
 
-	// Returns an array of slices referencing the matrix data.
-	// Changes to the slices effect changes to the matrix.
+	// Returns the rows of this matrix as slices. Only a contiguous
+	// DenseMatrix returns slices referencing its data, so that changes to
+	// them change the matrix; the sparse, pivot and float32 types and
+	// strided views return copies. Use Set to change any matrix.
 	Arrays() [][]float64
 
-	// Returns the contents/slices of this matrix stored into a flat array (row-major).
+	// Returns the contents/slices of this matrix stored into a flat array
+	// (row-major). Copies unless this is a contiguous DenseMatrix, as
+	// with Arrays.
 	Array() []float64
 
 	// The pretty-print string
@@ -82,110 +79,7 @@ func (M *matrix) Dimension() (rows, cols uint) {
 	return
 }
 
-/*
-   Take a matlab-style matrix representation
-   e.g., [a b c; d e f]
-*/
-func ParseMatlab(txt string) (A *DenseMatrix, err error) {
-	var arrays [][]float64
-	spaceSep := strings.Fields(txt)
-
-	tok := func() (t string, eos bool) {
-		defer func() {
-			for len(spaceSep) != 0 && len(spaceSep[0]) == 0 {
-				spaceSep = spaceSep[1:]
-			}
-		}()
-
-		isNotNumber := func(c byte) bool {
-			return c != '[' || c != ']' || c == ';'
-		}
-
-		if len(spaceSep) == 0 {
-			eos = true
-			return
-		}
-
-		top := spaceSep[0]
-
-		var lof int
-		for lof = 0; lof < len(top) && !isNotNumber(top[lof]); lof++ {
-		}
-
-		if lof != 0 {
-			t = top[:lof]
-			spaceSep[0] = top[lof:]
-			return
-		} else {
-			t = top[:1]
-			spaceSep[0] = top[1:]
-			return
-		}
-
-		panic("unreadable")
-	}
-
-	stack := func(row []float64) (err error) {
-		if len(arrays) == 0 {
-			arrays = [][]float64{row}
-			return
-		}
-		if len(arrays[0]) != len(row) {
-			err = errors.New("misaligned row")
-		}
-		arrays = append(arrays, row)
-		return
-	}
-
-	var row []float64
-
-loop:
-	for {
-		t, eos := tok()
-		if eos {
-			break loop
-		}
-		switch t {
-		case "[":
-		case ";":
-			err = stack(row)
-			if err != nil {
-				return
-			}
-			row = []float64{}
-		case "]":
-			err = stack(row)
-			if err != nil {
-				return
-			}
-			break loop
-		default:
-			var v float64
-			v, err = strconv.ParseFloat(t, 64)
-			if err != nil {
-				return
-			}
-			row = append(row, v)
-		}
-	}
-
-	A = MakeDenseMatrixStacked(arrays)
-	return
-}
-
 func String(M MatrixRO) string {
-	condense := func(vs string) string {
-		if strings.Index(vs, ".") != -1 {
-			for vs[len(vs)-1] == '0' {
-				vs = vs[0 : len(vs)-1]
-			}
-		}
-		if vs[len(vs)-1] == '.' {
-			vs = vs[0 : len(vs)-1]
-		}
-		return vs
-	}
-
 	if M == nil {
 		return "{nil}"
 	}
@@ -196,7 +90,7 @@ func String(M MatrixRO) string {
 	for i = 0; i < M.Rows(); i++ {
 		for j = 0; j < M.Cols(); j++ {
 			v := M.Get(i, j)
-			vs := condense(fmt.Sprintf("%f", v))
+			vs := formatMatlabFloat(v, -1)
 
 			maxLen = maxUInt(maxLen, uint(len(vs)))
 		}
@@ -206,7 +100,7 @@ func String(M MatrixRO) string {
 		for j = 0; j < M.Cols(); j++ {
 			v := M.Get(i, j)
 
-			vs := condense(fmt.Sprintf("%f", v))
+			vs := formatMatlabFloat(v, -1)
 
 			for uint(len(vs)) < maxLen {
 				vs = " " + vs
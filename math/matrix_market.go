@@ -0,0 +1,349 @@
+package math
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The qualifiers of the Matrix Market banner line.
+const (
+	MMCoordinate = "coordinate"
+	MMArray      = "array"
+
+	MMReal    = "real"
+	MMInteger = "integer"
+	MMPattern = "pattern"
+
+	MMGeneral       = "general"
+	MMSymmetric     = "symmetric"
+	MMSkewSymmetric = "skew-symmetric"
+)
+
+/*
+The qualifiers of a Matrix Market file. Coordinate files list the non-zeros
+and array files every element by columns. Symmetric and skew-symmetric files
+hold only the lower triangle, the strict lower triangle for skew-symmetric
+ones. Pattern files give the positions of the non-zeros but no values, which
+read as ones.
+*/
+type MatrixMarketHeader struct {
+	Format   string
+	Field    string
+	Symmetry string
+}
+
+func (h *MatrixMarketHeader) check() error {
+	switch {
+	case h.Format != MMCoordinate && h.Format != MMArray:
+		return fmt.Errorf("unknown format %q", h.Format)
+	case h.Field != MMReal && h.Field != MMInteger && h.Field != MMPattern:
+		return fmt.Errorf("unsupported field %q", h.Field)
+	case h.Symmetry != MMGeneral && h.Symmetry != MMSymmetric && h.Symmetry != MMSkewSymmetric:
+		return fmt.Errorf("unsupported symmetry %q", h.Symmetry)
+	case h.Field == MMPattern && h.Format == MMArray:
+		return fmt.Errorf("pattern field in array format")
+	case h.Field == MMPattern && h.Symmetry == MMSkewSymmetric:
+		return fmt.Errorf("skew-symmetric pattern")
+	}
+	return nil
+}
+
+/*
+Reads a matrix in the Matrix Market exchange format. Coordinate files give a
+*SparseMatrix and array files a *DenseMatrix. Duplicate coordinate entries
+are summed. Malformed input returns a *ParseError.
+*/
+func ReadMatrixMarket(r io.Reader) (Matrix, *MatrixMarketHeader, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<30)
+	line := 0
+	fail := func(format string, args ...interface{}) error {
+		return &ParseError{Format: "Matrix Market", Line: line, Msg: fmt.Sprintf(format, args...)}
+	}
+	// the fields of the next line that is neither blank nor a comment
+	next := func() ([]string, error) {
+		for sc.Scan() {
+			line++
+			t := strings.TrimSpace(sc.Text())
+			if t != "" && t[0] != '%' {
+				return strings.Fields(t), nil
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+		line = 0
+		return nil, fail("unexpected end of input")
+	}
+
+	if !sc.Scan() {
+		if err := sc.Err(); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, fail("empty input")
+	}
+	line++
+	banner := strings.Fields(strings.ToLower(sc.Text()))
+	if len(banner) != 5 || banner[0] != "%%matrixmarket" || banner[1] != "matrix" {
+		return nil, nil, fail("not a Matrix Market matrix header")
+	}
+	h := &MatrixMarketHeader{Format: banner[2], Field: banner[3], Symmetry: banner[4]}
+	if err := h.check(); err != nil {
+		return nil, nil, fail("%v", err)
+	}
+
+	parseUint := func(s string) (uint, error) {
+		v, err := strconv.ParseUint(s, 10, 0)
+		if err != nil {
+			return 0, fail("bad integer %q", s)
+		}
+		return uint(v), nil
+	}
+	parseValue := func(s string) (float64, error) {
+		if h.Field == MMInteger {
+			v, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return 0, fail("bad integer %q", s)
+			}
+			return float64(v), nil
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fail("bad number %q", s)
+		}
+		return v, nil
+	}
+
+	f, err := next()
+	if err != nil {
+		return nil, nil, err
+	}
+	nsize := 3
+	if h.Format == MMArray {
+		nsize = 2
+	}
+	if len(f) != nsize {
+		return nil, nil, fail("expected %d sizes, got %d", nsize, len(f))
+	}
+	var size [3]uint
+	for k := range f {
+		if size[k], err = parseUint(f[k]); err != nil {
+			return nil, nil, err
+		}
+	}
+	rows, cols := size[0], size[1]
+	if h.Symmetry != MMGeneral && rows != cols {
+		return nil, nil, fail("%s matrix is not square", h.Symmetry)
+	}
+	sign := 1.0
+	if h.Symmetry == MMSkewSymmetric {
+		sign = -1
+	}
+
+	if h.Format == MMArray {
+		A := Zeros(rows, cols)
+		var i, j uint
+		for j = 0; j < cols; j++ {
+			i = 0
+			switch h.Symmetry {
+			case MMSymmetric:
+				i = j
+			case MMSkewSymmetric:
+				i = j + 1
+			}
+			for ; i < rows; i++ {
+				f, err := next()
+				if err != nil {
+					return nil, nil, err
+				}
+				if len(f) != 1 {
+					return nil, nil, fail("expected one value, got %d", len(f))
+				}
+				v, err := parseValue(f[0])
+				if err != nil {
+					return nil, nil, err
+				}
+				A.elements[i*A.step+j] = v
+				if i != j {
+					A.elements[j*A.step+i] = sign * v
+				}
+			}
+		}
+		return A, h, nil
+	}
+
+	nvals := 3
+	if h.Field == MMPattern {
+		nvals = 2
+	}
+	A := ZerosSparse(rows, cols)
+	add := func(i, j uint, v float64) {
+		index := i*cols + j
+		if s := A.elements[index] + v; s != 0 {
+			A.elements[index] = s
+		} else {
+			delete(A.elements, index)
+		}
+	}
+	for k := uint(0); k < size[2]; k++ {
+		f, err := next()
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(f) != nvals {
+			return nil, nil, fail("expected %d fields, got %d", nvals, len(f))
+		}
+		i, err := parseUint(f[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		j, err := parseUint(f[1])
+		if err != nil {
+			return nil, nil, err
+		}
+		if i == 0 || j == 0 || i > rows || j > cols {
+			return nil, nil, fail("index %d, %d out of range", i, j)
+		}
+		i, j = i-1, j-1
+		v := 1.0
+		if nvals == 3 {
+			if v, err = parseValue(f[2]); err != nil {
+				return nil, nil, err
+			}
+		}
+		switch {
+		case h.Symmetry == MMGeneral:
+		case i < j || (i == j && h.Symmetry == MMSkewSymmetric):
+			return nil, nil, fail("entry %d, %d above the stored triangle", i+1, j+1)
+		case i > j:
+			add(j, i, sign*v)
+		}
+		add(i, j, v)
+	}
+	return A, h, nil
+}
+
+/*
+Reads a Matrix Market file of either format into a SparseMatrix.
+*/
+func ReadMatrixMarketSparse(r io.Reader) (*SparseMatrix, error) {
+	A, _, err := ReadMatrixMarket(r)
+	if err != nil {
+		return nil, err
+	}
+	return A.SparseMatrix(), nil
+}
+
+/*
+Reads a Matrix Market file of either format into a DenseMatrix.
+*/
+func ReadMatrixMarketDense(r io.Reader) (*DenseMatrix, error) {
+	A, _, err := ReadMatrixMarket(r)
+	if err != nil {
+		return nil, err
+	}
+	return A.DenseMatrix(), nil
+}
+
+/*
+Writes A in the Matrix Market exchange format with the qualifiers in h. A
+nil h writes SparseMatrix, CSRMatrix and CSCMatrix in real general
+coordinate format and any other matrix in real general array format. Values
+are written with the fewest digits that read back exactly. Returns
+ErrorIllegalArgument if A is not symmetric or skew-symmetric as h claims, or
+has a value that is not an integer in an integer file.
+*/
+func WriteMatrixMarket(w io.Writer, A MatrixRO, h *MatrixMarketHeader) error {
+	if h == nil {
+		h = &MatrixMarketHeader{Format: MMArray, Field: MMReal, Symmetry: MMGeneral}
+		switch A.(type) {
+		case *SparseMatrix, *CSRMatrix, *CSCMatrix:
+			h.Format = MMCoordinate
+		}
+	}
+	if err := h.check(); err != nil {
+		return ErrorIllegalArgument
+	}
+	rows, cols := A.Rows(), A.Cols()
+	if h.Symmetry != MMGeneral && rows != cols {
+		return ErrorDimensionMismatch
+	}
+
+	// the stored elements by columns, triangle only if A is symmetric
+	C := MakeCSCCopy(A)
+	sign := 1.0
+	if h.Symmetry == MMSkewSymmetric {
+		sign = -1
+	}
+	for j := uint(0); j < cols; j++ {
+		for p := C.indptr[j]; p < C.indptr[j+1]; p++ {
+			i, v := C.indices[p], C.values[p]
+			if h.Symmetry != MMGeneral && C.at(i, j) != sign*v {
+				return ErrorIllegalArgument
+			}
+			if h.Symmetry == MMSkewSymmetric && i == j {
+				return ErrorIllegalArgument
+			}
+			if h.Field == MMInteger && (v != math.Trunc(v) || math.Abs(v) > 1<<53) {
+				return ErrorIllegalArgument
+			}
+		}
+	}
+	stored := func(i, j uint) bool {
+		switch h.Symmetry {
+		case MMSymmetric:
+			return i >= j
+		case MMSkewSymmetric:
+			return i > j
+		}
+		return true
+	}
+	format := func(v float64) string {
+		if h.Field == MMInteger {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%%%%MatrixMarket matrix %s %s %s\n", h.Format, h.Field, h.Symmetry)
+	if h.Format == MMArray {
+		fmt.Fprintf(bw, "%d %d\n", rows, cols)
+		var i, j uint
+		for j = 0; j < cols; j++ {
+			for i = 0; i < rows; i++ {
+				if stored(i, j) {
+					fmt.Fprintln(bw, format(C.at(j, i)))
+				}
+			}
+		}
+		return bw.Flush()
+	}
+
+	nnz := uint(0)
+	for j := uint(0); j < cols; j++ {
+		lo, hi := C.indptr[j], C.indptr[j+1]
+		rowsj := C.indices[lo:hi]
+		nnz += hi - lo - uint(sort.Search(len(rowsj), func(p int) bool { return stored(rowsj[p], j) }))
+	}
+	fmt.Fprintf(bw, "%d %d %d\n", rows, cols, nnz)
+	for j := uint(0); j < cols; j++ {
+		for p := C.indptr[j]; p < C.indptr[j+1]; p++ {
+			i := C.indices[p]
+			if !stored(i, j) {
+				continue
+			}
+			if h.Field == MMPattern {
+				fmt.Fprintf(bw, "%d %d\n", i+1, j+1)
+			} else {
+				fmt.Fprintf(bw, "%d %d %s\n", i+1, j+1, format(C.values[p]))
+			}
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,115 @@
+package math
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hezila/hezila/utils"
+)
+
+func TestReadMatrixMarket(t *testing.T) {
+	coo := `%%MatrixMarket matrix coordinate real symmetric
+% a comment
+3 3 4
+1 1 2.5
+2 1 -1
+3 2 4e-1
+
+3 3 1
+`
+	A, h, err := ReadMatrixMarket(strings.NewReader(coo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := A.(*SparseMatrix); !ok || h.Symmetry != MMSymmetric {
+		t.Errorf("%T %v", A, h)
+	}
+	Ar := MakeDenseMatrix([]float64{
+		2.5, -1, 0,
+		-1, 0, 0.4,
+		0, 0.4, 1}, 3, 3)
+	if !Equals(A, Ar) {
+		t.Errorf("A=%v", A)
+	}
+
+	arr := `%%MatrixMarket matrix array integer skew-symmetric
+3 3
+1
+2
+3
+`
+	D, err := ReadMatrixMarketDense(strings.NewReader(arr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	Dr := MakeDenseMatrix([]float64{
+		0, -1, -2,
+		1, 0, -3,
+		2, 3, 0}, 3, 3)
+	if !Equals(D, Dr) {
+		t.Errorf("D=%v", D)
+	}
+
+	pat := "%%MatrixMarket matrix coordinate pattern general\n2 3 2\n1 3\n2 1\n"
+	P, err := ReadMatrixMarketSparse(strings.NewReader(pat))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Equals(P, MakeDenseMatrix([]float64{0, 0, 1, 1, 0, 0}, 2, 3)) {
+		t.Errorf("P=%v", P)
+	}
+
+	for _, bad := range []string{
+		"",
+		"%%MatrixMarket matrix coordinate complex general\n1 1 1\n1 1 1 0\n",
+		"%%MatrixMarket matrix coordinate real general\n2 2 2\n1 1 1\n",
+		"%%MatrixMarket matrix coordinate real general\n2 2 1\n3 1 1\n",
+		"%%MatrixMarket matrix coordinate real symmetric\n2 2 1\n1 2 1\n",
+		"%%MatrixMarket matrix array integer general\n1 1\n1.5\n",
+	} {
+		_, _, err := ReadMatrixMarket(strings.NewReader(bad))
+		if _, ok := err.(*ParseError); !ok {
+			t.Errorf("%q: %v", bad, err)
+		}
+	}
+}
+
+func TestWriteMatrixMarket(t *testing.T) {
+	S := MakeDenseMatrix([]float64{
+		4, 1, 0,
+		1, 0.1, -2,
+		0, -2, 7}, 3, 3)
+
+	for _, h := range []*MatrixMarketHeader{
+		nil,
+		{MMCoordinate, MMReal, MMGeneral},
+		{MMCoordinate, MMReal, MMSymmetric},
+		{MMArray, MMReal, MMSymmetric},
+	} {
+		var buf bytes.Buffer
+		if err := WriteMatrixMarket(&buf, S, h); err != nil {
+			t.Fatal(err)
+		}
+		B, err := ReadMatrixMarketDense(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Equals(B, S) {
+			t.Errorf("%v: B=%v", h, B)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMatrixMarket(&buf, S.SparseMatrix(), &MatrixMarketHeader{MMCoordinate, MMPattern, MMSymmetric}); err != nil {
+		t.Fatal(err)
+	}
+	utils.Expect(t, "%%MatrixMarket matrix coordinate pattern symmetric\n3 3 5\n1 1\n2 1\n2 2\n3 2\n3 3\n", buf.String())
+
+	if err := WriteMatrixMarket(&buf, S, &MatrixMarketHeader{MMArray, MMInteger, MMGeneral}); err != ErrorIllegalArgument {
+		t.Errorf("expected %v, got %v", ErrorIllegalArgument, err)
+	}
+	if err := WriteMatrixMarket(&buf, S, &MatrixMarketHeader{MMArray, MMReal, MMSkewSymmetric}); err != ErrorIllegalArgument {
+		t.Errorf("expected %v, got %v", ErrorIllegalArgument, err)
+	}
+}
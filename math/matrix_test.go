@@ -25,3 +25,47 @@ func TestString(t *testing.T) {
 	s := "{1, 2, 3,\n 4, 5, 6}"
 	utils.Expect(t, s, String(A))
 }
+
+func TestCheckedAccess(t *testing.T) {
+	A := Zeros(2, 3)
+	if _, err := A.GetChecked(2, 0); err != ErrorIllegalIndex {
+		t.Errorf("GetChecked: %v", err)
+	}
+	if err := A.SetChecked(1, 2, 5); err != nil {
+		t.Fatal(err)
+	}
+	utils.Expect(t, "5", A.UncheckedGet(1, 2))
+
+	err := Try(func() { A.Set(0, 3, 1) })
+	if err != ErrorIllegalIndex {
+		t.Errorf("Set: %v", err)
+	}
+	err = Try(func() { A.RowSlice(2) })
+	if err != ErrorIllegalIndex {
+		t.Errorf("RowSlice: %v", err)
+	}
+	if Product(A, A) != nil {
+		t.Error("Product of mismatched matrices")
+	}
+
+	S := ZerosSparse(2, 2)
+	if _, err := S.Exist(1, 1); err != ErrorNilElement {
+		t.Errorf("Exist: %v", err)
+	}
+	if err := S.SetChecked(2, 0, 1); err != ErrorIllegalIndex {
+		t.Errorf("SetChecked: %v", err)
+	}
+	if _, err := S.SubMatrix(1, 0, 2, 1); err != ErrorIllegalIndex {
+		t.Errorf("SubMatrix: %v", err)
+	}
+
+	S = DiagonalSparse([]float64{1, 2})
+	S.Set(0, 1, 3)
+	S.ScaleAddRow(1, 0, 2)
+	if !Equals(S, MakeDenseMatrix([]float64{1, 3, 2, 8}, 2, 2)) || S.Symmetric() {
+		t.Errorf("S=%v", S)
+	}
+	if c, err := S.ColVector(1); err != nil || c.Get(1, 0) != 8 {
+		t.Errorf("ColVector: %v %v", c, err)
+	}
+}
@@ -0,0 +1,347 @@
+package math
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/*
+NumPy .npy files hold one array: a magic string, a version, a header that is
+a Python dict literal giving the dtype, the memory order and the shape, and
+the raw elements. float64 and float32 arrays of either byte order and in C
+or Fortran order are read. Arrays are written little endian in C order, as
+float32 for a DenseMatrix32 and float64 otherwise. A 2-D array maps to a
+DenseMatrix; a 1-D array to a Vector, or to a single row, and a 0-D one to a
+1×1 matrix. .npz files are zip archives of .npy files, one per array.
+*/
+
+const npyMagic = "\x93NUMPY"
+
+// An array as stored in a .npy file, with its elements in file order.
+type npyArray struct {
+	shape   []uint
+	fortran bool
+	data    []float64
+}
+
+func npyError(format string, args ...interface{}) error {
+	return &ParseError{Format: "npy", Msg: fmt.Sprintf(format, args...)}
+}
+
+// The value of key in the header dict, without its quotes if a string.
+func npyHeaderValue(header, key string) (string, bool) {
+	k := strings.Index(header, "'"+key+"'")
+	if k < 0 {
+		return "", false
+	}
+	rest := strings.TrimSpace(header[k+len(key)+2:])
+	if !strings.HasPrefix(rest, ":") {
+		return "", false
+	}
+	rest = strings.TrimSpace(rest[1:])
+	switch {
+	case strings.HasPrefix(rest, "'"):
+		if e := strings.IndexByte(rest[1:], '\''); e >= 0 {
+			return rest[1 : e+1], true
+		}
+	case strings.HasPrefix(rest, "("):
+		if e := strings.IndexByte(rest, ')'); e >= 0 {
+			return rest[1:e], true
+		}
+	default:
+		if e := strings.IndexAny(rest, ",}"); e >= 0 {
+			return strings.TrimSpace(rest[:e]), true
+		}
+	}
+	return "", false
+}
+
+func readNpyArray(r io.Reader) (*npyArray, error) {
+	pre := make([]byte, len(npyMagic)+2)
+	if _, err := io.ReadFull(r, pre); err != nil {
+		return nil, npyError("short file")
+	}
+	if string(pre[:len(npyMagic)]) != npyMagic {
+		return nil, npyError("not a .npy file")
+	}
+	var hlen uint32
+	switch major := pre[len(npyMagic)]; major {
+	case 1:
+		var n uint16
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return nil, npyError("short header")
+		}
+		hlen = uint32(n)
+	case 2, 3:
+		if err := binary.Read(r, binary.LittleEndian, &hlen); err != nil {
+			return nil, npyError("short header")
+		}
+	default:
+		return nil, npyError("unsupported version %d", major)
+	}
+	if hlen > 1<<20 {
+		return nil, npyError("header too long")
+	}
+	hb := make([]byte, hlen)
+	if _, err := io.ReadFull(r, hb); err != nil {
+		return nil, npyError("short header")
+	}
+	header := string(hb)
+
+	descr, ok := npyHeaderValue(header, "descr")
+	if !ok {
+		return nil, npyError("no descr in header")
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	size := 8
+	switch descr {
+	case "<f8", "=f8":
+	case ">f8":
+		order = binary.BigEndian
+	case "<f4", "=f4":
+		size = 4
+	case ">f4":
+		order, size = binary.BigEndian, 4
+	default:
+		return nil, npyError("unsupported dtype %q", descr)
+	}
+
+	a := new(npyArray)
+	switch fo, _ := npyHeaderValue(header, "fortran_order"); fo {
+	case "True":
+		a.fortran = true
+	case "False":
+	default:
+		return nil, npyError("bad fortran_order %q", fo)
+	}
+	shape, ok := npyHeaderValue(header, "shape")
+	if !ok {
+		return nil, npyError("no shape in header")
+	}
+	n := uint64(1)
+	for _, f := range strings.Split(shape, ",") {
+		if f = strings.TrimSpace(f); f == "" {
+			continue
+		}
+		d, err := strconv.ParseUint(strings.TrimSuffix(f, "L"), 10, 32)
+		if err != nil {
+			return nil, npyError("bad shape (%s)", shape)
+		}
+		a.shape = append(a.shape, uint(d))
+		if n *= d; n > 1<<40 {
+			return nil, npyError("array too large")
+		}
+	}
+	if len(a.shape) > 2 {
+		return nil, npyError("%d-D arrays are not supported", len(a.shape))
+	}
+
+	// in chunks, so that a bad shape fails at the end of the data rather
+	// than in one huge allocation
+	buf := make([]byte, 1<<16)
+	per := uint64(len(buf) / size)
+	for uint64(len(a.data)) < n {
+		k := minUInt(uint(per), uint(n-uint64(len(a.data))))
+		chunk := buf[:int(k)*size]
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return nil, npyError("short data: %d of %d elements", len(a.data), n)
+		}
+		for p := 0; p < len(chunk); p += size {
+			if size == 8 {
+				a.data = append(a.data, math.Float64frombits(order.Uint64(chunk[p:])))
+			} else {
+				a.data = append(a.data, float64(math.Float32frombits(order.Uint32(chunk[p:]))))
+			}
+		}
+	}
+	return a, nil
+}
+
+// The array as a matrix; a 1-D array is a single row.
+func (a *npyArray) dense() *DenseMatrix {
+	var rows, cols uint = 1, 1
+	switch len(a.shape) {
+	case 1:
+		cols = a.shape[0]
+	case 2:
+		rows, cols = a.shape[0], a.shape[1]
+	}
+	if !a.fortran || rows == 1 || cols == 1 {
+		return MakeDenseMatrix(a.data, rows, cols)
+	}
+	// Fortran order is by columns
+	return MakeDenseCopy(MakeDenseMatrix(a.data, cols, rows).T())
+}
+
+/*
+Reads a .npy file into a matrix. A 1-D array reads as a single row. Malformed
+input returns a *ParseError.
+*/
+func ReadNpy(r io.Reader) (*DenseMatrix, error) {
+	a, err := readNpyArray(r)
+	if err != nil {
+		return nil, err
+	}
+	return a.dense(), nil
+}
+
+/*
+Reads a .npy file into a dense vector. The array must be 1-D, or 2-D with a
+single row or column; otherwise the error is ErrorDimensionMismatch.
+*/
+func ReadNpyVector(r io.Reader) (*Vector, error) {
+	a, err := readNpyArray(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(a.shape) == 2 && a.shape[0] != 1 && a.shape[1] != 1 {
+		return nil, ErrorDimensionMismatch
+	}
+	return &Vector{values: a.data}, nil
+}
+
+// Writes the magic string, version and header, padded so that the data
+// starts at a multiple of 64 bytes.
+func writeNpyHeader(w io.Writer, descr string, shape []uint) error {
+	dims := make([]string, len(shape))
+	for k, d := range shape {
+		dims[k] = strconv.FormatUint(uint64(d), 10)
+	}
+	tuple := strings.Join(dims, ", ")
+	if len(shape) == 1 {
+		tuple += ","
+	}
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", descr, tuple)
+	// version 1.0 has a two byte header length
+	pad := 63 - (len(npyMagic)+4+len(header))%64
+	header += strings.Repeat(" ", pad) + "\n"
+
+	var b bytes.Buffer
+	b.WriteString(npyMagic)
+	b.Write([]byte{1, 0})
+	binary.Write(&b, binary.LittleEndian, uint16(len(header)))
+	b.WriteString(header)
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+/*
+Writes A as a 2-D .npy array, of float32 if A is a DenseMatrix32 and of
+float64 otherwise.
+*/
+func WriteNpy(w io.Writer, A MatrixRO) error {
+	bw := bufio.NewWriter(w)
+	var e [8]byte
+	if A32, ok := A.(*DenseMatrix32); ok {
+		if err := writeNpyHeader(bw, "<f4", []uint{A32.rows, A32.cols}); err != nil {
+			return err
+		}
+		for _, v := range A32.elements {
+			binary.LittleEndian.PutUint32(e[:], math.Float32bits(v))
+			bw.Write(e[:4])
+		}
+		return bw.Flush()
+	}
+
+	if err := writeNpyHeader(bw, "<f8", []uint{A.Rows(), A.Cols()}); err != nil {
+		return err
+	}
+	var i, j uint
+	for i = 0; i < A.Rows(); i++ {
+		for j = 0; j < A.Cols(); j++ {
+			binary.LittleEndian.PutUint64(e[:], math.Float64bits(A.Get(i, j)))
+			bw.Write(e[:])
+		}
+	}
+	return bw.Flush()
+}
+
+/*
+Writes v as a 1-D float64 .npy array. A sparse vector is written densely up
+to its last stored element.
+*/
+func WriteNpyVector(w io.Writer, v *Vector) error {
+	values := v.values
+	if v.isSparse {
+		n := 0
+		if k := len(v.indexes); k > 0 {
+			n = v.indexes[k-1] + 1
+		}
+		values = make([]float64, n)
+		for k, i := range v.indexes {
+			values[i] = v.values[k]
+		}
+	}
+	bw := bufio.NewWriter(w)
+	if err := writeNpyHeader(bw, "<f8", []uint{uint(len(values))}); err != nil {
+		return err
+	}
+	var e [8]byte
+	for _, x := range values {
+		binary.LittleEndian.PutUint64(e[:], math.Float64bits(x))
+		bw.Write(e[:])
+	}
+	return bw.Flush()
+}
+
+/*
+Reads the arrays of a .npz archive of the given size, by name without the
+.npy suffix. 1-D arrays read as single rows, see ReadNpy.
+*/
+func ReadNpz(r io.ReaderAt, size int64) (map[string]*DenseMatrix, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, &ParseError{Format: "npz", Msg: err.Error()}
+	}
+	arrays := make(map[string]*DenseMatrix)
+	for _, f := range z.File {
+		name := strings.TrimSuffix(f.Name, ".npy")
+		rc, err := f.Open()
+		if err != nil {
+			return nil, &ParseError{Format: "npz", Msg: name + ": " + err.Error()}
+		}
+		A, err := ReadNpy(rc)
+		rc.Close()
+		if err != nil {
+			if pe, ok := err.(*ParseError); ok {
+				err = &ParseError{Format: "npz", Msg: name + ": " + pe.Msg}
+			}
+			return nil, err
+		}
+		arrays[name] = A
+	}
+	return arrays, nil
+}
+
+/*
+Writes the arrays to a .npz archive, each as name.npy, see WriteNpy. The
+entries are stored uncompressed and in the order of their names, like
+numpy.savez.
+*/
+func WriteNpz(w io.Writer, arrays map[string]MatrixRO) error {
+	names := make([]string, 0, len(arrays))
+	for name := range arrays {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	z := zip.NewWriter(w)
+	for _, name := range names {
+		f, err := z.CreateHeader(&zip.FileHeader{Name: name + ".npy", Method: zip.Store})
+		if err != nil {
+			return err
+		}
+		if err := WriteNpy(f, arrays[name]); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
@@ -0,0 +1,121 @@
+package math
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/hezila/hezila/utils"
+)
+
+// A .npy file with the given header dict and raw data, as numpy writes it.
+func npyFile(header string, data interface{}, order binary.ByteOrder) []byte {
+	var b bytes.Buffer
+	b.WriteString("\x93NUMPY\x01\x00")
+	binary.Write(&b, binary.LittleEndian, uint16(len(header)+1))
+	b.WriteString(header + "\n")
+	binary.Write(&b, order, data)
+	return b.Bytes()
+}
+
+func TestReadNpy(t *testing.T) {
+	want := MakeDenseMatrix([]float64{1, 2, 3, 4, 5, 6}, 2, 3)
+
+	// C order, little endian float64
+	c := npyFile("{'descr': '<f8', 'fortran_order': False, 'shape': (2, 3), }",
+		[]float64{1, 2, 3, 4, 5, 6}, binary.LittleEndian)
+	// Fortran order, big endian float32
+	f := npyFile("{'descr': '>f4', 'fortran_order': True, 'shape': (2, 3), }",
+		[]float32{1, 4, 2, 5, 3, 6}, binary.BigEndian)
+	for _, b := range [][]byte{c, f} {
+		A, err := ReadNpy(bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !Equals(A, want) {
+			t.Errorf("A=%v", A)
+		}
+	}
+
+	v, err := ReadNpyVector(bytes.NewReader(npyFile("{'descr': '<f4', 'fortran_order': False, 'shape': (3,), }",
+		[]float32{1.5, -2, 0}, binary.LittleEndian)))
+	if err != nil || v.IsSparse() || v.NNZ() != 3 || v.Get(0) != 1.5 || v.Get(1) != -2 {
+		t.Errorf("v=%v %v", v, err)
+	}
+	if _, err := ReadNpyVector(bytes.NewReader(c)); err != ErrorDimensionMismatch {
+		t.Errorf("2x3 vector: %v", err)
+	}
+
+	bad := []string{
+		"not numpy at all",
+		string(npyFile("{'descr': '<i8', 'fortran_order': False, 'shape': (1,), }", []int64{1}, binary.LittleEndian)),
+		string(npyFile("{'descr': '<f8', 'fortran_order': False, 'shape': (2, 2, 2), }", make([]float64, 8), binary.LittleEndian)),
+		string(c[:len(c)-8]),
+	}
+	for _, s := range bad {
+		if _, err := ReadNpy(strings.NewReader(s)); err == nil {
+			t.Errorf("read %q", s)
+		} else if _, ok := err.(*ParseError); !ok {
+			t.Errorf("%T %v", err, err)
+		}
+	}
+}
+
+func TestWriteNpy(t *testing.T) {
+	A := MakeDenseMatrix([]float64{1, 2, 3, 4, 5, math.Inf(1)}, 3, 2)
+	var buf bytes.Buffer
+	if err := WriteNpy(&buf, A.T()); err != nil {
+		t.Fatal(err)
+	}
+	// the header numpy.save writes, the data aligned at 128 bytes
+	b := buf.Bytes()
+	utils.Expect(t, "128", len(b)-6*8)
+	utils.Expect(t, "{'descr': '<f8', 'fortran_order': False, 'shape': (2, 3), }",
+		strings.TrimRight(string(b[10:128]), " \n"))
+	B, err := ReadNpy(&buf)
+	if err != nil || !Equals(B, A.T()) {
+		t.Errorf("B=%v %v", B, err)
+	}
+
+	buf.Reset()
+	if err := WriteNpy(&buf, A.DenseMatrix32()); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 128+6*4 {
+		t.Errorf("float32 file of %d bytes", buf.Len())
+	}
+	if B, err := ReadNpy(&buf); err != nil || !Equals(B, A) {
+		t.Errorf("float32 B=%v %v", B, err)
+	}
+
+	buf.Reset()
+	v, _ := NewSparseVectorFrom([]int{3, 1}, []float64{2, 1})
+	WriteNpyVector(&buf, v)
+	w, err := ReadNpyVector(&buf)
+	if err != nil || w.NNZ() != 4 || w.Get(1) != 1 || w.Get(3) != 2 || w.Get(0) != 0 {
+		t.Errorf("w=%v %v", w, err)
+	}
+}
+
+func TestNpz(t *testing.T) {
+	arrays := map[string]MatrixRO{
+		"weights": Eye(3),
+		"bias":    MakeDenseMatrix([]float64{0.5, -1}, 1, 2).DenseMatrix32(),
+	}
+	var buf bytes.Buffer
+	if err := WriteNpz(&buf, arrays); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadNpz(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 2 || !Equals(read["weights"], Eye(3)) || !Equals(read["bias"], arrays["bias"]) {
+		t.Errorf("read %v", read)
+	}
+	if _, err := ReadNpz(bytes.NewReader([]byte("junk")), 4); err == nil {
+		t.Error("read junk")
+	}
+}
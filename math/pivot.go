@@ -27,20 +27,9 @@ func (P *PivotMatrix) Array() []float64 {
 }
 
 func (P *PivotMatrix) Get(i, j uint) (v float64) {
-	if i < 0 {
-		i = P.rows + i
-		if i < 0 {
-			log.Fatal("index out of bound!")
-		}
+	if i >= P.rows || j >= P.cols {
+		panic(ErrorIllegalIndex)
 	}
-
-	if j < 0 {
-		j = P.cols + j
-		if j < 0 {
-			log.Fatal("index out of bound!")
-		}
-	}
-
 	if P.pivots[j] == i {
 		v = 1
 	}
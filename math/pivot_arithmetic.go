@@ -67,11 +67,9 @@ func (P *PivotMatrix) RowPivotDense(A *DenseMatrix) (*DenseMatrix, error) {
 	var si uint
 	for si = 0; si < A.rows; si++ {
 		di := P.pivots[si]
-		Astart := si * A.step
-		Bstart := di * B.step
 		var j uint
 		for j = 0; j < A.cols; j++ {
-			B.elements[Bstart+j] = A.elements[Astart+j]
+			B.elements[B.index(di, j)] = A.elements[A.index(si, j)]
 		}
 	}
 	return B, nil
@@ -87,12 +85,10 @@ func (P *PivotMatrix) ColPivotDense(A *DenseMatrix) (*DenseMatrix, error) {
 	B := Zeros(A.rows, A.cols)
 	var i uint
 	for i = 0; i < B.rows; i++ {
-		Astart := i * A.step
-		Bstart := i * B.step
 		var sj uint
 		for sj = 0; sj < B.cols; sj++ {
 			dj := P.pivots[sj]
-			B.elements[Bstart+dj] = A.elements[Astart+sj]
+			B.elements[B.index(i, dj)] = A.elements[A.index(i, sj)]
 		}
 	}
 	return B, nil
@@ -107,7 +103,7 @@ func (P *PivotMatrix) RowPivotSparse(A *SparseMatrix) (*SparseMatrix, error) {
 	}
 	B := ZerosSparse(A.rows, A.cols)
 	for index, value := range A.elements {
-		si, j := A.GetRowColIndex(index)
+		si, j, _ := A.GetRowColIndex(index)
 		di := P.pivots[si]
 		B.Set(di, j, value)
 	}
@@ -124,7 +120,7 @@ func (P *PivotMatrix) ColPivotSparse(A *SparseMatrix) (*SparseMatrix, error) {
 	}
 	B := ZerosSparse(A.rows, A.cols)
 	for index, value := range A.elements {
-		i, sj := A.GetRowColIndex(index)
+		i, sj, _ := A.GetRowColIndex(index)
 		dj := P.pivots[sj]
 		B.Set(i, dj, value)
 	}
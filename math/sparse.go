@@ -1,12 +1,7 @@
 package math
 
 import (
-	"fmt"
 	"math/rand"
-
-	"hezila/utils"
-
-	log "github.com/golang/glog"
 )
 
 // A sparse matrix with indexing all of its elements by a map
@@ -47,13 +42,13 @@ func MakeSparseMatrix(elements map[uint]float64, rows, cols uint) *SparseMatrix
 
 func (M *SparseMatrix) Arrays() [][]float64 {
 	a := make([][]float64, M.rows)
-	var i, j uint
+	var i uint
 	for i = 0; i < M.rows; i++ {
 		a[i] = make([]float64, M.cols)
 	}
 	for index, value := range M.elements {
 		i, j, err := M.GetRowColIndex(index)
-		if err != nil {
+		if err == nil {
 			a[i][j] = value
 		}
 	}
@@ -68,127 +63,105 @@ func (M *SparseMatrix) Array() []float64 {
 	return a
 }
 
+/*
+Splits an element index into its row and column. Returns ErrorIllegalIndex
+if the index lies outside the matrix.
+*/
 func (M *SparseMatrix) GetRowColIndex(index uint) (i, j uint, err error) {
-	if index < 0 || i > M.rows*M.cols-1 {
-		err := ErrorIllegalIndex
+	if index < M.offset || M.step == 0 {
+		err = ErrorIllegalIndex
+		return
 	}
 	i = (index - M.offset) / M.step
 	j = (index - M.offset) % M.step
+	if i >= M.rows || j >= M.cols {
+		err = ErrorIllegalIndex
+	}
 	return
 }
 
 func (M *SparseMatrix) GetRowIndex(index uint) (i uint, err error) {
-	if index < 0 || i > M.rows*M.cols-1 {
-		err := ErrorIllegalIndex
-	}
-	i = (index - M.offset) / M.step
+	i, _, err = M.GetRowColIndex(index)
 	return
 }
 
 func (M *SparseMatrix) GetColIndex(index uint) (j uint, err error) {
-	if index < 0 || i > M.rows*M.cols-1 {
-		err := ErrorIllegalIndex
-	}
-	j = (index - M.offset) % M.step
+	_, j, err = M.GetRowColIndex(index)
 	return
 }
 
+/*
+Returns the element at i, j, zero if it is not stored. Panics with
+ErrorIllegalIndex if the index is out of bounds; use Exist to get the error
+instead.
+*/
 func (M *SparseMatrix) Get(i, j uint) float64 {
-	if i < 0 {
-		i = M.rows + i
-		if i < 0 {
-			log.Fatal("index out of bound!")
-		}
-	}
-
-	if j < 0 {
-		j = M.cols + j
-		if j < 0 {
-			log.Fatal("index out of bound!")
-		}
-	}
-
 	if i >= M.rows || j >= M.cols {
-		log.Fatal("index out of bound!")
+		panic(ErrorIllegalIndex)
 	}
-
-	v, err = M.elements[i*M.step+j+M.offset]
-	if err != nil {
-		log.Warning("the element indexed does not exists!")
-	}
-	return v
+	return M.elements[i*M.step+j+M.offset]
 }
 
+/*
+Returns the element at i, j. The error is ErrorIllegalIndex if the index is
+out of bounds and ErrorNilElement if the element is not stored.
+*/
 func (M *SparseMatrix) Exist(i, j uint) (v float64, err error) {
-	if i < 0 {
-		i = M.rows + i
-		if i < 0 {
-			err = log.Fatal("index out of bound!")
-		}
-	}
-
-	if j < 0 {
-		j = M.cols + j
-		if j < 0 {
-			err = log.Fatal("index out of bound!")
-		}
-	}
-
 	if i >= M.rows || j >= M.cols {
-		err = log.Fatal("index out of bound!")
+		return 0, ErrorIllegalIndex
+	}
+	v, ok := M.elements[i*M.step+j+M.offset]
+	if !ok {
+		err = ErrorNilElement
 	}
-
-	v, err = M.elements[i*M.step+j+M.offset]
 	return
 }
 
 // Looks up an element given its element index
 func (M *SparseMatrix) GetValue(index uint) (v float64, err error) {
-	v, err = M.elements[index]
+	v, ok := M.elements[index]
+	if !ok {
+		err = ErrorNilElement
+	}
 	return
 }
 
+/*
+Sets the element at i, j; setting zero removes it. Panics with
+ErrorIllegalIndex if the index is out of bounds; use SetChecked to get the
+error instead.
+*/
 func (M *SparseMatrix) Set(i, j uint, v float64) {
-	//i = i % M.rows
-	if i < 0 {
-		i = M.rows + i
-		if i < 0 {
-			log.Fatal("index out of bound!")
-		}
+	if err := M.SetChecked(i, j, v); err != nil {
+		panic(err)
 	}
+}
 
-	//j = j % M.cols
-	if j < 0 {
-		j = M.cols + j
-		if j < 0 {
-			log.Fatal("index out of bound!")
-		}
+// Like Set, but returns ErrorIllegalIndex rather than panicking.
+func (M *SparseMatrix) SetChecked(i, j uint, v float64) error {
+	if i >= M.rows || j >= M.cols {
+		return ErrorIllegalIndex
 	}
-
-	index = i*M.step + j + M.offset
+	index := i*M.step + j + M.offset
 	if v == 0 {
 		delete(M.elements, index)
 	} else {
 		M.elements[index] = v
 	}
+	return nil
 }
 
+// Sets an element given its element index, see SetChecked.
 func (M *SparseMatrix) SetValue(index uint, v float64) error {
-	i, j, err := GetRowIndex(index)
-	if err != nil {
-		log.Fatal("index error!")
-	}
-	if i > M.rows-1 || j > M.cols-1 {
-		err := ErrorIllegalIndex
-		log.Fatal("index error!")
+	if _, _, err := M.GetRowColIndex(index); err != nil {
+		return err
 	}
-
 	if v == 0 {
 		delete(M.elements, index)
 	} else {
 		M.elements[index] = v
 	}
-	return err
+	return nil
 }
 
 func (M *SparseMatrix) Indices() (out chan uint) {
@@ -196,8 +169,7 @@ func (M *SparseMatrix) Indices() (out chan uint) {
 	out = make(chan uint)
 	go func(o chan uint) {
 		for index := range M.elements {
-			i, j := M.GetRowColIndex(index)
-			if i >= 0 && i < M.rows && j >= 0 && j < M.cols {
+			if _, _, err := M.GetRowColIndex(index); err == nil {
 				o <- index
 			}
 		}
@@ -207,15 +179,15 @@ func (M *SparseMatrix) Indices() (out chan uint) {
 }
 
 func (M *SparseMatrix) SubMatrix(i, j, rows, cols uint) (S *SparseMatrix, err error) {
-	if i < 0 || j < 0 || rows <= 0 || cols <= 0 ||
-		(i+rows) > M.rows || (j+cols) > M.cols {
+	if rows == 0 || cols == 0 || (i+rows) > M.rows || (j+cols) > M.cols {
 		err = ErrorIllegalIndex
+		return
 	}
 
 	S = ZerosSparse(rows, cols)
 	for r := uint(0); r < rows; r++ {
 		for c := uint(0); c < cols; c++ {
-			index = (i+r)*M.step + (j + c) + M.offset
+			index := (i+r)*M.step + (j + c) + M.offset
 			if val, ok := M.elements[index]; ok {
 				S.Set(r, c, val)
 			}
@@ -224,11 +196,11 @@ func (M *SparseMatrix) SubMatrix(i, j, rows, cols uint) (S *SparseMatrix, err er
 	return
 }
 
-func (M *SparseMatrix) ColVector(j uint) *SparseMatrix {
+func (M *SparseMatrix) ColVector(j uint) (*SparseMatrix, error) {
 	return M.SubMatrix(0, j, M.rows, 1)
 }
 
-func (M *SparseMatrix) RowVector(i uint) *SparseMatrix {
+func (M *SparseMatrix) RowVector(i uint) (*SparseMatrix, error) {
 	return M.SubMatrix(i, 0, 1, M.cols)
 }
 
@@ -242,12 +214,12 @@ func (A *SparseMatrix) Augment(B *SparseMatrix) (S *SparseMatrix, err error) {
 	S = ZerosSparse(A.rows, A.cols+B.cols)
 
 	for index, value := range A.elements {
-		i, j := A.GetRowColIndex(index)
+		i, j, _ := A.GetRowColIndex(index)
 		S.Set(i, j, value)
 	}
 
 	for index, value := range B.elements {
-		i, j := B.GetRowColIndex(index)
+		i, j, _ := B.GetRowColIndex(index)
 		S.Set(i, j+A.cols, value)
 	}
 
@@ -263,12 +235,12 @@ func (A *SparseMatrix) Stack(B *SparseMatrix) (S *SparseMatrix, err error) {
 	S = ZerosSparse(A.rows+B.rows, A.cols)
 
 	for index, value := range A.elements {
-		i, j := A.GetRowColIndex(index)
+		i, j, _ := A.GetRowColIndex(index)
 		S.Set(i, j, value)
 	}
 
 	for index, value := range B.elements {
-		i, j := B.GetRowColIndex(index)
+		i, j, _ := B.GetRowColIndex(index)
 		S.Set(i+A.rows, j, value)
 	}
 
@@ -278,7 +250,7 @@ func (A *SparseMatrix) Stack(B *SparseMatrix) (S *SparseMatrix, err error) {
 func (M *SparseMatrix) L() *SparseMatrix {
 	B := ZerosSparse(M.rows, M.cols)
 	for index, value := range M.elements {
-		i, j := M.GetRowColIndex(index)
+		i, j, _ := M.GetRowColIndex(index)
 		if i >= j {
 			B.Set(i, j, value)
 		}
@@ -289,7 +261,7 @@ func (M *SparseMatrix) L() *SparseMatrix {
 func (M *SparseMatrix) U() *SparseMatrix {
 	U := ZerosSparse(M.rows, M.cols)
 	for index, value := range M.elements {
-		i, j := M.GetRowColIndex(index)
+		i, j, _ := M.GetRowColIndex(index)
 		if i <= j {
 			U.Set(i, j, value)
 		}
@@ -312,10 +284,6 @@ func ZerosSparse(rows, cols uint) *SparseMatrix {
 	M.offset = 0
 	M.step = cols
 	M.elements = map[uint]float64{}
-	var i uint
-	for i = 0; i < rows*cols; i++ {
-		M.elements[i] = 0
-	}
 	return M
 }
 
@@ -351,7 +319,7 @@ func NormalsSparse(rows, cols uint) *SparseMatrix {
 	return N
 }
 
-func Diagonal(d []float64) *SparseMatrix {
+func DiagonalSparse(d []float64) *SparseMatrix {
 	n := uint(len(d))
 	D := ZerosSparse(n, n)
 	for i := uint(0); i < n; i++ {
@@ -366,7 +334,7 @@ Convert this sparse matrix into a dense matrix.
 func (A *SparseMatrix) DenseMatrix() *DenseMatrix {
 	B := Zeros(A.rows, A.cols)
 	for index, value := range A.elements {
-		i, j := A.GetRowColIndex(index)
+		i, j, _ := A.GetRowColIndex(index)
 		B.Set(i, j, value)
 	}
 	return B
@@ -376,6 +344,12 @@ func (A *SparseMatrix) SparseMatrix() *SparseMatrix {
 	return A.Copy()
 }
 
+// Convert this sparse matrix into compressed sparse row form.
+func (A *SparseMatrix) CSR() *CSRMatrix { return MakeCSRCopy(A) }
+
+// Convert this sparse matrix into compressed sparse column form.
+func (A *SparseMatrix) CSC() *CSCMatrix { return MakeCSCCopy(A) }
+
 func MakeSparseCopy(M MatrixRO) *SparseMatrix {
 	A := ZerosSparse(M.Rows(), M.Cols())
 
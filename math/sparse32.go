@@ -0,0 +1,143 @@
+package math
+
+/*
+A sparse matrix of float32 elements, indexed by a map like SparseMatrix.
+Get and Set convert to and from float64; see DenseMatrix32.
+*/
+type SparseMatrix32 struct {
+	matrix
+
+	// flatted elements, idx = i * cols + j
+	elements map[uint]float32
+}
+
+func ZerosSparse32(rows, cols uint) *SparseMatrix32 {
+	M := new(SparseMatrix32)
+	M.rows = rows
+	M.cols = cols
+	M.elements = make(map[uint]float32)
+	return M
+}
+
+/*
+Returns M rounded to float32. Elements that round to zero are dropped.
+*/
+func MakeSparseCopy32(M MatrixRO) *SparseMatrix32 {
+	A := ZerosSparse32(M.Rows(), M.Cols())
+	switch Mm := M.(type) {
+	case *SparseMatrix32:
+		for index, v := range Mm.elements {
+			A.elements[index] = v
+		}
+	case *DenseMatrix32:
+		for p, v := range Mm.elements {
+			if v != 0 {
+				A.elements[uint(p)] = v
+			}
+		}
+	default:
+		is, js, vs := triplets(M)
+		for p, v := range vs {
+			A.Set(is[p], js[p], v)
+		}
+	}
+	return A
+}
+
+/*
+Returns this matrix rounded to float32.
+*/
+func (A *SparseMatrix) SparseMatrix32() *SparseMatrix32 { return MakeSparseCopy32(A) }
+
+func (M *SparseMatrix32) Get32(i, j uint) float32 {
+	if i >= M.rows || j >= M.cols {
+		panic(ErrorIllegalIndex)
+	}
+	return M.elements[i*M.cols+j]
+}
+
+/*
+Sets the element at i, j; setting zero removes it.
+*/
+func (M *SparseMatrix32) Set32(i, j uint, v float32) {
+	if i >= M.rows || j >= M.cols {
+		panic(ErrorIllegalIndex)
+	}
+	if v == 0 {
+		delete(M.elements, i*M.cols+j)
+	} else {
+		M.elements[i*M.cols+j] = v
+	}
+}
+
+func (M *SparseMatrix32) Get(i, j uint) float64 { return float64(M.Get32(i, j)) }
+
+func (M *SparseMatrix32) Set(i, j uint, v float64) { M.Set32(i, j, float32(v)) }
+
+// The number of stored elements
+func (M *SparseMatrix32) NNZ() int { return len(M.elements) }
+
+func (M *SparseMatrix32) Arrays() [][]float64 { return M.DenseMatrix().Arrays() }
+
+func (M *SparseMatrix32) Array() []float64 { return M.DenseMatrix().elements }
+
+func (M *SparseMatrix32) Det() float64 { return M.DenseMatrix().Det() }
+
+func (M *SparseMatrix32) Trace() (res float64) {
+	for i := uint(0); i < minUInt(M.rows, M.cols); i++ {
+		res += float64(M.elements[i*M.cols+i])
+	}
+	return
+}
+
+func (M *SparseMatrix32) Copy() *SparseMatrix32 { return MakeSparseCopy32(M) }
+
+func (A *SparseMatrix32) Add(B MatrixRO) error { return A.addScaled(1, B) }
+
+func (A *SparseMatrix32) Subtract(B MatrixRO) error { return A.addScaled(-1, B) }
+
+func (A *SparseMatrix32) addScaled(alpha float64, B MatrixRO) error {
+	if A.rows != B.Rows() || A.cols != B.Cols() {
+		return ErrorDimensionMismatch
+	}
+	is, js, vs := triplets(B)
+	for p, v := range vs {
+		i, j := is[p], js[p]
+		A.Set(i, j, float64(A.elements[i*A.cols+j])+alpha*v)
+	}
+	return nil
+}
+
+func (A *SparseMatrix32) Scale(f float64) {
+	for index, v := range A.elements {
+		if s := float32(float64(v) * f); s != 0 {
+			A.elements[index] = s
+		} else {
+			delete(A.elements, index)
+		}
+	}
+}
+
+/*
+Returns the elements in float64.
+*/
+func (A *SparseMatrix32) SparseMatrix() *SparseMatrix {
+	B := ZerosSparse(A.rows, A.cols)
+	for index, v := range A.elements {
+		B.elements[index] = float64(v)
+	}
+	return B
+}
+
+func (A *SparseMatrix32) DenseMatrix() *DenseMatrix {
+	B := Zeros(A.rows, A.cols)
+	for index, v := range A.elements {
+		B.elements[index] = float64(v)
+	}
+	return B
+}
+
+// Convert this sparse matrix into compressed sparse row form, in float64.
+func (A *SparseMatrix32) CSR() *CSRMatrix { return MakeCSRCopy(A) }
+
+func (A *SparseMatrix32) String() string { return String(A) }
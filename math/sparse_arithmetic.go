@@ -66,7 +66,7 @@ func (A *SparseMatrix) AddSparse(B *SparseMatrix) error {
 	}
 
 	for index, value := range B.elements {
-		i, j := A.GetRowColIndex(index)
+		i, j, _ := A.GetRowColIndex(index)
 		A.Set(i, j, A.Get(i, j)+value)
 	}
 
@@ -103,7 +103,7 @@ func (A *SparseMatrix) SubtractSparse(B *SparseMatrix) error {
 	}
 
 	for index, value := range B.elements {
-		i, j := A.GetRowColIndex(index)
+		i, j, _ := A.GetRowColIndex(index)
 		A.Set(i, j, A.Get(i, j)-value)
 	}
 
@@ -114,11 +114,9 @@ func (A *SparseMatrix) SubtractSparse(B *SparseMatrix) error {
 Get the product of this matrix and another.
 */
 func (A *SparseMatrix) Times(B MatrixRO) (Matrix, error) {
-	/* uncomment this if an efficient version is written
 	if Bs, ok := B.(*SparseMatrix); ok {
-		return A.TimesSparse(Bs);
+		return A.TimesSparse(Bs)
 	}
-	*/
 
 	if A.cols != B.Rows() {
 		return nil, ErrorDimensionMismatch
@@ -127,7 +125,7 @@ func (A *SparseMatrix) Times(B MatrixRO) (Matrix, error) {
 	C := ZerosSparse(A.rows, B.Cols())
 
 	for index, value := range A.elements {
-		i, k := A.GetRowColIndex(index)
+		i, k, _ := A.GetRowColIndex(index)
 		//not sure if there is a more efficient way to do this without using
 		//a different data structure
 		var j uint
@@ -143,29 +141,15 @@ func (A *SparseMatrix) Times(B MatrixRO) (Matrix, error) {
 }
 
 /*
-Get the product of this matrix and another, optimized for sparsity.
+Get the product of this matrix and another, optimized for sparsity. The
+product is computed in compressed sparse row form, see CSRMatrix.TimesCSR.
 */
 func (A *SparseMatrix) TimesSparse(B *SparseMatrix) (*SparseMatrix, error) {
-	if A.cols != B.Rows() {
-		return nil, ErrorDimensionMismatch
-	}
-
-	C := ZerosSparse(A.rows, B.Cols())
-
-	for index, value := range A.elements {
-		i, k := A.GetRowColIndex(index)
-		//not sure if there is a more efficient way to do this without using
-		//a different data structure
-		var j uint
-		for j = 0; j < B.Cols(); j++ {
-			v := B.Get(k, j)
-			if v != 0 {
-				C.Set(i, j, C.Get(i, j)+value*v)
-			}
-		}
+	C, err := A.CSR().TimesCSR(B.CSR())
+	if err != nil {
+		return nil, err
 	}
-
-	return C, nil
+	return C.SparseMatrix(), nil
 }
 
 /*
@@ -204,7 +188,7 @@ func (A *SparseMatrix) ScaleMatrix(B MatrixRO) error {
 	}
 
 	for index, value := range A.elements {
-		i, j := A.GetRowColIndex(index)
+		i, j, _ := A.GetRowColIndex(index)
 		A.Set(i, j, value*B.Get(i, j))
 	}
 
@@ -221,7 +205,7 @@ func (A *SparseMatrix) ScaleMatrixSparse(B *SparseMatrix) error {
 		}
 
 		for index, value := range A.elements {
-			i, j := A.GetRowColIndex(index)
+			i, j, _ := A.GetRowColIndex(index)
 			A.Set(i, j, value*B.Get(i, j))
 		}
 	}
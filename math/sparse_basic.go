@@ -1,6 +1,12 @@
 package math
 
-import "math"
+import (
+	"math"
+	"math/rand"
+)
+
+// upper bound on the power iterations in SparseMatrix.TwoNorm
+const sparseNormMaxIter = 1000
 
 /*
 Swap two rows in this matrix.
@@ -8,7 +14,7 @@ Swap two rows in this matrix.
 func (A *SparseMatrix) SwapRows(r1, r2 uint) {
 	js := map[uint]bool{}
 	for index := range A.elements {
-		i, j := A.GetRowColIndex(index)
+		i, j, _ := A.GetRowColIndex(index)
 		if i == r1 || i == r2 {
 			js[j] = true
 		}
@@ -25,7 +31,7 @@ Scale a row by a scalar.
 */
 func (A *SparseMatrix) ScaleRow(r uint, f float64) {
 	for index, value := range A.elements {
-		i, j := A.GetRowColIndex(index)
+		i, j, _ := A.GetRowColIndex(index)
 		if i == r {
 			A.Set(i, j, value*f)
 		}
@@ -36,27 +42,20 @@ func (A *SparseMatrix) ScaleRow(r uint, f float64) {
 Add a multiple of row rs to row rd.
 */
 func (A *SparseMatrix) ScaleAddRow(rd, rs uint, f float64) {
-	//	for index, value := range A.elements {
-	//		i, j := A.GetRowColIndex(index)
-	//		if i == rs {
-	//			A.Set(rd, j, A.Get(rd, j)+value*f)
-	//		}
-	//	}
-
 	for j := uint(0); j < A.cols; j++ {
-		if val, ok := A.Get(rs, j); ok {
-			if old, okd := A.Get(rdd, j); okd {
-				val += old
-			}
-			A.Set(rd, j, val)
+		if v := A.Get(rs, j); v != 0 {
+			A.Set(rd, j, A.Get(rd, j)+f*v)
 		}
 	}
 }
 
 func (A *SparseMatrix) Symmetric() bool {
+	if A.rows != A.cols {
+		return false
+	}
 	for index, value := range A.elements {
-		if i, j := A.GetRowColIndex(index); i != j {
-			if val, ok := A.Get(j, i); ok != nil || value != val {
+		if i, j, _ := A.GetRowColIndex(index); i != j {
+			if value != A.Get(j, i) {
 				return false
 			}
 		}
@@ -67,7 +66,7 @@ func (A *SparseMatrix) Symmetric() bool {
 func (A *SparseMatrix) Transpose() *SparseMatrix {
 	B := ZerosSparse(A.cols, A.rows)
 	for index, value := range A.elements {
-		i, j := A.GetRowColIndex(index)
+		i, j, _ := A.GetRowColIndex(index)
 		B.Set(j, i, value)
 	}
 	return B
@@ -80,7 +79,7 @@ func (A *SparseMatrix) Det() float64 {
 
 func (A *SparseMatrix) Trace() (res float64) {
 	for index, value := range A.elements {
-		i, j := A.GetRowColIndex(index)
+		i, j, _ := A.GetRowColIndex(index)
 		if i == j {
 			res += value
 		}
@@ -95,7 +94,61 @@ func (A *SparseMatrix) OneNorm() (res float64) {
 	return
 }
 
+/*
+The spectral norm, estimated by power iteration on A'A.
+*/
 func (A *SparseMatrix) TwoNorm() float64 {
+	if len(A.elements) == 0 {
+		return 0
+	}
+
+	// a random start is almost surely not orthogonal to the top singular vector
+	rng := rand.New(rand.NewSource(1))
+	x := make([]float64, A.cols)
+	for j := range x {
+		x[j] = rng.NormFloat64()
+	}
+	y := make([]float64, A.rows)
+
+	var σ float64
+	for iter := 0; iter < sparseNormMaxIter; iter++ {
+		nx := norm2(x)
+		if nx == 0 {
+			return 0
+		}
+		for j := range x {
+			x[j] /= nx
+		}
+
+		for i := range y {
+			y[i] = 0
+		}
+		for index, value := range A.elements {
+			i, j, _ := A.GetRowColIndex(index)
+			y[i] += value * x[j]
+		}
+		for j := range x {
+			x[j] = 0
+		}
+		for index, value := range A.elements {
+			i, j, _ := A.GetRowColIndex(index)
+			x[j] += value * y[i]
+		}
+
+		// ||A'Ax|| converges to the square of the largest singular value
+		s := math.Sqrt(norm2(x))
+		if math.Abs(s-σ) <= 1e-12*s {
+			return s
+		}
+		σ = s
+	}
+	return σ
+}
+
+/*
+The Frobenius norm, the square root of the sum of squared elements.
+*/
+func (A *SparseMatrix) FrobeniusNorm() float64 {
 	var sum float64 = 0
 	for _, value := range A.elements {
 		sum += value * value
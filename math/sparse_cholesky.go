@@ -0,0 +1,297 @@
+package math
+
+import "math"
+
+/*
+The sparse Cholesky factorization PAP' = LL' of a symmetric positive definite
+matrix, where P is a fill-reducing permutation. The factor can be reused to
+solve against the same matrix many times.
+*/
+type SparseCholesky struct {
+	// lower triangular factor by columns, the diagonal first in each
+	l *CSCMatrix
+
+	// row and column k of PAP' are row and column perm[k] of A
+	perm []uint
+}
+
+/*
+Computes the sparse Cholesky factorization of A with the given ordering.
+Only the lower triangle of A is read, so A is assumed to be symmetric.
+Returns ExceptionNotSPD if A is not positive definite.
+*/
+func (A *SparseMatrix) Cholesky(order Ordering) (*SparseCholesky, error) {
+	return A.CSC().Cholesky(order)
+}
+
+/*
+Computes the sparse Cholesky factorization of A, see SparseMatrix.Cholesky.
+*/
+func (A *CSCMatrix) Cholesky(order Ordering) (*SparseCholesky, error) {
+	if A.rows != A.cols {
+		return nil, ErrorDimensionMismatch
+	}
+	perm, err := order.Permutation(A)
+	if err != nil {
+		return nil, err
+	}
+	n := A.rows
+	pinv := invertPermutation(perm)
+
+	// the upper triangle of C = PAP' by columns, from the lower triangle of A
+	var is, js []uint
+	var vs []float64
+	for j := uint(0); j < n; j++ {
+		for p := A.indptr[j]; p < A.indptr[j+1]; p++ {
+			if i := A.indices[p]; i >= j {
+				pi, pj := pinv[i], pinv[j]
+				is = append(is, minUInt(pi, pj))
+				js = append(js, maxUInt(pi, pj))
+				vs = append(vs, A.values[p])
+			}
+		}
+	}
+	C := compressTriplets(n, js, is, vs)
+
+	parent := eliminationTree(&C)
+
+	// Row k of L is the reach of column k of C in the elimination tree, so
+	// one symbolic pass counts the entries of every column of L.
+	stack := make([]uint, n)
+	mark := make([]uint, n)
+	count := make([]uint, n+1)
+	for k := uint(0); k < n; k++ {
+		top := ereach(&C, k, parent, stack, mark)
+		for _, i := range stack[top:] {
+			count[i+1]++
+		}
+		count[k+1]++
+	}
+	for k := uint(0); k < n; k++ {
+		count[k+1] += count[k]
+	}
+
+	// Up-looking factorization: row k of L solves a triangular system with
+	// the rows above it, and its entries are appended to their columns.
+	L := new(CSCMatrix)
+	L.rows, L.cols = n, n
+	L.indptr = count
+	L.indices = make([]uint, count[n])
+	L.values = make([]float64, count[n])
+	next := append([]uint(nil), count[:n]...)
+	x := make([]float64, n)
+	for k := range mark {
+		mark[k] = 0
+	}
+	for k := uint(0); k < n; k++ {
+		top := ereach(&C, k, parent, stack, mark)
+		for p := C.indptr[k]; p < C.indptr[k+1]; p++ {
+			x[C.indices[p]] = C.values[p]
+		}
+		d := x[k]
+		x[k] = 0
+		for _, i := range stack[top:] {
+			lki := x[i] / L.values[L.indptr[i]]
+			x[i] = 0
+			for p := L.indptr[i] + 1; p < next[i]; p++ {
+				x[L.indices[p]] -= L.values[p] * lki
+			}
+			d -= lki * lki
+			L.indices[next[i]] = k
+			L.values[next[i]] = lki
+			next[i]++
+		}
+		// the negated test also catches NaN
+		if !(d > 0) {
+			return nil, ExceptionNotSPD
+		}
+		L.indices[next[k]] = k
+		L.values[next[k]] = math.Sqrt(d)
+		next[k]++
+	}
+
+	return &SparseCholesky{l: L, perm: perm}, nil
+}
+
+/*
+The elimination tree of the matrix whose upper triangle is stored by columns
+in C: parent[k] is the parent of node k, or n for a root.
+*/
+func eliminationTree(C *compressed) []uint {
+	n := uint(len(C.indptr) - 1)
+	parent := make([]uint, n)
+	ancestor := make([]uint, n)
+	for k := uint(0); k < n; k++ {
+		parent[k] = n
+		ancestor[k] = n
+		for p := C.indptr[k]; p < C.indptr[k+1]; p++ {
+			// climb from i to the root of its subtree, compressing the path
+			for i := C.indices[p]; i < k; {
+				inext := ancestor[i]
+				ancestor[i] = k
+				if inext == n {
+					parent[i] = k
+				}
+				i = inext
+			}
+		}
+	}
+	return parent
+}
+
+/*
+The pattern of row k of the Cholesky factor, found by walking up the
+elimination tree from every non-zero of column k of C. The pattern is left
+in stack[top:] in topological order. mark must hold no value above k when
+called and holds k+1 for every node visited afterwards.
+*/
+func ereach(C *compressed, k uint, parent, stack, mark []uint) (top uint) {
+	n := uint(len(parent))
+	top = n
+	mark[k] = k + 1
+	for p := C.indptr[k]; p < C.indptr[k+1]; p++ {
+		i := C.indices[p]
+		if i > k {
+			continue
+		}
+		// push the path up to a marked node, then move it to the stack
+		length := uint(0)
+		for ; mark[i] != k+1; i = parent[i] {
+			stack[length] = i
+			length++
+			mark[i] = k + 1
+		}
+		for length > 0 {
+			length--
+			top--
+			stack[top] = stack[length]
+		}
+	}
+	return top
+}
+
+/*
+Returns a copy of the lower triangular factor L of PAP'.
+*/
+func (F *SparseCholesky) L() *CSCMatrix { return F.l.Copy() }
+
+/*
+Returns the fill-reducing permutation: row and column k of PAP' are row and
+column Perm()[k] of A.
+*/
+func (F *SparseCholesky) Perm() []uint { return append([]uint(nil), F.perm...) }
+
+/*
+The dimension of the factorized matrix.
+*/
+func (F *SparseCholesky) Size() uint { return F.l.rows }
+
+/*
+The number of non-zeros of L, which includes the fill-in.
+*/
+func (F *SparseCholesky) NNZ() uint { return F.l.NNZ() }
+
+/*
+Returns x such that Ax=b.
+*/
+func (F *SparseCholesky) Solve(b []float64) ([]float64, error) {
+	x := make([]float64, len(b))
+	return x, F.SolveTo(x, b)
+}
+
+/*
+Overwrites x with the solution of Ax=b. x and b may be the same slice.
+*/
+func (F *SparseCholesky) SolveTo(x, b []float64) error {
+	n := F.l.rows
+	if uint(len(b)) != n || uint(len(x)) != n {
+		return ErrorDimensionMismatch
+	}
+	y := make([]float64, n)
+	for k, i := range F.perm {
+		y[k] = b[i]
+	}
+	F.l.lowerSolve(y)
+	F.l.lowerTransSolve(y)
+	for k, i := range F.perm {
+		x[i] = y[k]
+	}
+	return nil
+}
+
+/*
+Returns X such that AX=B, solving for every column of B in turn.
+*/
+func (F *SparseCholesky) SolveMulti(B MatrixRO) (*DenseMatrix, error) {
+	return solveColumns(F.l.rows, B, F.SolveTo)
+}
+
+/*
+The determinant of the factorized matrix.
+*/
+func (F *SparseCholesky) Det() float64 {
+	d := 1.0
+	for j := uint(0); j < F.l.cols; j++ {
+		d *= F.l.values[F.l.indptr[j]]
+	}
+	return d * d
+}
+
+/*
+The natural logarithm of the determinant, which does not overflow for large
+matrices.
+*/
+func (F *SparseCholesky) LogDet() (ld float64) {
+	for j := uint(0); j < F.l.cols; j++ {
+		ld += math.Log(F.l.values[F.l.indptr[j]])
+	}
+	return 2 * ld
+}
+
+// Overwrites x with the solution of Lx=x, where L is lower triangular with
+// the diagonal first in every column.
+func (L *CSCMatrix) lowerSolve(x []float64) {
+	for j := uint(0); j < L.cols; j++ {
+		lo, hi := L.indptr[j], L.indptr[j+1]
+		x[j] /= L.values[lo]
+		for p := lo + 1; p < hi; p++ {
+			x[L.indices[p]] -= L.values[p] * x[j]
+		}
+	}
+}
+
+// Overwrites x with the solution of L'x=x, where L is lower triangular with
+// the diagonal first in every column.
+func (L *CSCMatrix) lowerTransSolve(x []float64) {
+	for j := L.cols; j > 0; j-- {
+		lo, hi := L.indptr[j-1], L.indptr[j]
+		s := x[j-1]
+		for p := lo + 1; p < hi; p++ {
+			s -= L.values[p] * x[L.indices[p]]
+		}
+		x[j-1] = s / L.values[lo]
+	}
+}
+
+// Solves for every column of B with solve, which takes x and b.
+func solveColumns(n uint, B MatrixRO, solve func(x, b []float64) error) (*DenseMatrix, error) {
+	if B.Rows() != n {
+		return nil, ErrorDimensionMismatch
+	}
+	m := B.Cols()
+	X := Zeros(n, m)
+	b := make([]float64, n)
+	var i, j uint
+	for j = 0; j < m; j++ {
+		for i = 0; i < n; i++ {
+			b[i] = B.Get(i, j)
+		}
+		if err := solve(b, b); err != nil {
+			return nil, err
+		}
+		for i = 0; i < n; i++ {
+			X.elements[i*X.step+j] = b[i]
+		}
+	}
+	return X, nil
+}
@@ -0,0 +1,65 @@
+package math
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSparseCholesky(t *testing.T) {
+	A := laplacian2D(12, 0).CSC()
+	n := A.Rows()
+	b := make([]float64, n)
+	for i := range b {
+		b[i] = float64(i%7) - 3
+	}
+	C, err := A.DenseMatrix().Cholesky()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logdet := C.LogDet()
+
+	fill := make(map[Ordering]uint)
+	for _, order := range []Ordering{NaturalOrdering, AMDOrdering, RCMOrdering} {
+		F, err := A.Cholesky(order)
+		if err != nil {
+			t.Fatalf("ordering %d: %v", order, err)
+		}
+		fill[order] = F.NNZ()
+		x, err := F.Solve(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, _ := A.MulVec(x)
+		for i := range r {
+			r[i] -= b[i]
+		}
+		if vecNorm(r) > 1e-10*vecNorm(b) {
+			t.Errorf("ordering %d: residual %g", order, vecNorm(r))
+		}
+		if math.Abs(F.LogDet()-logdet) > 1e-9*math.Abs(logdet) {
+			t.Errorf("ordering %d: logdet %v, expected %v", order, F.LogDet(), logdet)
+		}
+	}
+	if fill[AMDOrdering] >= fill[NaturalOrdering] {
+		t.Errorf("AMD fill %d, natural %d", fill[AMDOrdering], fill[NaturalOrdering])
+	}
+
+	// PAP' = LL'
+	F, _ := A.Cholesky(AMDOrdering)
+	L := F.L().DenseMatrix()
+	P := Zeros(n, n)
+	for k, i := range F.Perm() {
+		P.Set(uint(k), i, 1)
+	}
+	PAP := Product(P, A, P.Transpose())
+	if !ApproxEquals(Product(L, L.Transpose()), PAP, 1e-10) {
+		t.Error("LL' != PAP'")
+	}
+}
+
+func TestSparseCholeskyNotSPD(t *testing.T) {
+	A := MakeDenseMatrix([]float64{1, 2, 2, 1}, 2, 2).SparseMatrix()
+	if _, err := A.Cholesky(AMDOrdering); err != ExceptionNotSPD {
+		t.Errorf("expected %v, got %v", ExceptionNotSPD, err)
+	}
+}
@@ -0,0 +1,388 @@
+package math
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// sparse products with fewer stored entries than this run on one goroutine
+const spmvParallelThreshold = 1 << 16
+
+/*
+The storage shared by CSRMatrix and CSCMatrix. The entries of major line k,
+a row of a CSRMatrix or a column of a CSCMatrix, are entries indptr[k] up to
+indptr[k+1] of indices and values. indices holds the minor coordinate of
+each entry and is strictly increasing within a line.
+*/
+type compressed struct {
+	matrix
+
+	indptr  []uint
+	indices []uint
+	values  []float64
+}
+
+// Checks the structure against nmajor lines of length nminor.
+func (C *compressed) validate(nmajor, nminor uint) error {
+	if uint(len(C.indptr)) != nmajor+1 || len(C.indices) != len(C.values) ||
+		C.indptr[0] != 0 || C.indptr[nmajor] != uint(len(C.values)) {
+		return ErrorDimensionMismatch
+	}
+	for k := uint(0); k < nmajor; k++ {
+		lo, hi := C.indptr[k], C.indptr[k+1]
+		if lo > hi || hi > uint(len(C.values)) {
+			return ErrorIllegalIndex
+		}
+		for p := lo; p < hi; p++ {
+			if C.indices[p] >= nminor || (p > lo && C.indices[p] <= C.indices[p-1]) {
+				return ErrorIllegalIndex
+			}
+		}
+	}
+	return nil
+}
+
+/*
+The number of stored entries.
+*/
+func (C *compressed) NNZ() uint { return uint(len(C.values)) }
+
+/*
+Multiplies every stored entry by f.
+*/
+func (C *compressed) Scale(f float64) {
+	if f == 0 {
+		for k := range C.indptr {
+			C.indptr[k] = 0
+		}
+		C.indices = C.indices[:0]
+		C.values = C.values[:0]
+		return
+	}
+	for p := range C.values {
+		C.values[p] *= f
+	}
+}
+
+// The position of the entry at major, minor in indices and values, or where
+// it would be inserted, and whether it is stored.
+func (C *compressed) find(major, minor uint) (uint, bool) {
+	lo, hi := C.indptr[major], C.indptr[major+1]
+	p := lo + uint(sort.Search(int(hi-lo), func(q int) bool { return C.indices[lo+uint(q)] >= minor }))
+	return p, p < hi && C.indices[p] == minor
+}
+
+func (C *compressed) at(major, minor uint) float64 {
+	if p, ok := C.find(major, minor); ok {
+		return C.values[p]
+	}
+	return 0
+}
+
+// Stores v at major, minor, removing the entry if v is zero. Inserting or
+// removing an entry moves every entry after it.
+func (C *compressed) set(major, minor uint, v float64) {
+	p, ok := C.find(major, minor)
+	switch {
+	case ok && v != 0:
+		C.values[p] = v
+		return
+	case ok:
+		C.indices = append(C.indices[:p], C.indices[p+1:]...)
+		C.values = append(C.values[:p], C.values[p+1:]...)
+		for k := major + 1; k < uint(len(C.indptr)); k++ {
+			C.indptr[k]--
+		}
+	case v != 0:
+		C.indices = append(C.indices, 0)
+		C.values = append(C.values, 0)
+		copy(C.indices[p+1:], C.indices[p:])
+		copy(C.values[p+1:], C.values[p:])
+		C.indices[p] = minor
+		C.values[p] = v
+		for k := major + 1; k < uint(len(C.indptr)); k++ {
+			C.indptr[k]++
+		}
+	}
+}
+
+func (C *compressed) copyOf() compressed {
+	D := compressed{matrix: C.matrix}
+	D.indptr = append([]uint(nil), C.indptr...)
+	D.indices = append([]uint(nil), C.indices...)
+	D.values = append([]float64(nil), C.values...)
+	return D
+}
+
+// The storage of the same matrix with major and minor swapped, that is the
+// CSC form of a CSR matrix and vice versa. The result is sorted since the
+// lines are visited in order.
+func (C *compressed) transposed(nminor uint) compressed {
+	D := compressed{matrix: C.matrix}
+	D.indptr = make([]uint, nminor+1)
+	D.indices = make([]uint, len(C.indices))
+	D.values = make([]float64, len(C.values))
+	for _, m := range C.indices {
+		D.indptr[m+1]++
+	}
+	for m := uint(0); m < nminor; m++ {
+		D.indptr[m+1] += D.indptr[m]
+	}
+	next := append([]uint(nil), D.indptr[:nminor]...)
+	for k := 0; k+1 < len(C.indptr); k++ {
+		for p := C.indptr[k]; p < C.indptr[k+1]; p++ {
+			q := next[C.indices[p]]
+			next[C.indices[p]]++
+			D.indices[q] = uint(k)
+			D.values[q] = C.values[p]
+		}
+	}
+	return D
+}
+
+// Adds alpha times B, stored with the same orientation, line by line.
+func (C *compressed) axpy(alpha float64, B *compressed) {
+	nmajor := uint(len(C.indptr) - 1)
+	indptr := make([]uint, nmajor+1)
+	indices := make([]uint, 0, len(C.indices)+len(B.indices))
+	values := make([]float64, 0, len(C.values)+len(B.values))
+	for k := uint(0); k < nmajor; k++ {
+		p, pend := C.indptr[k], C.indptr[k+1]
+		q, qend := B.indptr[k], B.indptr[k+1]
+		for p < pend || q < qend {
+			var m uint
+			var v float64
+			switch {
+			case q == qend || (p < pend && C.indices[p] < B.indices[q]):
+				m, v = C.indices[p], C.values[p]
+				p++
+			case p == pend || B.indices[q] < C.indices[p]:
+				m, v = B.indices[q], alpha*B.values[q]
+				q++
+			default:
+				m, v = C.indices[p], C.values[p]+alpha*B.values[q]
+				p++
+				q++
+			}
+			if v != 0 {
+				indices = append(indices, m)
+				values = append(values, v)
+			}
+		}
+		indptr[k+1] = uint(len(indices))
+	}
+	C.indptr, C.indices, C.values = indptr, indices, values
+}
+
+// Sets y[k] to the dot product of line k with x. Lines are independent, so
+// large matrices are split across goroutines.
+func (C *compressed) gather(y, x []float64) {
+	parLines(C.indptr, spmvParallelThreshold, func(k0, k1 uint) {
+		for k := k0; k < k1; k++ {
+			var s float64
+			for p := C.indptr[k]; p < C.indptr[k+1]; p++ {
+				s += C.values[p] * x[C.indices[p]]
+			}
+			y[k] = s
+		}
+	})
+}
+
+/*
+Runs work over lines 0 up to len(cost)-1, where cost[k] is the cumulative
+cost of the lines before k. If the total cost reaches threshold the lines
+are split into chunks of similar cost, one per goroutine.
+*/
+func parLines(cost []uint, threshold uint, work func(k0, k1 uint)) {
+	n := len(cost) - 1
+	total := cost[n]
+	workers := runtime.GOMAXPROCS(0)
+	if total < threshold || workers == 1 {
+		work(0, uint(n))
+		return
+	}
+	var wg sync.WaitGroup
+	k0 := 0
+	for w := 1; w <= workers; w++ {
+		k1 := n
+		if w < workers {
+			target := total * uint(w) / uint(workers)
+			k1 = sort.Search(n, func(k int) bool { return cost[k] >= target })
+		}
+		if k1 > k0 {
+			wg.Add(1)
+			go func(k0, k1 uint) {
+				defer wg.Done()
+				work(k0, k1)
+			}(uint(k0), uint(k1))
+		}
+		k0 = k1
+	}
+	wg.Wait()
+}
+
+// Removes the entries that are stored as zero.
+func (C *compressed) dropZeros() {
+	end, lo := uint(0), uint(0)
+	for k := 1; k < len(C.indptr); k++ {
+		hi := C.indptr[k]
+		for p := lo; p < hi; p++ {
+			if C.values[p] != 0 {
+				C.indices[end] = C.indices[p]
+				C.values[end] = C.values[p]
+				end++
+			}
+		}
+		lo = hi
+		C.indptr[k] = end
+	}
+	C.indices = C.indices[:end]
+	C.values = C.values[:end]
+}
+
+// Sets y to the sum of x[k] times line k.
+func (C *compressed) scatter(y, x []float64) {
+	for m := range y {
+		y[m] = 0
+	}
+	for k := 0; k+1 < len(C.indptr); k++ {
+		xk := x[k]
+		if xk == 0 {
+			continue
+		}
+		for p := C.indptr[k]; p < C.indptr[k+1]; p++ {
+			y[C.indices[p]] += C.values[p] * xk
+		}
+	}
+}
+
+// The elements as a dense row-major array; rowMajor tells whether the lines
+// are rows or columns.
+func (C *compressed) array(rowMajor bool) []float64 {
+	a := make([]float64, C.rows*C.cols)
+	for k := 0; k+1 < len(C.indptr); k++ {
+		for p := C.indptr[k]; p < C.indptr[k+1]; p++ {
+			if rowMajor {
+				a[uint(k)*C.cols+C.indices[p]] = C.values[p]
+			} else {
+				a[C.indices[p]*C.cols+uint(k)] = C.values[p]
+			}
+		}
+	}
+	return a
+}
+
+/*
+Compresses the triplets (major[p], minor[p], vals[p]) into nmajor lines.
+Duplicates are summed and zeros dropped. The inputs are left untouched.
+*/
+func compressTriplets(nmajor uint, major, minor []uint, vals []float64) compressed {
+	var C compressed
+	count := make([]uint, nmajor+1)
+	for _, k := range major {
+		count[k+1]++
+	}
+	for k := uint(0); k < nmajor; k++ {
+		count[k+1] += count[k]
+	}
+
+	// bucket by line, then sort each line
+	next := append([]uint(nil), count[:nmajor]...)
+	idx := make([]uint, len(vals))
+	val := make([]float64, len(vals))
+	for p, k := range major {
+		q := next[k]
+		next[k]++
+		idx[q] = minor[p]
+		val[q] = vals[p]
+	}
+
+	// compacting in place is safe, each entry read is written at most once
+	// and never ahead of the read position
+	C.indptr = make([]uint, nmajor+1)
+	C.indices = idx[:0]
+	C.values = val[:0]
+	for k := uint(0); k < nmajor; k++ {
+		lo, hi := count[k], count[k+1]
+		sort.Sort(byIndex{idx[lo:hi], val[lo:hi]})
+		start := uint(len(C.indices))
+		for p := lo; p < hi; p++ {
+			n := uint(len(C.indices))
+			if n > start && C.indices[n-1] == idx[p] {
+				C.values[n-1] += val[p]
+				continue
+			}
+			C.indices = append(C.indices, idx[p])
+			C.values = append(C.values, val[p])
+		}
+		end := start
+		for p := start; p < uint(len(C.indices)); p++ {
+			if C.values[p] != 0 {
+				C.indices[end] = C.indices[p]
+				C.values[end] = C.values[p]
+				end++
+			}
+		}
+		C.indices = C.indices[:end]
+		C.values = C.values[:end]
+		C.indptr[k+1] = end
+	}
+	return C
+}
+
+// The non-zero elements of A as triplets.
+func triplets(A MatrixRO) (is, js []uint, vs []float64) {
+	add := func(i, j uint, v float64) {
+		if v != 0 {
+			is = append(is, i)
+			js = append(js, j)
+			vs = append(vs, v)
+		}
+	}
+	switch Am := A.(type) {
+	case *SparseMatrix:
+		for index, value := range Am.elements {
+			if i, j, err := Am.GetRowColIndex(index); err == nil {
+				add(i, j, value)
+			}
+		}
+	case *CSRMatrix:
+		for k := 0; k+1 < len(Am.indptr); k++ {
+			for p := Am.indptr[k]; p < Am.indptr[k+1]; p++ {
+				add(uint(k), Am.indices[p], Am.values[p])
+			}
+		}
+	case *CSCMatrix:
+		for k := 0; k+1 < len(Am.indptr); k++ {
+			for p := Am.indptr[k]; p < Am.indptr[k+1]; p++ {
+				add(Am.indices[p], uint(k), Am.values[p])
+			}
+		}
+	case *SparseMatrix32:
+		for index, value := range Am.elements {
+			add(index/Am.cols, index%Am.cols, float64(value))
+		}
+	default:
+		var i, j uint
+		for i = 0; i < A.Rows(); i++ {
+			for j = 0; j < A.Cols(); j++ {
+				add(i, j, A.Get(i, j))
+			}
+		}
+	}
+	return
+}
+
+// Sorts parallel index and value slices by index.
+type byIndex struct {
+	idx []uint
+	val []float64
+}
+
+func (b byIndex) Len() int           { return len(b.idx) }
+func (b byIndex) Less(p, q int) bool { return b.idx[p] < b.idx[q] }
+func (b byIndex) Swap(p, q int) {
+	b.idx[p], b.idx[q] = b.idx[q], b.idx[p]
+	b.val[p], b.val[q] = b.val[q], b.val[p]
+}
@@ -0,0 +1,189 @@
+package math
+
+/*
+A sparse matrix in compressed sparse column form: the non-zeros are stored
+column after column, each column sorted by row. Column traversal and the
+product A'x are fast; see CSRMatrix for the costs of Get and Set.
+*/
+type CSCMatrix struct {
+	compressed
+}
+
+/*
+Makes a CSCMatrix from its raw arrays, which are not copied. The rows of
+column j are indices[indptr[j]:indptr[j+1]], strictly increasing, and values
+holds the corresponding elements.
+*/
+func MakeCSCMatrix(rows, cols uint, indptr, indices []uint, values []float64) (*CSCMatrix, error) {
+	A := new(CSCMatrix)
+	A.rows = rows
+	A.cols = cols
+	A.indptr = indptr
+	A.indices = indices
+	A.values = values
+	if err := A.validate(cols, rows); err != nil {
+		return nil, err
+	}
+	return A, nil
+}
+
+func ZerosCSC(rows, cols uint) *CSCMatrix {
+	A := new(CSCMatrix)
+	A.rows = rows
+	A.cols = cols
+	A.indptr = make([]uint, cols+1)
+	return A
+}
+
+/*
+Returns the CSC form of A, see MakeCSRCopy.
+*/
+func MakeCSCCopy(A MatrixRO) *CSCMatrix {
+	B := new(CSCMatrix)
+	switch Am := A.(type) {
+	case *CSCMatrix:
+		B.compressed = Am.copyOf()
+	case *CSRMatrix:
+		B.compressed = Am.transposed(Am.cols)
+	default:
+		is, js, vs := triplets(A)
+		B.compressed = compressTriplets(A.Cols(), js, is, vs)
+	}
+	B.rows = A.Rows()
+	B.cols = A.Cols()
+	return B
+}
+
+/*
+Returns the row indices and values of column j. The slices reference the
+matrix storage.
+*/
+func (A *CSCMatrix) Col(j uint) (rows []uint, values []float64) {
+	if j >= A.cols {
+		panic(ErrorIllegalIndex)
+	}
+	lo, hi := A.indptr[j], A.indptr[j+1]
+	return A.indices[lo:hi], A.values[lo:hi]
+}
+
+func (A *CSCMatrix) Get(i, j uint) float64 {
+	if i >= A.rows || j >= A.cols {
+		panic(ErrorIllegalIndex)
+	}
+	return A.at(j, i)
+}
+
+/*
+Sets the element at i, j; setting zero removes it. Panics with
+ErrorIllegalIndex if the index is out of bounds.
+*/
+func (A *CSCMatrix) Set(i, j uint, v float64) {
+	if i >= A.rows || j >= A.cols {
+		panic(ErrorIllegalIndex)
+	}
+	A.set(j, i, v)
+}
+
+func (A *CSCMatrix) Add(B MatrixRO) error {
+	return A.addScaled(1, B)
+}
+
+func (A *CSCMatrix) Subtract(B MatrixRO) error {
+	return A.addScaled(-1, B)
+}
+
+func (A *CSCMatrix) addScaled(alpha float64, B MatrixRO) error {
+	if A.rows != B.Rows() || A.cols != B.Cols() {
+		return ErrorDimensionMismatch
+	}
+	Bc, ok := B.(*CSCMatrix)
+	if !ok {
+		Bc = MakeCSCCopy(B)
+	}
+	A.axpy(alpha, &Bc.compressed)
+	return nil
+}
+
+/*
+Returns y = Ax.
+*/
+func (A *CSCMatrix) MulVec(x []float64) ([]float64, error) {
+	y := make([]float64, A.rows)
+	return y, A.MulVecTo(y, x, false)
+}
+
+/*
+Returns y = A'x, without forming the transpose.
+*/
+func (A *CSCMatrix) MulVecTrans(x []float64) ([]float64, error) {
+	y := make([]float64, A.cols)
+	return y, A.MulVecTo(y, x, true)
+}
+
+/*
+Overwrites y with Ax, or with A'x if trans is true. y must not share storage
+with x. Large products run on several goroutines when trans is set.
+*/
+func (A *CSCMatrix) MulVecTo(y, x []float64, trans bool) error {
+	m, n := A.rows, A.cols
+	if trans {
+		m, n = n, m
+	}
+	if uint(len(x)) != n || uint(len(y)) != m {
+		return ErrorDimensionMismatch
+	}
+	if trans {
+		A.gather(y, x)
+	} else {
+		A.scatter(y, x)
+	}
+	return nil
+}
+
+/*
+Returns the transpose, also in CSC form.
+*/
+func (A *CSCMatrix) Transpose() *CSCMatrix {
+	B := &CSCMatrix{A.transposed(A.rows)}
+	B.rows, B.cols = A.cols, A.rows
+	return B
+}
+
+/*
+Returns the CSR form of this matrix.
+*/
+func (A *CSCMatrix) CSR() *CSRMatrix { return MakeCSRCopy(A) }
+
+func (A *CSCMatrix) Copy() *CSCMatrix { return MakeCSCCopy(A) }
+
+func (A *CSCMatrix) Det() float64 { return A.DenseMatrix().Det() }
+
+func (A *CSCMatrix) Trace() (res float64) {
+	for i := uint(0); i < minUInt(A.rows, A.cols); i++ {
+		res += A.at(i, i)
+	}
+	return
+}
+
+/*
+Returns the elements as a row-major array. This allocates rows*cols elements.
+*/
+func (A *CSCMatrix) Array() []float64 { return A.array(false) }
+
+func (A *CSCMatrix) Arrays() [][]float64 { return A.DenseMatrix().Arrays() }
+
+func (A *CSCMatrix) DenseMatrix() *DenseMatrix {
+	return MakeDenseMatrix(A.array(false), A.rows, A.cols)
+}
+
+func (A *CSCMatrix) SparseMatrix() *SparseMatrix {
+	B := ZerosSparse(A.rows, A.cols)
+	for j := uint(0); j < A.cols; j++ {
+		for p := A.indptr[j]; p < A.indptr[j+1]; p++ {
+			B.Set(A.indices[p], j, A.values[p])
+		}
+	}
+	return B
+}
+
+func (A *CSCMatrix) String() string { return String(A) }
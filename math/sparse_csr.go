@@ -0,0 +1,193 @@
+package math
+
+/*
+A sparse matrix in compressed sparse row form: the non-zeros are stored row
+after row, each row sorted by column. Row traversal and the product Ax are
+fast and the storage is three flat arrays. Get is a binary search within a
+row, and Set is linear in the number of stored entries when it inserts or
+removes one, so build large matrices with MakeCSRCopy or from triplets
+instead.
+*/
+type CSRMatrix struct {
+	compressed
+}
+
+/*
+Makes a CSRMatrix from its raw arrays, which are not copied. The columns of
+row i are indices[indptr[i]:indptr[i+1]], strictly increasing, and values
+holds the corresponding elements.
+*/
+func MakeCSRMatrix(rows, cols uint, indptr, indices []uint, values []float64) (*CSRMatrix, error) {
+	A := new(CSRMatrix)
+	A.rows = rows
+	A.cols = cols
+	A.indptr = indptr
+	A.indices = indices
+	A.values = values
+	if err := A.validate(rows, cols); err != nil {
+		return nil, err
+	}
+	return A, nil
+}
+
+func ZerosCSR(rows, cols uint) *CSRMatrix {
+	A := new(CSRMatrix)
+	A.rows = rows
+	A.cols = cols
+	A.indptr = make([]uint, rows+1)
+	return A
+}
+
+/*
+Returns the CSR form of A. Map based sparse matrices and CSC matrices are
+converted from their entries, anything else by scanning every element.
+*/
+func MakeCSRCopy(A MatrixRO) *CSRMatrix {
+	B := new(CSRMatrix)
+	switch Am := A.(type) {
+	case *CSRMatrix:
+		B.compressed = Am.copyOf()
+	case *CSCMatrix:
+		B.compressed = Am.transposed(Am.rows)
+	default:
+		is, js, vs := triplets(A)
+		B.compressed = compressTriplets(A.Rows(), is, js, vs)
+	}
+	B.rows = A.Rows()
+	B.cols = A.Cols()
+	return B
+}
+
+/*
+Returns the column indices and values of row i. The slices reference the
+matrix storage.
+*/
+func (A *CSRMatrix) Row(i uint) (cols []uint, values []float64) {
+	if i >= A.rows {
+		panic(ErrorIllegalIndex)
+	}
+	lo, hi := A.indptr[i], A.indptr[i+1]
+	return A.indices[lo:hi], A.values[lo:hi]
+}
+
+func (A *CSRMatrix) Get(i, j uint) float64 {
+	if i >= A.rows || j >= A.cols {
+		panic(ErrorIllegalIndex)
+	}
+	return A.at(i, j)
+}
+
+/*
+Sets the element at i, j; setting zero removes it. Panics with
+ErrorIllegalIndex if the index is out of bounds.
+*/
+func (A *CSRMatrix) Set(i, j uint, v float64) {
+	if i >= A.rows || j >= A.cols {
+		panic(ErrorIllegalIndex)
+	}
+	A.set(i, j, v)
+}
+
+func (A *CSRMatrix) Add(B MatrixRO) error {
+	return A.addScaled(1, B)
+}
+
+func (A *CSRMatrix) Subtract(B MatrixRO) error {
+	return A.addScaled(-1, B)
+}
+
+func (A *CSRMatrix) addScaled(alpha float64, B MatrixRO) error {
+	if A.rows != B.Rows() || A.cols != B.Cols() {
+		return ErrorDimensionMismatch
+	}
+	Bc, ok := B.(*CSRMatrix)
+	if !ok {
+		Bc = MakeCSRCopy(B)
+	}
+	A.axpy(alpha, &Bc.compressed)
+	return nil
+}
+
+/*
+Returns y = Ax.
+*/
+func (A *CSRMatrix) MulVec(x []float64) ([]float64, error) {
+	y := make([]float64, A.rows)
+	return y, A.MulVecTo(y, x, false)
+}
+
+/*
+Returns y = A'x, without forming the transpose.
+*/
+func (A *CSRMatrix) MulVecTrans(x []float64) ([]float64, error) {
+	y := make([]float64, A.cols)
+	return y, A.MulVecTo(y, x, true)
+}
+
+/*
+Overwrites y with Ax, or with A'x if trans is true. y must not share storage
+with x. Large products run on several goroutines unless trans is set.
+*/
+func (A *CSRMatrix) MulVecTo(y, x []float64, trans bool) error {
+	m, n := A.rows, A.cols
+	if trans {
+		m, n = n, m
+	}
+	if uint(len(x)) != n || uint(len(y)) != m {
+		return ErrorDimensionMismatch
+	}
+	if trans {
+		A.scatter(y, x)
+	} else {
+		A.gather(y, x)
+	}
+	return nil
+}
+
+/*
+Returns the transpose, also in CSR form.
+*/
+func (A *CSRMatrix) Transpose() *CSRMatrix {
+	B := &CSRMatrix{A.transposed(A.cols)}
+	B.rows, B.cols = A.cols, A.rows
+	return B
+}
+
+/*
+Returns the CSC form of this matrix.
+*/
+func (A *CSRMatrix) CSC() *CSCMatrix { return MakeCSCCopy(A) }
+
+func (A *CSRMatrix) Copy() *CSRMatrix { return MakeCSRCopy(A) }
+
+func (A *CSRMatrix) Det() float64 { return A.DenseMatrix().Det() }
+
+func (A *CSRMatrix) Trace() (res float64) {
+	for i := uint(0); i < minUInt(A.rows, A.cols); i++ {
+		res += A.at(i, i)
+	}
+	return
+}
+
+/*
+Returns the elements as a row-major array. This allocates rows*cols elements.
+*/
+func (A *CSRMatrix) Array() []float64 { return A.array(true) }
+
+func (A *CSRMatrix) Arrays() [][]float64 { return A.DenseMatrix().Arrays() }
+
+func (A *CSRMatrix) DenseMatrix() *DenseMatrix {
+	return MakeDenseMatrix(A.array(true), A.rows, A.cols)
+}
+
+func (A *CSRMatrix) SparseMatrix() *SparseMatrix {
+	B := ZerosSparse(A.rows, A.cols)
+	for i := uint(0); i < A.rows; i++ {
+		for p := A.indptr[i]; p < A.indptr[i+1]; p++ {
+			B.Set(i, A.indices[p], A.values[p])
+		}
+	}
+	return B
+}
+
+func (A *CSRMatrix) String() string { return String(A) }
@@ -0,0 +1,124 @@
+package math
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/hezila/hezila/utils"
+)
+
+func TestCSRConversions(t *testing.T) {
+	A := MakeDenseMatrix([]float64{
+		1, 0, 2, 0,
+		0, 0, 0, 3,
+		4, 5, 0, 0}, 3, 4)
+
+	R := A.CSR()
+	utils.Expect(t, "5", R.NNZ())
+	cols, values := R.Row(2)
+	utils.Expect(t, "[0 1]", cols)
+	utils.Expect(t, "[4 5]", values)
+	if !Equals(R, A) || !Equals(R.DenseMatrix(), A) {
+		t.Errorf("R=%v", R)
+	}
+
+	C := R.CSC()
+	rows, _ := C.Col(3)
+	utils.Expect(t, "[1]", rows)
+	if !Equals(C, A) || !Equals(C.CSR(), A) || !Equals(C.Transpose(), A.Transpose()) {
+		t.Errorf("C=%v", C)
+	}
+
+	S := A.SparseMatrix()
+	if !Equals(S.CSR(), A) || !Equals(S.CSC(), A) || !Equals(R.SparseMatrix(), A) {
+		t.Error("conversion through SparseMatrix")
+	}
+
+	R.Set(1, 1, 7)
+	R.Set(0, 0, 0)
+	utils.Expect(t, "5", R.NNZ())
+	utils.Expect(t, "7", R.Get(1, 1))
+	if err := R.Subtract(R.Copy()); err != nil || R.NNZ() != 0 {
+		t.Errorf("R-R=%v, %v", R, err)
+	}
+
+	if _, err := MakeCSRMatrix(2, 2, []uint{0, 2, 1}, []uint{0, 1, 1}, []float64{1, 2, 3}); err == nil {
+		t.Error("accepted decreasing indptr")
+	}
+}
+
+func TestCSRMulVec(t *testing.T) {
+	// large enough for the product to run in parallel
+	rows, cols := uint(2000), uint(300)
+	S := ZerosSparse(rows, cols)
+	for k := 0; k < 80000; k++ {
+		S.Set(uint(rand.Intn(int(rows))), uint(rand.Intn(int(cols))), rand.NormFloat64())
+	}
+	A := S.DenseMatrix()
+	R, C := S.CSR(), S.CSC()
+
+	x := Normals(cols, 1)
+	z := Normals(rows, 1)
+	Ax, _ := A.Times(x)
+	Atz, _ := A.Transpose().Times(z)
+
+	for _, M := range []interface {
+		MulVec([]float64) ([]float64, error)
+		MulVecTrans([]float64) ([]float64, error)
+	}{R, C} {
+		y, err := M.MulVec(x.Array())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ApproxEquals(MakeDenseMatrix(y, rows, 1), Ax, 1e-9) {
+			t.Errorf("%T: Ax mismatch", M)
+		}
+		y, _ = M.MulVecTrans(z.Array())
+		if !ApproxEquals(MakeDenseMatrix(y, cols, 1), Atz, 1e-9) {
+			t.Errorf("%T: A'x mismatch", M)
+		}
+		if _, err := M.MulVec(z.Array()); err != ErrorDimensionMismatch {
+			t.Errorf("%T: %v", M, err)
+		}
+	}
+}
+
+func TestSparseProduct(t *testing.T) {
+	rows, cols := uint(400), uint(300)
+	S := ZerosSparse(rows, cols)
+	for k := 0; k < 6000; k++ {
+		S.Set(uint(rand.Intn(int(rows))), uint(rand.Intn(int(cols))), float64(rand.Intn(5)-2))
+	}
+	A := S.CSR()
+	D := S.DenseMatrix()
+
+	// the Gram matrix is large enough to run in parallel
+	G, err := A.Transpose().TimesCSR(A)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect, _ := D.Transpose().TimesDense(D)
+	if !Equals(G, expect) {
+		t.Error("A'A mismatch")
+	}
+	if bound, _ := EstimateProductNNZ(A.Transpose(), A); bound < G.NNZ() {
+		t.Errorf("estimate %d below nnz %d", bound, G.NNZ())
+	}
+	for _, v := range G.values {
+		if v == 0 {
+			t.Fatal("stored zero")
+		}
+	}
+
+	Gc, _ := A.CSC().Transpose().TimesCSC(A.CSC())
+	if !Equals(Gc, expect) {
+		t.Error("CSC A'A mismatch")
+	}
+	P, _ := S.Transpose().Times(S)
+	if !Equals(P, expect) {
+		t.Error("SparseMatrix A'A mismatch")
+	}
+	if _, err := A.TimesCSR(A); err != ErrorDimensionMismatch {
+		t.Errorf("A*A: %v", err)
+	}
+}
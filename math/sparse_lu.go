@@ -0,0 +1,285 @@
+package math
+
+import (
+	"math"
+	"sort"
+)
+
+/*
+A candidate pivot on the diagonal is taken over the largest in its column
+when it is at least this fraction of it, which keeps the fill-reducing
+ordering intact for diagonally dominant matrices.
+*/
+const sparseLUPivotTol = 0.1
+
+/*
+The sparse LU factorization PAQ = LU of a square matrix, where Q is a
+fill-reducing column ordering and P the row permutation chosen by partial
+pivoting. The factor is computed once and can be reused for any number of
+solves.
+*/
+type SparseLU struct {
+	// unit lower triangular L and upper triangular U, by columns; the
+	// diagonal comes first in the columns of L and last in those of U
+	l, u *CSCMatrix
+
+	// row k of PAQ is row p[k] of A and column k is column q[k]
+	p, q []uint
+}
+
+/*
+Computes the sparse LU factorization of the square matrix A with the given
+ordering, which is applied to the columns. Returns ExceptionSingular if A is
+structurally or numerically singular.
+*/
+func (A *SparseMatrix) LU(order Ordering) (*SparseLU, error) {
+	return A.CSC().LU(order)
+}
+
+/*
+Computes the sparse LU factorization of A, see SparseMatrix.LU.
+*/
+func (A *CSCMatrix) LU(order Ordering) (*SparseLU, error) {
+	if A.rows != A.cols {
+		return nil, ErrorDimensionMismatch
+	}
+	q, err := order.Permutation(A)
+	if err != nil {
+		return nil, err
+	}
+	n := A.rows
+
+	// Left-looking factorization of Gilbert and Peierls: column k of L and U
+	// solves a sparse triangular system with the columns of L so far. Until
+	// the end the rows of L are rows of A, pinv maps them to pivot order.
+	const none = ^uint(0)
+	pinv := make([]uint, n)
+	for i := range pinv {
+		pinv[i] = none
+	}
+	L := &compressed{indptr: make([]uint, n+1)}
+	U := &compressed{indptr: make([]uint, n+1)}
+	nnz := 4*A.NNZ() + n
+	L.indices, L.values = make([]uint, 0, nnz), make([]float64, 0, nnz)
+	U.indices, U.values = make([]uint, 0, nnz), make([]float64, 0, nnz)
+
+	x := make([]float64, n)
+	xi := make([]uint, n)
+	w := &luReach{stack: make([]uint, n), pos: make([]uint, n), mark: make([]bool, n)}
+	for k := uint(0); k < n; k++ {
+		col := q[k]
+		top := w.reach(L, &A.compressed, col, pinv, xi)
+
+		// x = L \ A(:,col) on the pattern xi[top:]
+		for p := A.indptr[col]; p < A.indptr[col+1]; p++ {
+			x[A.indices[p]] = A.values[p]
+		}
+		for _, j := range xi[top:] {
+			J := pinv[j]
+			if J == none {
+				continue
+			}
+			for p := L.indptr[J] + 1; p < L.indptr[J+1]; p++ {
+				x[L.indices[p]] -= L.values[p] * x[j]
+			}
+		}
+
+		// the entries in pivoted rows belong to U, the largest of the others
+		// is the pivot
+		ipiv, a := none, -1.0
+		for _, i := range xi[top:] {
+			if pinv[i] == none {
+				if t := math.Abs(x[i]); t > a {
+					ipiv, a = i, t
+				}
+			} else {
+				U.indices = append(U.indices, pinv[i])
+				U.values = append(U.values, x[i])
+			}
+		}
+		if ipiv == none || a <= 0 {
+			return nil, ExceptionSingular
+		}
+		if pinv[col] == none && math.Abs(x[col]) >= a*sparseLUPivotTol {
+			ipiv = col
+		}
+
+		pivot := x[ipiv]
+		U.indices = append(U.indices, k)
+		U.values = append(U.values, pivot)
+		pinv[ipiv] = k
+		L.indices = append(L.indices, ipiv)
+		L.values = append(L.values, 1)
+		for _, i := range xi[top:] {
+			if pinv[i] == none {
+				L.indices = append(L.indices, i)
+				L.values = append(L.values, x[i]/pivot)
+			}
+			x[i] = 0
+		}
+		L.indptr[k+1] = uint(len(L.values))
+		U.indptr[k+1] = uint(len(U.values))
+	}
+
+	// renumber the rows of L to pivot order and sort every column, which
+	// leaves the diagonal first in L and last in U
+	for p, i := range L.indices {
+		L.indices[p] = pinv[i]
+	}
+	for _, C := range []*compressed{L, U} {
+		for k := uint(0); k < n; k++ {
+			lo, hi := C.indptr[k], C.indptr[k+1]
+			sort.Sort(byIndex{C.indices[lo:hi], C.values[lo:hi]})
+		}
+	}
+
+	F := &SparseLU{l: &CSCMatrix{*L}, u: &CSCMatrix{*U}, q: q}
+	F.l.rows, F.l.cols = n, n
+	F.u.rows, F.u.cols = n, n
+	F.p = invertPermutation(pinv)
+	return F, nil
+}
+
+// Scratch space for the depth first searches of the LU factorization.
+type luReach struct {
+	stack, pos []uint
+	mark       []bool
+}
+
+/*
+The rows that L \ A(:,col) can make non-zero: the nodes reachable from the
+non-zeros of the column in the graph of L, where row j leads to the rows of
+column pinv[j] of L if it has been pivoted. They are left in xi[top:] in
+topological order.
+*/
+func (w *luReach) reach(L, A *compressed, col uint, pinv, xi []uint) (top uint) {
+	const none = ^uint(0)
+	top = uint(len(xi))
+	for p := A.indptr[col]; p < A.indptr[col+1]; p++ {
+		if r := A.indices[p]; !w.mark[r] {
+			// non-recursive depth first search from r
+			head := 0
+			w.stack[0] = r
+			for head >= 0 {
+				j := w.stack[head]
+				J := pinv[j]
+				if !w.mark[j] {
+					w.mark[j] = true
+					if J != none {
+						w.pos[head] = L.indptr[J]
+					}
+				}
+				done := true
+				if J != none {
+					for q := w.pos[head]; q < L.indptr[J+1]; q++ {
+						if i := L.indices[q]; !w.mark[i] {
+							w.pos[head] = q + 1
+							head++
+							w.stack[head] = i
+							done = false
+							break
+						}
+					}
+				}
+				if done {
+					head--
+					top--
+					xi[top] = j
+				}
+			}
+		}
+	}
+	for _, j := range xi[top:] {
+		w.mark[j] = false
+	}
+	return top
+}
+
+/*
+Returns a copy of the unit lower triangular factor L.
+*/
+func (F *SparseLU) L() *CSCMatrix { return F.l.Copy() }
+
+/*
+Returns a copy of the upper triangular factor U.
+*/
+func (F *SparseLU) U() *CSCMatrix { return F.u.Copy() }
+
+/*
+Returns the row permutation: row k of PAQ is row P()[k] of A.
+*/
+func (F *SparseLU) P() []uint { return append([]uint(nil), F.p...) }
+
+/*
+Returns the column permutation: column k of PAQ is column Q()[k] of A.
+*/
+func (F *SparseLU) Q() []uint { return append([]uint(nil), F.q...) }
+
+/*
+The dimension of the factorized matrix.
+*/
+func (F *SparseLU) Size() uint { return F.l.rows }
+
+/*
+The number of non-zeros of L and U together, which includes the fill-in.
+The unit diagonal of L is counted.
+*/
+func (F *SparseLU) NNZ() uint { return F.l.NNZ() + F.u.NNZ() }
+
+/*
+Returns x such that Ax=b.
+*/
+func (F *SparseLU) Solve(b []float64) ([]float64, error) {
+	x := make([]float64, len(b))
+	return x, F.SolveTo(x, b)
+}
+
+/*
+Overwrites x with the solution of Ax=b. x and b may be the same slice.
+*/
+func (F *SparseLU) SolveTo(x, b []float64) error {
+	n := F.l.rows
+	if uint(len(b)) != n || uint(len(x)) != n {
+		return ErrorDimensionMismatch
+	}
+	y := make([]float64, n)
+	for k, i := range F.p {
+		y[k] = b[i]
+	}
+	F.l.lowerSolve(y)
+	F.u.upperSolve(y)
+	for k, j := range F.q {
+		x[j] = y[k]
+	}
+	return nil
+}
+
+/*
+Returns X such that AX=B, solving for every column of B in turn.
+*/
+func (F *SparseLU) SolveMulti(B MatrixRO) (*DenseMatrix, error) {
+	return solveColumns(F.l.rows, B, F.SolveTo)
+}
+
+/*
+The determinant of the factorized matrix.
+*/
+func (F *SparseLU) Det() float64 {
+	d := permutationSign(F.p) * permutationSign(F.q)
+	for j := uint(0); j < F.u.cols; j++ {
+		d *= F.u.values[F.u.indptr[j+1]-1]
+	}
+	return d
+}
+
+// Overwrites x with the solution of Ux=x, where U is upper triangular with
+// the diagonal last in every column.
+func (U *CSCMatrix) upperSolve(x []float64) {
+	for j := U.cols; j > 0; j-- {
+		lo, hi := U.indptr[j-1], U.indptr[j]
+		x[j-1] /= U.values[hi-1]
+		for p := lo; p < hi-1; p++ {
+			x[U.indices[p]] -= U.values[p] * x[j-1]
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package math
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSparseLU(t *testing.T) {
+	A := laplacian2D(12, 0.4).CSC()
+	n := A.Rows()
+	b := make([]float64, n)
+	for i := range b {
+		b[i] = float64(i%5) - 2
+	}
+	for _, order := range []Ordering{NaturalOrdering, AMDOrdering, RCMOrdering} {
+		F, err := A.LU(order)
+		if err != nil {
+			t.Fatalf("ordering %d: %v", order, err)
+		}
+		x, err := F.Solve(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, _ := A.MulVec(x)
+		for i := range r {
+			r[i] -= b[i]
+		}
+		if vecNorm(r) > 1e-10*vecNorm(b) {
+			t.Errorf("ordering %d: residual %g", order, vecNorm(r))
+		}
+	}
+
+	// a zero diagonal needs row pivoting; PAQ = LU
+	D := MakeDenseMatrix([]float64{
+		0, 2, 0, 1,
+		3, 0, 0, 0,
+		0, 1, 0, 4,
+		1, 0, 5, 0}, 4, 4)
+	F, err := D.SparseMatrix().LU(AMDOrdering)
+	if err != nil {
+		t.Fatal(err)
+	}
+	P, Q := Zeros(4, 4), Zeros(4, 4)
+	for k, i := range F.P() {
+		P.Set(uint(k), i, 1)
+	}
+	for k, j := range F.Q() {
+		Q.Set(j, uint(k), 1)
+	}
+	if !ApproxEquals(Product(F.L(), F.U()), Product(P, D, Q), 1e-12) {
+		t.Errorf("L=%v U=%v", F.L(), F.U())
+	}
+	if math.Abs(F.Det()-D.Det()) > 1e-9 {
+		t.Errorf("det=%v, expected %v", F.Det(), D.Det())
+	}
+	B := MakeDenseMatrix([]float64{1, 2, 3, 4, 5, 6, 7, 8}, 4, 2)
+	X, err := F.SolveMulti(B)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ApproxEquals(Product(D, X), B, 1e-12) {
+		t.Errorf("X=%v", X)
+	}
+
+	S := MakeDenseMatrix([]float64{1, 2, 2, 4}, 2, 2).SparseMatrix()
+	if _, err := S.LU(NaturalOrdering); err != ExceptionSingular {
+		t.Errorf("expected %v, got %v", ExceptionSingular, err)
+	}
+}
+
+func TestOrderings(t *testing.T) {
+	// a tridiagonal matrix with its rows and columns shuffled
+	n := uint(30)
+	shuffle := make([]uint, n)
+	for i := range shuffle {
+		shuffle[i] = uint(i*7) % n
+	}
+	A := ZerosSparse(n, n)
+	for i := uint(0); i < n; i++ {
+		A.Set(shuffle[i], shuffle[i], 2)
+		if i > 0 {
+			A.Set(shuffle[i], shuffle[i-1], -1)
+			A.Set(shuffle[i-1], shuffle[i], -1)
+		}
+	}
+
+	for _, order := range []Ordering{NaturalOrdering, AMDOrdering, RCMOrdering} {
+		perm, err := order.Permutation(A)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen := make([]bool, n)
+		for _, i := range perm {
+			if i >= n || seen[i] {
+				t.Fatalf("ordering %d: %v is not a permutation", order, perm)
+			}
+			seen[i] = true
+		}
+	}
+
+	// RCM recovers the tridiagonal ordering
+	perm, _ := RCM(A)
+	pinv := invertPermutation(perm)
+	for i := uint(1); i < n; i++ {
+		if d := int(pinv[shuffle[i]]) - int(pinv[shuffle[i-1]]); d != 1 && d != -1 {
+			t.Errorf("RCM bandwidth %d", d)
+			break
+		}
+	}
+
+	// and with AMD the factor has no fill
+	F, err := A.Cholesky(AMDOrdering)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if F.NNZ() != 2*n-1 {
+		t.Errorf("AMD fill %d", F.NNZ()-(2*n-1))
+	}
+
+	if _, err := RCM(Zeros(2, 3)); err != ErrorDimensionMismatch {
+		t.Error(err)
+	}
+}
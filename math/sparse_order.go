@@ -0,0 +1,357 @@
+package math
+
+import "sort"
+
+/*
+A fill-reducing ordering for the sparse factorizations. The ordering is
+computed from the pattern of A+A', so it is symmetric: row and column k of
+the permuted matrix are row and column perm[k] of A.
+*/
+type Ordering int
+
+const (
+	// factor the matrix as it is
+	NaturalOrdering Ordering = iota
+
+	// approximate minimum degree, usually the least fill
+	AMDOrdering
+
+	// reverse Cuthill-McKee, a small bandwidth
+	RCMOrdering
+)
+
+/*
+Returns the permutation for the ordering: perm[k] is the row and column of
+A that goes to position k.
+*/
+func (o Ordering) Permutation(A MatrixRO) ([]uint, error) {
+	switch o {
+	case NaturalOrdering:
+		if A.Rows() != A.Cols() {
+			return nil, ErrorDimensionMismatch
+		}
+		perm := make([]uint, A.Rows())
+		for k := range perm {
+			perm[k] = uint(k)
+		}
+		return perm, nil
+	case AMDOrdering:
+		return AMD(A)
+	case RCMOrdering:
+		return RCM(A)
+	}
+	return nil, ErrorIllegalArgument
+}
+
+// Returns the inverse of the permutation perm.
+func invertPermutation(perm []uint) []uint {
+	inv := make([]uint, len(perm))
+	for k, i := range perm {
+		inv[i] = uint(k)
+	}
+	return inv
+}
+
+// The sign of the permutation perm, +1 if it is even and -1 if it is odd.
+func permutationSign(perm []uint) float64 {
+	seen := make([]bool, len(perm))
+	sign := 1.0
+	for k := range perm {
+		if seen[k] {
+			continue
+		}
+		// a cycle of length l is l-1 transpositions
+		for i := uint(k); !seen[i]; i = perm[i] {
+			seen[i] = true
+			sign = -sign
+		}
+		sign = -sign
+	}
+	return sign
+}
+
+/*
+The adjacency of the graph of A+A', without self loops, stored like a CSR
+matrix: the neighbours of node i are adj[ptr[i]:ptr[i+1]].
+*/
+func symmetricGraph(A MatrixRO) (ptr, adj []uint, err error) {
+	if A.Rows() != A.Cols() {
+		return nil, nil, ErrorDimensionMismatch
+	}
+	n := A.Rows()
+	is, js, _ := triplets(A)
+	major := make([]uint, 0, 2*len(is))
+	minor := make([]uint, 0, 2*len(is))
+	for p := range is {
+		if is[p] != js[p] {
+			major = append(major, is[p], js[p])
+			minor = append(minor, js[p], is[p])
+		}
+	}
+	ones := make([]float64, len(major))
+	for p := range ones {
+		ones[p] = 1
+	}
+	G := compressTriplets(n, major, minor, ones)
+	return G.indptr, G.indices, nil
+}
+
+/*
+Returns the reverse Cuthill-McKee ordering of the square matrix A, see
+Ordering.Permutation. Every connected component of A+A' is numbered by a
+breadth first search from a pseudo-peripheral node, visiting neighbours by
+increasing degree, and the result is reversed. It keeps the non-zeros close
+to the diagonal, so the factors of a banded matrix stay banded.
+*/
+func RCM(A MatrixRO) ([]uint, error) {
+	ptr, adj, err := symmetricGraph(A)
+	if err != nil {
+		return nil, err
+	}
+	n := uint(len(ptr) - 1)
+	degree := func(i uint) uint { return ptr[i+1] - ptr[i] }
+
+	perm := make([]uint, 0, n)
+	visited := make([]bool, n)
+	level := make([]uint, n)
+
+	// breadth first search from root over the unvisited nodes, appending them
+	// to perm; returns the index of the first node of the last level
+	bfs := func(root uint) uint {
+		start := uint(len(perm))
+		perm = append(perm, root)
+		visited[root] = true
+		level[root] = 0
+		last := start
+		for h := start; h < uint(len(perm)); h++ {
+			i := perm[h]
+			if level[i] != level[perm[last]] {
+				last = h
+			}
+			nbrs := uint(len(perm))
+			for p := ptr[i]; p < ptr[i+1]; p++ {
+				if j := adj[p]; !visited[j] {
+					visited[j] = true
+					level[j] = level[i] + 1
+					perm = append(perm, j)
+				}
+			}
+			added := perm[nbrs:]
+			sort.SliceStable(added, func(s, t int) bool { return degree(added[s]) < degree(added[t]) })
+		}
+		return last
+	}
+	// forgets the nodes perm[start:]
+	undo := func(start uint) {
+		for _, i := range perm[start:] {
+			visited[i] = false
+		}
+		perm = perm[:start]
+	}
+
+	for r := uint(0); r < n; r++ {
+		if visited[r] {
+			continue
+		}
+		// start at a node of least degree in the component
+		start := uint(len(perm))
+		bfs(r)
+		root := r
+		for _, i := range perm[start:] {
+			if degree(i) < degree(root) {
+				root = i
+			}
+		}
+		undo(start)
+
+		// George and Liu: move to a node of least degree in the last level
+		// while that makes the level structure deeper
+		for {
+			last := bfs(root)
+			depth := level[perm[len(perm)-1]]
+			next := perm[last]
+			for _, i := range perm[last:] {
+				if degree(i) < degree(next) {
+					next = i
+				}
+			}
+			undo(start)
+			bfs(next)
+			deeper := level[perm[len(perm)-1]] > depth
+			undo(start)
+			if !deeper {
+				break
+			}
+			root = next
+		}
+		bfs(root)
+	}
+
+	for s, t := 0, len(perm)-1; s < t; s, t = s+1, t-1 {
+		perm[s], perm[t] = perm[t], perm[s]
+	}
+	return perm, nil
+}
+
+/*
+Returns an approximate minimum degree ordering of the square matrix A, see
+Ordering.Permutation. This is the algorithm of Amestoy, Davis and Duff on
+the quotient graph, with their approximate external degrees and element
+absorption, but without supervariable detection: every node is eliminated
+on its own. It usually gives much less fill than RCM in the Cholesky and LU
+factors.
+*/
+func AMD(A MatrixRO) ([]uint, error) {
+	ptr, adj, err := symmetricGraph(A)
+	if err != nil {
+		return nil, err
+	}
+	n := uint(len(ptr) - 1)
+
+	// The quotient graph: vars[i] are the uneliminated neighbours of
+	// variable i and elems[i] the elements it belongs to. Once i is
+	// eliminated it becomes an element and vars[i] lists its variables.
+	vars := make([][]uint, n)
+	elems := make([][]uint, n)
+	deg := make([]uint, n)
+	for i := uint(0); i < n; i++ {
+		vars[i] = append([]uint(nil), adj[ptr[i]:ptr[i+1]]...)
+		deg[i] = uint(len(vars[i]))
+	}
+
+	// variables are kept in doubly linked lists by degree
+	const none = ^uint(0)
+	head := make([]uint, n+1)
+	next := make([]uint, n)
+	prev := make([]uint, n)
+	for d := range head {
+		head[d] = none
+	}
+	insert := func(i uint) {
+		d := deg[i]
+		next[i], prev[i] = head[d], none
+		if head[d] != none {
+			prev[head[d]] = i
+		}
+		head[d] = i
+	}
+	remove := func(i uint) {
+		if prev[i] != none {
+			next[prev[i]] = next[i]
+		} else {
+			head[deg[i]] = next[i]
+		}
+		if next[i] != none {
+			prev[next[i]] = prev[i]
+		}
+	}
+	for i := uint(0); i < n; i++ {
+		insert(i)
+	}
+
+	eliminated := make([]bool, n)
+	absorbed := make([]bool, n)
+	// mark[i] == stamp when i is in the current pivot pattern, and w[e] is
+	// the size of element e outside it when wstamp[e] == stamp
+	mark := make([]uint, n)
+	w := make([]uint, n)
+	wstamp := make([]uint, n)
+
+	perm := make([]uint, 0, n)
+	mindeg := uint(0)
+	for k := uint(0); k < n; k++ {
+		for head[mindeg] == none {
+			mindeg++
+		}
+		p := head[mindeg]
+		remove(p)
+		eliminated[p] = true
+		perm = append(perm, p)
+		stamp := k + 1
+
+		// the pattern of the new element p: its variable neighbours and the
+		// variables of the elements it absorbs
+		mark[p] = stamp
+		var Lp []uint
+		for _, i := range vars[p] {
+			if mark[i] != stamp {
+				mark[i] = stamp
+				Lp = append(Lp, i)
+			}
+		}
+		for _, e := range elems[p] {
+			if absorbed[e] {
+				continue
+			}
+			for _, i := range vars[e] {
+				if !eliminated[i] && mark[i] != stamp {
+					mark[i] = stamp
+					Lp = append(Lp, i)
+				}
+			}
+			absorbed[e] = true
+			vars[e] = nil
+		}
+		vars[p] = Lp
+		elems[p] = nil
+
+		// replace the absorbed elements by p and drop the edges p covers
+		for _, i := range Lp {
+			es := elems[i][:0]
+			for _, e := range elems[i] {
+				if !absorbed[e] {
+					es = append(es, e)
+				}
+			}
+			elems[i] = append(es, p)
+			vs := vars[i][:0]
+			for _, j := range vars[i] {
+				if mark[j] != stamp {
+					vs = append(vs, j)
+				}
+			}
+			vars[i] = vs
+		}
+
+		// |Le \ Lp| for the other elements next to the pattern
+		for _, i := range Lp {
+			for _, e := range elems[i] {
+				if e == p {
+					continue
+				}
+				if wstamp[e] != stamp {
+					wstamp[e] = stamp
+					w[e] = uint(len(vars[e]))
+				}
+				w[e]--
+			}
+		}
+
+		// the approximate degrees; elements inside Lp are absorbed into p
+		nleft := n - k - 1
+		lp := uint(len(Lp))
+		for _, i := range Lp {
+			remove(i)
+			ext := uint(len(vars[i])) + lp - 1
+			es := elems[i][:0]
+			for _, e := range elems[i] {
+				if e != p && w[e] == 0 {
+					absorbed[e] = true
+					vars[e] = nil
+					continue
+				}
+				if e != p {
+					ext += w[e]
+				}
+				es = append(es, e)
+			}
+			elems[i] = es
+			deg[i] = minUInt(nleft-1, minUInt(deg[i]+lp-1, ext))
+			insert(i)
+			if deg[i] < mindeg {
+				mindeg = deg[i]
+			}
+		}
+	}
+	return perm, nil
+}
@@ -0,0 +1,140 @@
+package math
+
+import "sort"
+
+// sparse products with fewer multiply-adds than this run on one goroutine
+const spgemmParallelThreshold = 1 << 16
+
+/*
+An upper bound on the number of non-zeros of AB: the number of multiply-adds
+the product takes, capped at its size. The bound is exact when no two of the
+multiply-adds land on the same element. It costs one pass over the non-zeros
+of A, so it is cheap to check before a large product.
+*/
+func EstimateProductNNZ(A, B *CSRMatrix) (uint, error) {
+	if A.cols != B.rows {
+		return 0, ErrorDimensionMismatch
+	}
+	flops := spgemmFlops(&A.compressed, &B.compressed)
+	return minUInt(flops[len(flops)-1], A.rows*B.cols), nil
+}
+
+/*
+Returns the product AB. The result is computed row by row with Gustavson's
+algorithm: a symbolic pass counts the non-zeros of every row so the result
+is allocated once, then a numeric pass fills it in. Large products are split
+across goroutines in chunks of rows taking a similar number of multiply-adds.
+For a Gram matrix A'A use A.Transpose().TimesCSR(A).
+*/
+func (A *CSRMatrix) TimesCSR(B *CSRMatrix) (*CSRMatrix, error) {
+	if A.cols != B.rows {
+		return nil, ErrorDimensionMismatch
+	}
+	C := &CSRMatrix{spgemm(&A.compressed, &B.compressed, B.cols)}
+	C.rows = A.rows
+	C.cols = B.cols
+	return C, nil
+}
+
+/*
+Returns the product AB, computed column by column, see TimesCSR.
+*/
+func (A *CSCMatrix) TimesCSC(B *CSCMatrix) (*CSCMatrix, error) {
+	if A.cols != B.rows {
+		return nil, ErrorDimensionMismatch
+	}
+	// column j of AB is A times column j of B, which is the row form of
+	// (AB)' = B'A'
+	C := &CSCMatrix{spgemm(&B.compressed, &A.compressed, A.rows)}
+	C.rows = A.rows
+	C.cols = B.cols
+	return C, nil
+}
+
+// The cumulative multiply-adds of the lines of the product of A and B, both
+// stored by rows.
+func spgemmFlops(A, B *compressed) []uint {
+	nmajor := len(A.indptr) - 1
+	flops := make([]uint, nmajor+1)
+	for i := 0; i < nmajor; i++ {
+		f := uint(0)
+		for p := A.indptr[i]; p < A.indptr[i+1]; p++ {
+			k := A.indices[p]
+			f += B.indptr[k+1] - B.indptr[k]
+		}
+		flops[i+1] = flops[i] + f
+	}
+	return flops
+}
+
+// The row form of the product of A and B, both stored by rows, where B has
+// ncols columns.
+func spgemm(A, B *compressed, ncols uint) compressed {
+	nrows := uint(len(A.indptr) - 1)
+	flops := spgemmFlops(A, B)
+	C := compressed{indptr: make([]uint, nrows+1)}
+
+	// mark[j] == i+1 once column j of row i has been seen, so the marks need
+	// no clearing between rows
+	parLines(flops, spgemmParallelThreshold, func(i0, i1 uint) {
+		mark := make([]uint, ncols)
+		for i := i0; i < i1; i++ {
+			n := uint(0)
+			for p := A.indptr[i]; p < A.indptr[i+1]; p++ {
+				k := A.indices[p]
+				for q := B.indptr[k]; q < B.indptr[k+1]; q++ {
+					if j := B.indices[q]; mark[j] != i+1 {
+						mark[j] = i + 1
+						n++
+					}
+				}
+			}
+			C.indptr[i+1] = n
+		}
+	})
+	for i := uint(0); i < nrows; i++ {
+		C.indptr[i+1] += C.indptr[i]
+	}
+	C.indices = make([]uint, C.indptr[nrows])
+	C.values = make([]float64, C.indptr[nrows])
+
+	cancelled := make([]bool, nrows)
+	parLines(flops, spgemmParallelThreshold, func(i0, i1 uint) {
+		mark := make([]uint, ncols)
+		acc := make([]float64, ncols)
+		for i := i0; i < i1; i++ {
+			lo, hi := C.indptr[i], C.indptr[i+1]
+			cols := C.indices[lo:lo]
+			for p := A.indptr[i]; p < A.indptr[i+1]; p++ {
+				a, k := A.values[p], A.indices[p]
+				for q := B.indptr[k]; q < B.indptr[k+1]; q++ {
+					j := B.indices[q]
+					if mark[j] != i+1 {
+						mark[j] = i + 1
+						acc[j] = a * B.values[q]
+						cols = append(cols, j)
+					} else {
+						acc[j] += a * B.values[q]
+					}
+				}
+			}
+			sort.Slice(cols, func(s, t int) bool { return cols[s] < cols[t] })
+			vals := C.values[lo:hi]
+			for t, j := range cols {
+				vals[t] = acc[j]
+				if vals[t] == 0 {
+					cancelled[i] = true
+				}
+			}
+		}
+	})
+
+	// terms that cancelled exactly leave zeros behind
+	for _, c := range cancelled {
+		if c {
+			C.dropZeros()
+			break
+		}
+	}
+	return C
+}
@@ -0,0 +1,176 @@
+package math
+
+import "sync"
+
+/*
+Assembles a sparse matrix from (i, j, v) triplets in coordinate form.
+Triplets may be added in any order and from any number of goroutines, with
+Add and AddBatch, or from one goroutine per TripletShard.
+Duplicates are summed when the matrix is built, and entries that are or sum
+to zero are dropped, so a count matrix is built by adding 1 per event.
+*/
+type TripletBuilder struct {
+	matrix
+
+	mu      sync.Mutex
+	triples tripletBuffer
+	shards  []*TripletShard
+}
+
+// Triplets in three parallel slices.
+type tripletBuffer struct {
+	is, js []uint
+	vs     []float64
+}
+
+func (t *tripletBuffer) append(i, j uint, v float64) {
+	t.is = append(t.is, i)
+	t.js = append(t.js, j)
+	t.vs = append(t.vs, v)
+}
+
+func NewTripletBuilder(rows, cols uint) *TripletBuilder {
+	B := new(TripletBuilder)
+	B.rows = rows
+	B.cols = cols
+	return B
+}
+
+/*
+Adds v at i, j. Safe for concurrent use. Returns ErrorIllegalIndex if the
+index is out of bounds.
+*/
+func (B *TripletBuilder) Add(i, j uint, v float64) error {
+	if i >= B.rows || j >= B.cols {
+		return ErrorIllegalIndex
+	}
+	B.mu.Lock()
+	B.triples.append(i, j, v)
+	B.mu.Unlock()
+	return nil
+}
+
+/*
+Adds vs[p] at is[p], js[p] for every p, taking the lock once. Safe for
+concurrent use. Nothing is added if an index is out of bounds.
+*/
+func (B *TripletBuilder) AddBatch(is, js []uint, vs []float64) error {
+	if len(is) != len(js) || len(is) != len(vs) {
+		return ErrorDimensionMismatch
+	}
+	for p := range is {
+		if is[p] >= B.rows || js[p] >= B.cols {
+			return ErrorIllegalIndex
+		}
+	}
+	B.mu.Lock()
+	B.triples.is = append(B.triples.is, is...)
+	B.triples.js = append(B.triples.js, js...)
+	B.triples.vs = append(B.triples.vs, vs...)
+	B.mu.Unlock()
+	return nil
+}
+
+/*
+A buffer of triplets owned by one goroutine, which adds to it without
+locking. Its triplets are part of every matrix the builder makes afterwards.
+A shard must not be used while the builder makes a matrix or counts its
+triplets with Len.
+*/
+type TripletShard struct {
+	builder *TripletBuilder
+	triples tripletBuffer
+}
+
+/*
+Returns a new shard of the builder, for a goroutine that adds many triplets.
+*/
+func (B *TripletBuilder) Shard() *TripletShard {
+	S := &TripletShard{builder: B}
+	B.mu.Lock()
+	B.shards = append(B.shards, S)
+	B.mu.Unlock()
+	return S
+}
+
+/*
+Adds v at i, j. Returns ErrorIllegalIndex if the index is out of bounds.
+*/
+func (S *TripletShard) Add(i, j uint, v float64) error {
+	if i >= S.builder.rows || j >= S.builder.cols {
+		return ErrorIllegalIndex
+	}
+	S.triples.append(i, j, v)
+	return nil
+}
+
+/*
+The number of triplets added so far, duplicates included. It counts those
+of the shards too, so unlike Add it must not be called while any shard is
+in use.
+*/
+func (B *TripletBuilder) Len() int {
+	B.mu.Lock()
+	defer B.mu.Unlock()
+	n := len(B.triples.vs)
+	for _, S := range B.shards {
+		n += len(S.triples.vs)
+	}
+	return n
+}
+
+// All triplets of the builder and its shards.
+func (B *TripletBuilder) gather() tripletBuffer {
+	B.mu.Lock()
+	defer B.mu.Unlock()
+	if len(B.shards) == 0 {
+		return B.triples
+	}
+	n := len(B.triples.vs)
+	for _, S := range B.shards {
+		n += len(S.triples.vs)
+	}
+	t := tripletBuffer{make([]uint, 0, n), make([]uint, 0, n), make([]float64, 0, n)}
+	t.is = append(t.is, B.triples.is...)
+	t.js = append(t.js, B.triples.js...)
+	t.vs = append(t.vs, B.triples.vs...)
+	for _, S := range B.shards {
+		t.is = append(t.is, S.triples.is...)
+		t.js = append(t.js, S.triples.js...)
+		t.vs = append(t.vs, S.triples.vs...)
+	}
+	return t
+}
+
+/*
+Builds the matrix in CSR form. The builder is left as it is, so more
+triplets can be added and the matrix built again.
+*/
+func (B *TripletBuilder) CSR() *CSRMatrix {
+	t := B.gather()
+	A := &CSRMatrix{compressTriplets(B.rows, t.is, t.js, t.vs)}
+	A.rows = B.rows
+	A.cols = B.cols
+	return A
+}
+
+/*
+Builds the matrix in CSC form, see CSR.
+*/
+func (B *TripletBuilder) CSC() *CSCMatrix {
+	t := B.gather()
+	A := &CSCMatrix{compressTriplets(B.cols, t.js, t.is, t.vs)}
+	A.rows = B.rows
+	A.cols = B.cols
+	return A
+}
+
+/*
+Builds the matrix as a SparseMatrix, see CSR.
+*/
+func (B *TripletBuilder) SparseMatrix() *SparseMatrix { return B.CSR().SparseMatrix() }
+
+/*
+Builds the matrix as a DenseMatrix, see CSR.
+*/
+func (B *TripletBuilder) DenseMatrix() *DenseMatrix { return B.CSR().DenseMatrix() }
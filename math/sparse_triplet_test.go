@@ -0,0 +1,50 @@
+package math
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestTripletBuilder(t *testing.T) {
+	B := NewTripletBuilder(3, 4)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			S := B.Shard()
+			for k := 0; k < 100; k++ {
+				B.Add(0, 1, 1)
+				S.Add(2, 3, 0.5)
+			}
+			// cancels to an explicit zero
+			B.AddBatch([]uint{1, 1}, []uint{2, 2}, []float64{float64(g), -float64(g)})
+		}(g)
+	}
+	wg.Wait()
+
+	if B.Len() != 8*(200+2) {
+		t.Errorf("%d triplets", B.Len())
+	}
+	Ar := MakeDenseMatrix([]float64{
+		0, 800, 0, 0,
+		0, 0, 0, 0,
+		0, 0, 0, 400}, 3, 4)
+	C := B.CSR()
+	if C.NNZ() != 2 || !Equals(C, Ar) {
+		t.Errorf("CSR=%v", C)
+	}
+	if D := B.CSC(); D.NNZ() != 2 || !Equals(D, Ar) {
+		t.Errorf("CSC=%v", D)
+	}
+	if S := B.SparseMatrix(); !Equals(S, Ar) {
+		t.Errorf("SparseMatrix=%v", S)
+	}
+
+	if err := B.Add(3, 0, 1); err != ErrorIllegalIndex {
+		t.Errorf("expected %v, got %v", ErrorIllegalIndex, err)
+	}
+	if err := B.AddBatch([]uint{0}, []uint{0, 1}, []float64{1}); err != ErrorDimensionMismatch {
+		t.Errorf("expected %v, got %v", ErrorDimensionMismatch, err)
+	}
+}
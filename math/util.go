@@ -2,6 +2,9 @@ package math
 
 import "runtime"
 
+// machine epsilon for float64
+const eps = 2.220446049250313e-16
+
 func fibonacci() func() int {
 	var x, y int = -1, 1
 	return func() int {
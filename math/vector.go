@@ -1,16 +1,17 @@
 package math
 
 import (
-	"log"
 	"math"
+	"sort"
 )
 
 type Vector struct {
-	// for dense vector
+	// dense vector: every element; sparse vector: the stored elements
 	values []float64
 
-	// for sparse vector
-	sparse_values map[int]float64
+	// for sparse vector, the index of each stored element, strictly
+	// increasing
+	indexes []int
 
 	// sparsity indicator
 	isSparse bool
@@ -28,16 +29,45 @@ func NewVector(length int) *Vector {
 // Creat a new sparse vector
 func NewSparseVector() *Vector {
 	v := new(Vector)
-	v.sparse_values = make(map[int]float64)
 	v.isSparse = true
 	return v
 }
 
+// Creat a new sparse vector from its non-zero elements, values[k] at
+// indexes[k]. Indexes may come in any order; duplicates are summed and zeros
+// dropped.
+func NewSparseVectorFrom(indexes []int, values []float64) (*Vector, error) {
+	if len(indexes) != len(values) {
+		return nil, ErrorDimensionMismatch
+	}
+	v := NewSparseVector()
+	order := make([]int, len(indexes))
+	for k := range order {
+		if indexes[k] < 0 {
+			return nil, ErrorIllegalIndex
+		}
+		order[k] = k
+	}
+	sort.SliceStable(order, func(s, t int) bool { return indexes[order[s]] < indexes[order[t]] })
+	for _, k := range order {
+		n := len(v.indexes)
+		if n > 0 && v.indexes[n-1] == indexes[k] {
+			v.values[n-1] += values[k]
+			continue
+		}
+		v.indexes = append(v.indexes, indexes[k])
+		v.values = append(v.values, values[k])
+	}
+	v.dropZeros()
+	return v, nil
+}
+
 // Clean the content of the vector
 // (set all of the elements of a dense vector as zero)
 func (v *Vector) Clear() {
 	if v.isSparse {
-		v.sparse_values = make(map[int]float64)
+		v.indexes = v.indexes[:0]
+		v.values = v.values[:0]
 	} else {
 		for i := 0; i < len(v.values); i++ {
 			v.values[i] = 0.0
@@ -47,25 +77,14 @@ func (v *Vector) Clear() {
 
 // Check whether the both vector is homogeneous (both dense or sparse)
 func (v *Vector) IsHomogeneous(that *Vector) bool {
-	if v.isSparse {
-		if that.isSparse {
-			return true
-		} else {
-			return false
-		}
-	} else {
-		if that.isSparse {
-			return false
-		} else {
-			return true
-		}
-	}
+	return v.isSparse == that.isSparse
 }
 
-// Check whether the two vectors (only for dense vectors) have same size;
+// Check whether the two vectors have same size; sparse vectors have no fixed
+// size, so this is false if either of them is sparse.
 func (v *Vector) IsSameSize(o *Vector) bool {
 	if v.isSparse || o.isSparse {
-		log.Fatal("the operation IsSameSize is only for dense vector.")
+		return false
 	}
 	if len(v.values) == len(o.values) {
 		return true
@@ -77,13 +96,16 @@ func (v *Vector) IsSparse() bool {
 	return v.isSparse
 }
 
+// The number of stored elements: the length of a dense vector, the number of
+// non-zeros of a sparse one
+func (v *Vector) NNZ() int {
+	return len(v.values)
+}
+
+// The indexes of the stored elements, in increasing order
 func (v *Vector) Indexes() []int {
 	if v.isSparse {
-		indexes := make([]int, 0, len(v.sparse_values))
-		for k := range v.sparse_values {
-			indexes = append(indexes, k)
-		}
-		return indexes
+		return append([]int(nil), v.indexes...)
 	} else {
 		indexes := make([]int, len(v.values))
 		for i := 0; i < len(v.values); i++ {
@@ -93,119 +115,412 @@ func (v *Vector) Indexes() []int {
 	}
 }
 
-func (v *Vector) Copy(from *Vector) {
+// Check that every element of o fits into v, when v is dense
+func (v *Vector) fits(o *Vector) bool {
 	if v.isSparse {
-		v.sparse_values = make(map[int]float64)
-		for i, va := range from.sparse_values {
-			v.sparse_values[i] = va
-		}
-	} else {
-		if !v.IsSameSize(from) {
-			log.Fatal("cannot perform the copy operation on two different size vectors")
-		}
+		return true
+	}
+	if !o.isSparse {
+		return len(o.values) == len(v.values)
+	}
+	n := len(o.indexes)
+	return n == 0 || o.indexes[n-1] < len(v.values)
+}
 
-		for i := 0; i < len(v.values); i++ {
-			v.values[i] = from.values[i]
+// Copy the elements of from into v, keeping the kind of v. Returns
+// ErrorDimensionMismatch if v is dense and from does not fit into it.
+func (v *Vector) Copy(from *Vector) error {
+	if !v.fits(from) {
+		return ErrorDimensionMismatch
+	}
+	switch {
+	case v.isSparse && from.isSparse:
+		v.indexes = append(v.indexes[:0], from.indexes...)
+		v.values = append(v.values[:0], from.values...)
+	case v.isSparse:
+		v.Clear()
+		for i, value := range from.values {
+			if value != 0 {
+				v.indexes = append(v.indexes, i)
+				v.values = append(v.values, value)
+			}
 		}
+	case from.isSparse:
+		v.Clear()
+		for k, i := range from.indexes {
+			v.values[i] = from.values[k]
+		}
+	default:
+		copy(v.values, from.values)
 	}
+	return nil
+}
+
+// The position of index in a sparse vector, or where it would be inserted,
+// and whether it is stored.
+func (v *Vector) find(index int) (int, bool) {
+	k := sort.SearchInts(v.indexes, index)
+	return k, k < len(v.indexes) && v.indexes[k] == index
 }
 
+// Get the element at index. Panics with ErrorIllegalIndex if index is out of
+// bounds; use GetChecked to get the error instead.
 func (v *Vector) Get(index int) float64 {
-	if v.isSparse {
-		return v.sparse_values[index]
+	value, err := v.GetChecked(index)
+	if err != nil {
+		panic(err)
 	}
+	return value
+}
 
-	if index >= len(v.values) {
-		log.Fatal("index out of bounds in vector")
+// Like Get, but returns ErrorIllegalIndex rather than panicking.
+func (v *Vector) GetChecked(index int) (float64, error) {
+	if index < 0 || (!v.isSparse && index >= len(v.values)) {
+		return 0, ErrorIllegalIndex
+	}
+	if v.isSparse {
+		if k, ok := v.find(index); ok {
+			return v.values[k], nil
+		}
+		return 0, nil
 	}
-	return v.values[index]
+	return v.values[index], nil
 }
 
+// Set the element at index. Panics with ErrorIllegalIndex if index is out of
+// bounds; use SetChecked to get the error instead.
 func (v *Vector) Set(index int, value float64) {
-	if v.isSparse {
-		v.sparse_values[index] = value
-	} else {
-		if index >= len(v.values) {
-			log.Fatal("index out of bounds in vector")
-		}
+	if err := v.SetChecked(index, value); err != nil {
+		panic(err)
+	}
+}
+
+// Like Set, but returns ErrorIllegalIndex rather than panicking. Setting an
+// element of a sparse vector to zero removes it.
+func (v *Vector) SetChecked(index int, value float64) error {
+	if index < 0 || (!v.isSparse && index >= len(v.values)) {
+		return ErrorIllegalIndex
+	}
+	if !v.isSparse {
 		v.values[index] = value
+		return nil
+	}
+	k, ok := v.find(index)
+	switch {
+	case ok && value != 0:
+		v.values[k] = value
+	case ok:
+		v.indexes = append(v.indexes[:k], v.indexes[k+1:]...)
+		v.values = append(v.values[:k], v.values[k+1:]...)
+	case value != 0:
+		v.indexes = append(v.indexes, 0)
+		v.values = append(v.values, 0)
+		copy(v.indexes[k+1:], v.indexes[k:])
+		copy(v.values[k+1:], v.values[k:])
+		v.indexes[k] = index
+		v.values[k] = value
 	}
+	return nil
 }
 
+// Set every stored element, which for a sparse vector leaves the others zero
 func (v *Vector) SetAll(value float64) {
-	if v.isSparse {
-		for i := range v.sparse_values {
-			v.sparse_values[i] = value
-		}
-	} else {
-		for i := 0; i < len(v.values); i++ {
-			v.values[i] = value
-		}
+	if v.isSparse && value == 0 {
+		v.Clear()
+		return
+	}
+	for i := range v.values {
+		v.values[i] = value
 	}
 }
 
-func (v *Vector) SetValues(values []float64) {
-	if len(v.values) != len(values) {
-		log.Fatal("The dimension does not match.")
+// Set the elements 0 up to len(values)-1. A dense vector must have exactly
+// one element per value, or ErrorDimensionMismatch is returned; a sparse
+// vector keeps the non-zero values only.
+func (v *Vector) SetValues(values []float64) error {
+	if !v.isSparse && len(v.values) != len(values) {
+		return ErrorDimensionMismatch
+	}
+	if !v.isSparse {
+		copy(v.values, values)
+		return nil
 	}
-	for i, k := range values {
-		v.values[i] = k
+	v.Clear()
+	for i, value := range values {
+		if value != 0 {
+			v.indexes = append(v.indexes, i)
+			v.values = append(v.values, value)
+		}
 	}
+	return nil
+}
+
+// v_i = v_i + alpha * o_i, see Axpy
+func (v *Vector) Increament(o *Vector, alpha float64) error {
+	return v.Axpy(alpha, o)
 }
 
-// v_i = v_i + alpha * o_i
-func (v *Vector) Increament(o *Vector, alpha float64) {
-	if !v.IsHomogeneous(o) {
-		log.Fatal("cannot perform the increment opertion on two different type of vectors")
+// v_i = v_i + alpha * x_i. Returns ErrorDimensionMismatch if v is dense and
+// x does not fit into it.
+func (v *Vector) Axpy(alpha float64, x *Vector) error {
+	return v.WeightedSum(v, x, 1, alpha)
+}
+
+// v_i = v_i + o_i
+func (v *Vector) Add(o *Vector) error {
+	return v.WeightedSum(v, o, 1, 1)
+}
+
+// v_i = v_i - o_i
+func (v *Vector) Subtract(o *Vector) error {
+	return v.WeightedSum(v, o, 1, -1)
+}
+
+// v_i = v_i * o_i
+func (v *Vector) ElementMult(o *Vector) error {
+	if !v.fits(o) {
+		return ErrorDimensionMismatch
 	}
-	if v.isSparse {
-		for i, value := range o.sparse_values {
-			v.sparse_values[i] += value * alpha
+	switch {
+	case !v.isSparse && !o.isSparse:
+		for i, k := range o.values {
+			v.values[i] *= k
 		}
-	} else {
-		if !v.IsSameSize(o) {
-			log.Fatal("cannot perform the increament operation on two different size vector")
+	case !v.isSparse:
+		// zero where o is not stored
+		k := 0
+		for i := range v.values {
+			if k < len(o.indexes) && o.indexes[k] == i {
+				v.values[i] *= o.values[k]
+				k++
+			} else {
+				v.values[i] = 0
+			}
 		}
-
-		for i, k := range v.values {
-			v.values[i] = k + alpha*o.values[i]
+	case !o.isSparse:
+		if !o.fits(v) {
+			return ErrorDimensionMismatch
 		}
+		for k, i := range v.indexes {
+			v.values[k] *= o.values[i]
+		}
+		v.dropZeros()
+	default:
+		// keep the indexes stored in both
+		end, q := 0, 0
+		for p, i := range v.indexes {
+			for q < len(o.indexes) && o.indexes[q] < i {
+				q++
+			}
+			if q < len(o.indexes) && o.indexes[q] == i {
+				v.indexes[end] = i
+				v.values[end] = v.values[p] * o.values[q]
+				end++
+			}
+		}
+		v.indexes = v.indexes[:end]
+		v.values = v.values[:end]
+		v.dropZeros()
 	}
+	return nil
 }
 
 // norm = \sqrt{sum_i^n{n_i^2}}
 func (v *Vector) Norm() float64 {
-	var square_sum float64
+	// scaled like the BLAS nrm2 so that large and tiny elements do not
+	// overflow or underflow
+	scale, ssq := 0.0, 1.0
 	for _, k := range v.values {
-		square_sum += k * k
+		if k == 0 {
+			continue
+		}
+		a := math.Abs(k)
+		if scale < a {
+			ssq = 1 + ssq*(scale/a)*(scale/a)
+			scale = a
+		} else {
+			ssq += (a / scale) * (a / scale)
+		}
 	}
-	return math.Sqrt(square_sum)
+	return scale * math.Sqrt(ssq)
+}
+
+// norm = sum_i^n{|n_i|}
+func (v *Vector) Norm1() (norm float64) {
+	for _, k := range v.values {
+		norm += math.Abs(k)
+	}
+	return
+}
+
+// norm = max_i{|n_i|}
+func (v *Vector) NormInf() (norm float64) {
+	for _, k := range v.values {
+		norm = math.Max(norm, math.Abs(k))
+	}
+	return
 }
 
 // v_i = v_i * scale
 func (v *Vector) Scale(scale float64) {
+	if v.isSparse && scale == 0 {
+		v.Clear()
+		return
+	}
 	for i, k := range v.values {
 		v.values[i] = k * scale
 	}
 }
 
-func (v *Vector) WeightedSum(va, vb *Vector, a, b float64) {
-	if !v.IsSameSize(va) || !v.IsSameSize(vb) {
-		log.Fatal("The dimensions do not match with each other.")
+// v_i = a * va_i + b * vb_i, keeping the kind of v. Returns
+// ErrorDimensionMismatch if v is dense and va or vb does not fit into it.
+func (v *Vector) WeightedSum(va, vb *Vector, a, b float64) error {
+	if !v.fits(va) || !v.fits(vb) {
+		return ErrorDimensionMismatch
+	}
+	if v.isSparse {
+		if !va.isSparse || !vb.isSparse {
+			// a dense operand makes the result dense in general, so sum
+			// into a dense vector of its length
+			n := len(va.values)
+			if va.isSparse {
+				n = len(vb.values)
+			}
+			d := NewVector(n)
+			if err := d.WeightedSum(va, vb, a, b); err != nil {
+				return err
+			}
+			return v.Copy(d)
+		}
+		v.indexes, v.values = mergeSparse(va, vb, a, b)
+		return nil
+	}
+
+	// v is dense; va or vb may be v itself, so scale it in place first
+	switch {
+	case va == v && vb == v:
+		v.Scale(a + b)
+		return nil
+	case va == v:
+		v.Scale(a)
+		v.addScaled(b, vb)
+	case vb == v:
+		v.Scale(b)
+		v.addScaled(a, va)
+	default:
+		v.Clear()
+		v.addScaled(a, va)
+		v.addScaled(b, vb)
+	}
+	return nil
+}
+
+// v_i += alpha * o_i for a dense v that o fits into
+func (v *Vector) addScaled(alpha float64, o *Vector) {
+	if o.isSparse {
+		for k, i := range o.indexes {
+			v.values[i] += alpha * o.values[k]
+		}
+		return
 	}
-	for i := 0; i < len(v.values); i++ {
-		v.values[i] = a*va.values[i] + b*vb.values[i]
+	for i, k := range o.values {
+		v.values[i] += alpha * k
 	}
 }
 
+// The sum a*x + b*y of two sparse vectors, merging their sorted indexes.
+// Zeros are dropped.
+func mergeSparse(x, y *Vector, a, b float64) ([]int, []float64) {
+	n := len(x.indexes) + len(y.indexes)
+	indexes := make([]int, 0, n)
+	values := make([]float64, 0, n)
+	p, q := 0, 0
+	for p < len(x.indexes) || q < len(y.indexes) {
+		var i int
+		var value float64
+		switch {
+		case q == len(y.indexes) || (p < len(x.indexes) && x.indexes[p] < y.indexes[q]):
+			i, value = x.indexes[p], a*x.values[p]
+			p++
+		case p == len(x.indexes) || y.indexes[q] < x.indexes[p]:
+			i, value = y.indexes[q], b*y.values[q]
+			q++
+		default:
+			i, value = x.indexes[p], a*x.values[p]+b*y.values[q]
+			p++
+			q++
+		}
+		if value != 0 {
+			indexes = append(indexes, i)
+			values = append(values, value)
+		}
+	}
+	return indexes, values
+}
+
+// Removes the stored zeros of a sparse vector.
+func (v *Vector) dropZeros() {
+	end := 0
+	for k, value := range v.values {
+		if value != 0 {
+			v.indexes[end] = v.indexes[k]
+			v.values[end] = value
+			end++
+		}
+	}
+	v.indexes = v.indexes[:end]
+	v.values = v.values[:end]
+}
+
+// Panics with ErrorDimensionMismatch if a dense operand does not fit into
+// the other one. Sparse-sparse products merge the sorted indexes.
 func (v *Vector) Dot(o *Vector) float64 {
-	if !v.IsSameSize(o) {
-		log.Fatal("the dot cannot take place on two diffent size vector")
+	switch {
+	case !v.isSparse && !o.isSparse:
+		if !v.IsSameSize(o) {
+			panic(ErrorDimensionMismatch)
+		}
+		var result float64
+		for i, k := range v.values {
+			result += k * o.values[i]
+		}
+		return result
+	case !v.isSparse:
+		return o.Dot(v)
+	case !o.isSparse:
+		if !o.fits(v) {
+			panic(ErrorDimensionMismatch)
+		}
+		var result float64
+		for k, i := range v.indexes {
+			result += v.values[k] * o.values[i]
+		}
+		return result
 	}
 
 	var result float64
-	for i, k := range v.values {
-		result += k + o.values[i]
+	p, q := 0, 0
+	for p < len(v.indexes) && q < len(o.indexes) {
+		switch {
+		case v.indexes[p] < o.indexes[q]:
+			p++
+		case o.indexes[q] < v.indexes[p]:
+			q++
+		default:
+			result += v.values[p] * o.values[q]
+			p++
+			q++
+		}
 	}
 	return result
 }
+
+// The cosine of the angle between v and o, zero if either is zero. Panics
+// like Dot.
+func (v *Vector) CosineSimilarity(o *Vector) float64 {
+	d := v.Dot(o)
+	if d == 0 {
+		return 0
+	}
+	return d / v.Norm() / o.Norm()
+}
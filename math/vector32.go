@@ -0,0 +1,215 @@
+package math
+
+import (
+	"math"
+	"sort"
+)
+
+/*
+A dense or sparse vector of float32 elements, stored like Vector. Dot and
+Norm accumulate in float64. The rest of the vector algebra is on Vector;
+convert with Vector and Vector32.
+*/
+type Vector32 struct {
+	// dense vector: every element; sparse vector: the stored elements
+	values []float32
+
+	// for sparse vector, the index of each stored element, strictly
+	// increasing
+	indexes []int
+
+	// sparsity indicator
+	isSparse bool
+}
+
+// Creat a new dense vector
+func NewVector32(length int) *Vector32 {
+	return &Vector32{values: make([]float32, length)}
+}
+
+// Creat a new sparse vector
+func NewSparseVector32() *Vector32 {
+	return &Vector32{isSparse: true}
+}
+
+// Returns this vector rounded to float32, of the same kind. Elements of a
+// sparse vector that round to zero are dropped.
+func (v *Vector) Vector32() *Vector32 {
+	w := &Vector32{values: make([]float32, 0, len(v.values)), isSparse: v.isSparse}
+	for k, value := range v.values {
+		f := float32(value)
+		if v.isSparse {
+			if f == 0 {
+				continue
+			}
+			w.indexes = append(w.indexes, v.indexes[k])
+		}
+		w.values = append(w.values, f)
+	}
+	return w
+}
+
+// Returns the elements in float64, as a vector of the same kind
+func (v *Vector32) Vector() *Vector {
+	w := &Vector{values: make([]float64, len(v.values)), isSparse: v.isSparse}
+	for k, value := range v.values {
+		w.values[k] = float64(value)
+	}
+	if v.isSparse {
+		w.indexes = append([]int(nil), v.indexes...)
+	}
+	return w
+}
+
+func (v *Vector32) IsSparse() bool {
+	return v.isSparse
+}
+
+// The number of stored elements, see Vector.NNZ
+func (v *Vector32) NNZ() int {
+	return len(v.values)
+}
+
+// The stored elements; the slice references the vector data
+func (v *Vector32) Values32() []float32 {
+	return v.values
+}
+
+// The position of index in a sparse vector, see Vector.find
+func (v *Vector32) find(index int) (int, bool) {
+	k := sort.SearchInts(v.indexes, index)
+	return k, k < len(v.indexes) && v.indexes[k] == index
+}
+
+// Get the element at index. Panics with ErrorIllegalIndex if index is out of
+// bounds.
+func (v *Vector32) Get(index int) float32 {
+	if index < 0 || (!v.isSparse && index >= len(v.values)) {
+		panic(ErrorIllegalIndex)
+	}
+	if !v.isSparse {
+		return v.values[index]
+	}
+	if k, ok := v.find(index); ok {
+		return v.values[k]
+	}
+	return 0
+}
+
+// Set the element at index, see Vector.SetChecked. Panics with
+// ErrorIllegalIndex if index is out of bounds.
+func (v *Vector32) Set(index int, value float32) {
+	if index < 0 || (!v.isSparse && index >= len(v.values)) {
+		panic(ErrorIllegalIndex)
+	}
+	if !v.isSparse {
+		v.values[index] = value
+		return
+	}
+	k, ok := v.find(index)
+	switch {
+	case ok && value != 0:
+		v.values[k] = value
+	case ok:
+		v.indexes = append(v.indexes[:k], v.indexes[k+1:]...)
+		v.values = append(v.values[:k], v.values[k+1:]...)
+	case value != 0:
+		v.indexes = append(v.indexes, 0)
+		v.values = append(v.values, 0)
+		copy(v.indexes[k+1:], v.indexes[k:])
+		copy(v.values[k+1:], v.values[k:])
+		v.indexes[k] = index
+		v.values[k] = value
+	}
+}
+
+// Panics with ErrorDimensionMismatch like Vector.Dot.
+func (v *Vector32) Dot(o *Vector32) float64 {
+	switch {
+	case !v.isSparse && !o.isSparse:
+		if len(v.values) != len(o.values) {
+			panic(ErrorDimensionMismatch)
+		}
+		var result float64
+		for i, k := range v.values {
+			result += float64(k) * float64(o.values[i])
+		}
+		return result
+	case !v.isSparse:
+		return o.Dot(v)
+	case !o.isSparse:
+		if n := len(v.indexes); n > 0 && v.indexes[n-1] >= len(o.values) {
+			panic(ErrorDimensionMismatch)
+		}
+		var result float64
+		for k, i := range v.indexes {
+			result += float64(v.values[k]) * float64(o.values[i])
+		}
+		return result
+	}
+
+	var result float64
+	p, q := 0, 0
+	for p < len(v.indexes) && q < len(o.indexes) {
+		switch {
+		case v.indexes[p] < o.indexes[q]:
+			p++
+		case o.indexes[q] < v.indexes[p]:
+			q++
+		default:
+			result += float64(v.values[p]) * float64(o.values[q])
+			p++
+			q++
+		}
+	}
+	return result
+}
+
+// norm = \sqrt{sum_i^n{n_i^2}}; no element squared in float64 overflows
+func (v *Vector32) Norm() float64 {
+	var ssq float64
+	for _, k := range v.values {
+		ssq += float64(k) * float64(k)
+	}
+	return math.Sqrt(ssq)
+}
+
+// v_i = v_i * scale
+func (v *Vector32) Scale(scale float32) {
+	if v.isSparse && scale == 0 {
+		v.values, v.indexes = nil, nil
+		return
+	}
+	for i, k := range v.values {
+		v.values[i] = k * scale
+	}
+}
+
+// v_i = v_i + alpha * x_i. Returns ErrorDimensionMismatch if v is dense and
+// x does not fit into it.
+func (v *Vector32) Axpy(alpha float32, x *Vector32) error {
+	if v.isSparse {
+		w := v.Vector()
+		if err := w.Axpy(float64(alpha), x.Vector()); err != nil {
+			return err
+		}
+		*v = *w.Vector32()
+		return nil
+	}
+	if x.isSparse {
+		if n := len(x.indexes); n > 0 && x.indexes[n-1] >= len(v.values) {
+			return ErrorDimensionMismatch
+		}
+		for k, i := range x.indexes {
+			v.values[i] += alpha * x.values[k]
+		}
+		return nil
+	}
+	if len(x.values) != len(v.values) {
+		return ErrorDimensionMismatch
+	}
+	for i, k := range x.values {
+		v.values[i] += alpha * k
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package math
 
 import (
+	"math"
 	"testing"
 
 	"github.com/hezila/hezila/utils"
@@ -11,3 +12,81 @@ func TestCopy(t *testing.T) {
 	va.SetValues([]float64{1, 2, 3})
 	utils.Expect(t, "1", va.Get(0))
 }
+
+func TestVectorErrors(t *testing.T) {
+	va := NewVector(3)
+	if err := va.SetValues([]float64{1, 2}); err != ErrorDimensionMismatch {
+		t.Errorf("SetValues: %v", err)
+	}
+	if _, err := va.GetChecked(3); err != ErrorIllegalIndex {
+		t.Errorf("GetChecked: %v", err)
+	}
+	vs := NewSparseVector()
+	vs.Set(3, 1)
+	if err := va.Increament(vs, 1); err != ErrorDimensionMismatch {
+		t.Errorf("Increament: %v", err)
+	}
+	err := Try(func() { va.Dot(NewVector(2)) })
+	utils.Expect(t, "Input dimensions do not match", err)
+}
+
+func TestVectorAlgebra(t *testing.T) {
+	// x = (1, 0, 2, 0, -3), y = (0, 4, 1, 0, 2) as dense and sparse vectors
+	xd, yd := NewVector(5), NewVector(5)
+	xd.SetValues([]float64{1, 0, 2, 0, -3})
+	yd.SetValues([]float64{0, 4, 1, 0, 2})
+	xs, _ := NewSparseVectorFrom([]int{4, 0, 2, 0}, []float64{-3, 2, 2, -1})
+	ys := NewSparseVector()
+	ys.SetValues([]float64{0, 4, 1, 0, 2})
+	utils.Expect(t, "[0 2 4]", xs.Indexes())
+
+	for _, x := range []*Vector{xd, xs} {
+		for _, y := range []*Vector{yd, ys} {
+			utils.Expect(t, "-4", x.Dot(y))
+			utils.ExpectNear(t, -4/math.Sqrt(14)/math.Sqrt(21), x.CosineSimilarity(y), 1e-15)
+
+			for _, w := range []*Vector{NewVector(5), NewSparseVector()} {
+				w.Copy(x)
+				if err := w.Axpy(2, y); err != nil {
+					t.Fatal(err)
+				}
+				utils.Expect(t, "[1 8 4 0 1]", dense(w, 5))
+
+				if err := w.ElementMult(y); err != nil {
+					t.Fatal(err)
+				}
+				utils.Expect(t, "[0 32 4 0 2]", dense(w, 5))
+			}
+
+			d := NewVector(5)
+			if err := d.WeightedSum(x, y, 1, -1); err != nil {
+				t.Fatal(err)
+			}
+			utils.Expect(t, "[1 -4 1 0 -5]", dense(d, 5))
+		}
+		utils.Expect(t, "6", x.Norm1())
+		utils.Expect(t, "3", x.NormInf())
+		utils.ExpectNear(t, math.Sqrt(14), x.Norm(), 1e-15)
+	}
+
+	// the sparse sum keeps no zeros
+	s := NewSparseVector()
+	s.Copy(xs)
+	s.Subtract(xs)
+	utils.Expect(t, "0", s.NNZ())
+
+	long := NewSparseVector()
+	long.Set(9, 1)
+	if err := Try(func() { xd.Dot(long) }); err != ErrorDimensionMismatch {
+		t.Errorf("Dot: %v", err)
+	}
+}
+
+// The first n elements of v.
+func dense(v *Vector, n int) []float64 {
+	d := make([]float64, n)
+	for i := range d {
+		d[i] = v.Get(i)
+	}
+	return d
+}